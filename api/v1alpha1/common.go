@@ -1,8 +1,15 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -45,13 +52,141 @@ type RunHistoryPolicy struct {
 type RemediationStrategy struct {
 	AutoApply                *bool                      `json:"autoApply,omitempty"`
 	ApplyWithoutPlanArtifact *bool                      `json:"applyWithoutPlanArtifact,omitempty"`
+	DestroyOnDelete          *bool                      `json:"destroyOnDelete,omitempty"`
 	OnError                  OnErrorRemediationStrategy `json:"onError,omitempty"`
+	Approval                 ApprovalPolicy             `json:"approval,omitempty"`
+}
+
+// Approvals required before applying a plan, automatically or manually. Any authenticated
+// user of the server can approve when no users nor groups are set.
+type ApprovalPolicy struct {
+	// Number of approvals required, plans are applied without approval when unset or 0
+	// +kubebuilder:validation:Minimum=0
+	Required *int `json:"required,omitempty"`
+	// Users allowed to approve, matched against their ID or email
+	Users []string `json:"users,omitempty"`
+	// Groups allowed to approve, read from the groups claim of the OIDC provider
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Approvals of a plan, identified by its run and the sum of its artifact. They are stored in
+// an annotation of the layer by the server and discarded when a newer plan is made.
+type PlanApprovals struct {
+	Run       string           `json:"run"`
+	PlanSum   string           `json:"planSum"`
+	Approvals []ApprovalRecord `json:"approvals,omitempty"`
+	Rejection *ApprovalRecord  `json:"rejection,omitempty"`
+}
+
+type ApprovalRecord struct {
+	User string      `json:"user"`
+	Time metav1.Time `json:"time"`
 }
 
 type OnErrorRemediationStrategy struct {
 	MaxRetries *int `json:"maxRetries,omitempty"`
 }
 
+type Variables struct {
+	Vars     []Variable `json:"vars,omitempty"`
+	VarFiles []string   `json:"varFiles,omitempty"`
+}
+
+type Variable struct {
+	Name      string          `json:"name"`
+	Value     string          `json:"value,omitempty"`
+	ValueFrom *VariableSource `json:"valueFrom,omitempty"`
+}
+
+type VariableSource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	LayerOutputRef  *LayerOutputSelector         `json:"layerOutputRef,omitempty"`
+}
+
+// An output published by another layer of the namespace, see OutputsPublication
+type LayerOutputSelector struct {
+	Layer    string `json:"layer"`
+	Output   string `json:"output"`
+	Optional *bool  `json:"optional,omitempty"`
+}
+
+// Maximum duration of the runs of each action. The runner interrupts terraform when it is
+// exceeded and the attempt fails.
+type RunTimeouts struct {
+	Plan    *metav1.Duration `json:"plan,omitempty"`
+	Apply   *metav1.Duration `json:"apply,omitempty"`
+	Destroy *metav1.Duration `json:"destroy,omitempty"`
+	Drift   *metav1.Duration `json:"drift,omitempty"`
+}
+
+func (t RunTimeouts) forAction(action string) *metav1.Duration {
+	switch action {
+	case "plan":
+		return t.Plan
+	case "apply":
+		return t.Apply
+	case "destroy":
+		return t.Destroy
+	case "drift":
+		return t.Drift
+	}
+	return nil
+}
+
+type DriftDetection struct {
+	// +kubebuilder:validation:Enum=plan;refresh-only
+	Mode DriftDetectionMode `json:"mode,omitempty"`
+	// Cron schedule of the drift detections, e.g. "0 * * * *", takes precedence over the period
+	Schedule string `json:"schedule,omitempty"`
+	// Period between two drift detections, overrides the drift detection period of the controller
+	Period *metav1.Duration `json:"period,omitempty"`
+}
+
+type DriftDetectionMode string
+
+const (
+	// DriftDetectionModePlan runs a regular plan on the drift detection period
+	DriftDetectionModePlan DriftDetectionMode = "plan"
+	// DriftDetectionModeRefreshOnly runs a refresh-only plan on the drift detection period,
+	// which reports drift without producing a plan to apply
+	DriftDetectionModeRefreshOnly DriftDetectionMode = "refresh-only"
+)
+
+// Policies are evaluated against the JSON plan of a layer, an apply is blocked
+// as long as the last plan violates one of them
+type Policies struct {
+	// ConfigMaps in the namespace of the layer containing the policies,
+	// each key ending with .cel is a CEL policy
+	ConfigMaps []corev1.LocalObjectReference `json:"configMaps,omitempty"`
+}
+
+// Hook is a command run by the runner in the directory of the layer, before or after a phase of a run
+type Hook struct {
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=pre-init;post-init;pre-plan;post-plan;pre-apply;post-apply
+	Phase   HookPhase `json:"phase"`
+	Command []string  `json:"command"`
+	// Fail the run when the hook fails, true by default
+	FailOnError *bool `json:"failOnError,omitempty"`
+}
+
+type HookPhase string
+
+const (
+	HookPhasePreInit   HookPhase = "pre-init"
+	HookPhasePostInit  HookPhase = "post-init"
+	HookPhasePrePlan   HookPhase = "pre-plan"
+	HookPhasePostPlan  HookPhase = "post-plan"
+	HookPhasePreApply  HookPhase = "pre-apply"
+	HookPhasePostApply HookPhase = "post-apply"
+)
+
+// IsFailOnError returns true if a failure of the hook must fail the run
+func (h Hook) IsFailOnError() bool {
+	return chooseBool(nil, h.FailOnError, true)
+}
+
 type TerraformConfig struct {
 	Version string `json:"version,omitempty"`
 	Enabled *bool  `json:"enabled,omitempty"`
@@ -65,6 +200,8 @@ type OpenTofuConfig struct {
 type TerragruntConfig struct {
 	Version string `json:"version,omitempty"`
 	Enabled *bool  `json:"enabled,omitempty"`
+	// Run all the terragrunt units found under the layer path, in dependency order
+	Stack *bool `json:"stack,omitempty"`
 }
 
 func GetTerraformEnabled(repository *TerraformRepository, layer *TerraformLayer) bool {
@@ -93,10 +230,67 @@ func GetTerragruntEnabled(repository *TerraformRepository, layer *TerraformLayer
 	return chooseBool(repository.Spec.TerragruntConfig.Enabled, layer.Spec.TerragruntConfig.Enabled, false)
 }
 
+// GetTerragruntStackEnabled returns true if the layer path is the root of a terragrunt stack
+func GetTerragruntStackEnabled(repository *TerraformRepository, layer *TerraformLayer) bool {
+	return GetTerragruntEnabled(repository, layer) && chooseBool(repository.Spec.TerragruntConfig.Stack, layer.Spec.TerragruntConfig.Stack, false)
+}
+
 func GetTerragruntVersion(repository *TerraformRepository, layer *TerraformLayer) string {
 	return chooseString(repository.Spec.TerragruntConfig.Version, layer.Spec.TerragruntConfig.Version)
 }
 
+// GetWorkspace returns the Terraform/OpenTofu workspace the layer runs in.
+// An empty string means the default workspace.
+func GetWorkspace(repository *TerraformRepository, layer *TerraformLayer) string {
+	return chooseString(repository.Spec.Workspace, layer.Spec.Workspace)
+}
+
+// GetVariables returns the variables of the layer merged over the ones of the repository.
+// Variables are merged by name and var files of the layer are passed after the ones of
+// the repository, so that the layer always takes precedence.
+func GetVariables(repository *TerraformRepository, layer *TerraformLayer) Variables {
+	return Variables{
+		Vars:     mergeVars(repository.Spec.Variables.Vars, layer.Spec.Variables.Vars),
+		VarFiles: mergeVarFiles(repository.Spec.Variables.VarFiles, layer.Spec.Variables.VarFiles),
+	}
+}
+
+// GetBackendConfig returns the backend configuration of the layer merged over the one of
+// the repository, by name. Each value is passed to init as a -backend-config argument.
+func GetBackendConfig(repository *TerraformRepository, layer *TerraformLayer) []Variable {
+	return mergeVars(repository.Spec.BackendConfig, layer.Spec.BackendConfig)
+}
+
+// Names of the backend configuration keys identifying the state of a layer, the other ones
+// being credentials or settings of the backend
+var backendStateKeys = []string{"bucket", "key", "prefix", "path", "workspace_key_prefix", "storage_account_name", "container_name", "organization", "address"}
+
+// GetBackendKey identifies the state the layer writes to from the values of the backend configuration
+// keys identifying a state, and its workspace. Values taken from ConfigMaps and Secrets are resolved
+// with resolve. An empty string means the backend configuration of the layer does not identify its state.
+func GetBackendKey(repository *TerraformRepository, layer *TerraformLayer, resolve func(source *VariableSource) (string, error)) (string, error) {
+	values := []string{}
+	for _, v := range GetBackendConfig(repository, layer) {
+		if !slices.Contains(backendStateKeys, v.Name) {
+			continue
+		}
+		value := v.Value
+		if v.ValueFrom != nil {
+			resolved, err := resolve(v.ValueFrom)
+			if err != nil {
+				return "", fmt.Errorf("could not resolve the value of backend configuration %s: %w", v.Name, err)
+			}
+			value = resolved
+		}
+		values = append(values, fmt.Sprintf("%s=%s", v.Name, value))
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	sort.Strings(values)
+	return fmt.Sprintf("%s,workspace=%s", strings.Join(values, ","), GetWorkspace(repository, layer)), nil
+}
+
 func GetOverrideRunnerSpec(repository *TerraformRepository, layer *TerraformLayer) OverrideRunnerSpec {
 	return OverrideRunnerSpec{
 		Tolerations:  overrideTolerations(repository.Spec.OverrideRunnerSpec.Tolerations, layer.Spec.OverrideRunnerSpec.Tolerations),
@@ -138,6 +332,85 @@ func GetAutoApplyEnabled(repo *TerraformRepository, layer *TerraformLayer) bool
 	return chooseBool(repo.Spec.RemediationStrategy.AutoApply, layer.Spec.RemediationStrategy.AutoApply, false)
 }
 
+// GetApprovalPolicy returns the approval policy of the layer, each field of the layer taking
+// precedence over the one of the repository
+func GetApprovalPolicy(repo *TerraformRepository, layer *TerraformLayer) ApprovalPolicy {
+	policy := repo.Spec.RemediationStrategy.Approval
+	if layer.Spec.RemediationStrategy.Approval.Required != nil {
+		policy.Required = layer.Spec.RemediationStrategy.Approval.Required
+	}
+	if len(layer.Spec.RemediationStrategy.Approval.Users) > 0 {
+		policy.Users = layer.Spec.RemediationStrategy.Approval.Users
+	}
+	if len(layer.Spec.RemediationStrategy.Approval.Groups) > 0 {
+		policy.Groups = layer.Spec.RemediationStrategy.Approval.Groups
+	}
+	return policy
+}
+
+func GetDestroyOnDeleteEnabled(repo *TerraformRepository, layer *TerraformLayer) bool {
+	return chooseBool(repo.Spec.RemediationStrategy.DestroyOnDelete, layer.Spec.RemediationStrategy.DestroyOnDelete, false)
+}
+
+func GetDriftDetectionMode(repo *TerraformRepository, layer *TerraformLayer) DriftDetectionMode {
+	mode := DriftDetectionMode(chooseString(string(repo.Spec.DriftDetection.Mode), string(layer.Spec.DriftDetection.Mode)))
+	if mode == "" {
+		return DriftDetectionModePlan
+	}
+	return mode
+}
+
+// GetDriftSchedule returns the cron schedule and the period of the drift detections of the layer,
+// a schedule or a period set on the layer takes precedence over the ones of the repository
+func GetDriftSchedule(repo *TerraformRepository, layer *TerraformLayer) (string, *metav1.Duration) {
+	if layer.Spec.DriftDetection.Schedule != "" || layer.Spec.DriftDetection.Period != nil {
+		return layer.Spec.DriftDetection.Schedule, layer.Spec.DriftDetection.Period
+	}
+	return repo.Spec.DriftDetection.Schedule, repo.Spec.DriftDetection.Period
+}
+
+// GetPolicies returns the policies of the repository and of the layer, both apply to the layer
+func GetPolicies(repo *TerraformRepository, layer *TerraformLayer) Policies {
+	configMaps := []corev1.LocalObjectReference{}
+	seen := map[string]bool{}
+	for _, cm := range append(append([]corev1.LocalObjectReference{}, repo.Spec.Policies.ConfigMaps...), layer.Spec.Policies.ConfigMaps...) {
+		if seen[cm.Name] {
+			continue
+		}
+		seen[cm.Name] = true
+		configMaps = append(configMaps, cm)
+	}
+	return Policies{ConfigMaps: configMaps}
+}
+
+// GetRunTimeout returns the timeout of the runs of an action, the layer takes precedence over the
+// repository. A zero duration disables the timeout.
+func GetRunTimeout(repository *TerraformRepository, layer *TerraformLayer, action string, defaultTimeout time.Duration) time.Duration {
+	if timeout := layer.Spec.Timeouts.forAction(action); timeout != nil {
+		return timeout.Duration
+	}
+	if timeout := repository.Spec.Timeouts.forAction(action); timeout != nil {
+		return timeout.Duration
+	}
+	return defaultTimeout
+}
+
+// GetHooks returns the hooks of the repository followed by the ones of the layer,
+// a hook of the layer replaces the hook of the repository with the same name
+func GetHooks(repo *TerraformRepository, layer *TerraformLayer) []Hook {
+	result := []Hook{}
+	index := map[string]int{}
+	for _, hook := range append(append([]Hook{}, repo.Spec.Hooks...), layer.Spec.Hooks...) {
+		if i, ok := index[hook.Name]; ok {
+			result[i] = hook
+			continue
+		}
+		index[hook.Name] = len(result)
+		result = append(result, hook)
+	}
+	return result
+}
+
 func isEnabled(enabled *bool) bool {
 	return enabled != nil && *enabled
 }
@@ -350,6 +623,38 @@ func overrideExtraArgs(a, b ExtraArgs) ExtraArgs {
 	return result
 }
 
+func mergeVars(a, b []Variable) []Variable {
+	result := []Variable{}
+	index := map[string]int{}
+
+	for _, elt := range append(append([]Variable{}, a...), b...) {
+		if i, ok := index[elt.Name]; ok {
+			result[i] = elt
+			continue
+		}
+		index[elt.Name] = len(result)
+		result = append(result, elt)
+	}
+	return result
+}
+
+// Duplicated var files only keep their last position, since the last file passed wins
+func mergeVarFiles(a, b []string) []string {
+	result := []string{}
+	all := append(append([]string{}, a...), b...)
+	last := map[string]int{}
+
+	for i, elt := range all {
+		last[elt] = i
+	}
+	for i, elt := range all {
+		if last[elt] == i {
+			result = append(result, elt)
+		}
+	}
+	return result
+}
+
 func MergeInitContainers(a, b []corev1.Container) []corev1.Container {
 	result := []corev1.Container{}
 	tempMap := map[string]corev1.Container{}
@@ -1,11 +1,15 @@
 package v1alpha1_test
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 )
@@ -2553,3 +2557,540 @@ func TestChooseSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestGetWorkspace(t *testing.T) {
+	tt := []struct {
+		name              string
+		repository        *configv1alpha1.TerraformRepository
+		layer             *configv1alpha1.TerraformLayer
+		expectedWorkspace string
+	}{
+		{
+			"NoWorkspace",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			"",
+		},
+		{
+			"OnlyRepositoryWorkspace",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					Workspace: "staging",
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			"staging",
+		},
+		{
+			"OnlyLayerWorkspace",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Workspace: "production",
+				},
+			},
+			"production",
+		},
+		{
+			"OverrideRepositoryWithLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					Workspace: "staging",
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Workspace: "production",
+				},
+			},
+			"production",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetWorkspace(tc.repository, tc.layer)
+			if tc.expectedWorkspace != result {
+				t.Errorf("different workspace computed: expected %s got %s", tc.expectedWorkspace, result)
+			}
+		})
+	}
+}
+
+func TestGetVariables(t *testing.T) {
+	secretSource := &configv1alpha1.VariableSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "secret"},
+			Key:                  "password",
+		},
+	}
+	tt := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		layer      *configv1alpha1.TerraformLayer
+		expected   configv1alpha1.Variables
+	}{
+		{
+			"NoVariables",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.Variables{
+				Vars:     []configv1alpha1.Variable{},
+				VarFiles: []string{},
+			},
+		},
+		{
+			"OnlyRepositoryVariables",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					Variables: configv1alpha1.Variables{
+						Vars:     []configv1alpha1.Variable{{Name: "region", Value: "eu-west-3"}},
+						VarFiles: []string{"common.tfvars"},
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.Variables{
+				Vars:     []configv1alpha1.Variable{{Name: "region", Value: "eu-west-3"}},
+				VarFiles: []string{"common.tfvars"},
+			},
+		},
+		{
+			"MergeLayerOverRepository",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					Variables: configv1alpha1.Variables{
+						Vars: []configv1alpha1.Variable{
+							{Name: "region", Value: "eu-west-3"},
+							{Name: "env", Value: "dev"},
+						},
+						VarFiles: []string{"common.tfvars"},
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Variables: configv1alpha1.Variables{
+						Vars: []configv1alpha1.Variable{
+							{Name: "env", Value: "prod"},
+							{Name: "password", ValueFrom: secretSource},
+						},
+						VarFiles: []string{"prod.tfvars", "common.tfvars"},
+					},
+				},
+			},
+			configv1alpha1.Variables{
+				Vars: []configv1alpha1.Variable{
+					{Name: "region", Value: "eu-west-3"},
+					{Name: "env", Value: "prod"},
+					{Name: "password", ValueFrom: secretSource},
+				},
+				VarFiles: []string{"prod.tfvars", "common.tfvars"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetVariables(tc.repository, tc.layer)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected variables %v but got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetDestroyOnDeleteEnabled(t *testing.T) {
+	tt := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		layer      *configv1alpha1.TerraformLayer
+		expected   bool
+	}{
+		{
+			"DisabledByDefault",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			false,
+		},
+		{
+			"OnlyRepositoryEnabling",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						DestroyOnDelete: &[]bool{true}[0],
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			true,
+		},
+		{
+			"EnabledInRepositoryDisabledInLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						DestroyOnDelete: &[]bool{true}[0],
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						DestroyOnDelete: &[]bool{false}[0],
+					},
+				},
+			},
+			false,
+		},
+		{
+			"OnlyLayerEnabling",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						DestroyOnDelete: &[]bool{true}[0],
+					},
+				},
+			},
+			true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetDestroyOnDeleteEnabled(tc.repository, tc.layer)
+			if tc.expected != result {
+				t.Errorf("different enabled status computed: expected %t got %t", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetDriftDetectionMode(t *testing.T) {
+	tt := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		layer      *configv1alpha1.TerraformLayer
+		expected   configv1alpha1.DriftDetectionMode
+	}{
+		{
+			"DefaultsToPlan",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.DriftDetectionModePlan,
+		},
+		{
+			"OnlyRepositoryMode",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{Mode: configv1alpha1.DriftDetectionModeRefreshOnly},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.DriftDetectionModeRefreshOnly,
+		},
+		{
+			"OverrideRepositoryWithLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{Mode: configv1alpha1.DriftDetectionModeRefreshOnly},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DriftDetection: configv1alpha1.DriftDetection{Mode: configv1alpha1.DriftDetectionModePlan},
+				},
+			},
+			configv1alpha1.DriftDetectionModePlan,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetDriftDetectionMode(tc.repository, tc.layer)
+			if tc.expected != result {
+				t.Errorf("different drift detection mode computed: expected %s got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetDriftSchedule(t *testing.T) {
+	hourly := &metav1.Duration{Duration: time.Hour}
+	weekly := &metav1.Duration{Duration: 7 * 24 * time.Hour}
+	tt := []struct {
+		name             string
+		repository       *configv1alpha1.TerraformRepository
+		layer            *configv1alpha1.TerraformLayer
+		expectedSchedule string
+		expectedPeriod   *metav1.Duration
+	}{
+		{
+			"NoSchedule",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			"",
+			nil,
+		},
+		{
+			"OnlyRepositorySchedule",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{Schedule: "0 3 * * 1"},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			"0 3 * * 1",
+			nil,
+		},
+		{
+			"LayerPeriodOverridesRepositorySchedule",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{Schedule: "0 3 * * 1", Period: weekly},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DriftDetection: configv1alpha1.DriftDetection{Period: hourly},
+				},
+			},
+			"",
+			hourly,
+		},
+		{
+			"LayerModeKeepsRepositorySchedule",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{Period: weekly},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DriftDetection: configv1alpha1.DriftDetection{Mode: configv1alpha1.DriftDetectionModeRefreshOnly},
+				},
+			},
+			"",
+			weekly,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, period := configv1alpha1.GetDriftSchedule(tc.repository, tc.layer)
+			if schedule != tc.expectedSchedule {
+				t.Errorf("different drift detection schedule computed: expected %q got %q", tc.expectedSchedule, schedule)
+			}
+			if period != tc.expectedPeriod {
+				t.Errorf("different drift detection period computed: expected %v got %v", tc.expectedPeriod, period)
+			}
+		})
+	}
+}
+
+func TestGetPolicies(t *testing.T) {
+	tt := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		layer      *configv1alpha1.TerraformLayer
+		expected   configv1alpha1.Policies
+	}{
+		{
+			"NoPolicies",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.Policies{ConfigMaps: []corev1.LocalObjectReference{}},
+		},
+		{
+			"MergeRepositoryAndLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					Policies: configv1alpha1.Policies{ConfigMaps: []corev1.LocalObjectReference{{Name: "org-policies"}, {Name: "team-policies"}}},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Policies: configv1alpha1.Policies{ConfigMaps: []corev1.LocalObjectReference{{Name: "team-policies"}, {Name: "layer-policies"}}},
+				},
+			},
+			configv1alpha1.Policies{ConfigMaps: []corev1.LocalObjectReference{{Name: "org-policies"}, {Name: "team-policies"}, {Name: "layer-policies"}}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetPolicies(tc.repository, tc.layer)
+			if !reflect.DeepEqual(tc.expected, result) {
+				t.Errorf("different policies computed: expected %v got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetBackendKey(t *testing.T) {
+	secrets := map[string]string{"backend/bucket": "production-states"}
+	resolve := func(source *configv1alpha1.VariableSource) (string, error) {
+		if source.SecretKeyRef == nil {
+			return "", errors.New("not a secret")
+		}
+		value, ok := secrets[fmt.Sprintf("%s/%s", source.SecretKeyRef.Name, source.SecretKeyRef.Key)]
+		if !ok {
+			return "", errors.New("secret not found")
+		}
+		return value, nil
+	}
+	tt := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		layer      *configv1alpha1.TerraformLayer
+		expected   string
+		err        bool
+	}{
+		{
+			"NoBackendConfig",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			"",
+			false,
+		},
+		{
+			"OnlyCredentials",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					BackendConfig: []configv1alpha1.Variable{
+						{Name: "access_key", Value: "my-access-key"},
+					},
+				},
+			},
+			"",
+			false,
+		},
+		{
+			"LayerOverridingRepository",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					BackendConfig: []configv1alpha1.Variable{
+						{Name: "bucket", Value: "states"},
+						{Name: "key", Value: "default.tfstate"},
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Workspace: "staging",
+					BackendConfig: []configv1alpha1.Variable{
+						{Name: "key", Value: "network.tfstate"},
+						{Name: "bucket", ValueFrom: &configv1alpha1.VariableSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "backend"},
+								Key:                  "bucket",
+							},
+						}},
+						{Name: "access_key", Value: "my-access-key"},
+					},
+				},
+			},
+			"bucket=production-states,key=network.tfstate,workspace=staging",
+			false,
+		},
+		{
+			"UnresolvedValue",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					BackendConfig: []configv1alpha1.Variable{
+						{Name: "bucket", ValueFrom: &configv1alpha1.VariableSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+								Key:                  "bucket",
+							},
+						}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := configv1alpha1.GetBackendKey(tc.repository, tc.layer, resolve)
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected backend key %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetHooks(t *testing.T) {
+	ignoreErrors := false
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			Hooks: []configv1alpha1.Hook{
+				{Name: "fmt", Phase: configv1alpha1.HookPhasePreInit, Command: []string{"terraform", "fmt", "-check"}},
+				{Name: "tflint", Phase: configv1alpha1.HookPhasePostInit, Command: []string{"tflint"}},
+			},
+		},
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Hooks: []configv1alpha1.Hook{
+				{Name: "checkov", Phase: configv1alpha1.HookPhasePostPlan, Command: []string{"checkov", "-d", "."}},
+				{Name: "tflint", Phase: configv1alpha1.HookPhasePostInit, Command: []string{"tflint", "--minimum-failure-severity=error"}, FailOnError: &ignoreErrors},
+			},
+		},
+	}
+	hooks := configv1alpha1.GetHooks(repository, layer)
+	expected := []configv1alpha1.Hook{
+		repository.Spec.Hooks[0],
+		layer.Spec.Hooks[1],
+		layer.Spec.Hooks[0],
+	}
+	if !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("expected hooks %v but got %v", expected, hooks)
+	}
+	if !hooks[0].IsFailOnError() || hooks[1].IsFailOnError() {
+		t.Errorf("expected hooks to fail on error by default")
+	}
+}
+
+func TestGetRunTimeout(t *testing.T) {
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			Timeouts: configv1alpha1.RunTimeouts{
+				Plan:  &metav1.Duration{Duration: 30 * time.Minute},
+				Apply: &metav1.Duration{Duration: 2 * time.Hour},
+			},
+		},
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Timeouts: configv1alpha1.RunTimeouts{
+				Apply: &metav1.Duration{Duration: 0},
+				Drift: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		},
+	}
+	tt := []struct {
+		action   string
+		expected time.Duration
+	}{
+		{"plan", 30 * time.Minute},
+		{"apply", 0},
+		{"destroy", time.Hour},
+		{"drift", 10 * time.Minute},
+	}
+	for _, tc := range tt {
+		t.Run(tc.action, func(t *testing.T) {
+			timeout := configv1alpha1.GetRunTimeout(repository, layer, tc.action, time.Hour)
+			if timeout != tc.expected {
+				t.Errorf("expected timeout %s but got %s", tc.expected, timeout)
+			}
+		})
+	}
+}
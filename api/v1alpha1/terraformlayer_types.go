@@ -30,16 +30,25 @@ type TerraformLayerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	Path                 string                   `json:"path,omitempty"`
-	Branch               string                   `json:"branch,omitempty"`
-	AdditionalTargetRefs []string                 `json:"additionalTargetRefs,omitempty"`
-	TerraformConfig      TerraformConfig          `json:"terraform,omitempty"`
-	OpenTofuConfig       OpenTofuConfig           `json:"opentofu,omitempty"`
-	TerragruntConfig     TerragruntConfig         `json:"terragrunt,omitempty"`
-	Repository           TerraformLayerRepository `json:"repository,omitempty"`
-	RemediationStrategy  RemediationStrategy      `json:"remediationStrategy,omitempty"`
-	OverrideRunnerSpec   OverrideRunnerSpec       `json:"overrideRunnerSpec,omitempty"`
-	RunHistoryPolicy     RunHistoryPolicy         `json:"runHistoryPolicy,omitempty"`
+	Path                 string                    `json:"path,omitempty"`
+	Branch               string                    `json:"branch,omitempty"`
+	Workspace            string                    `json:"workspace,omitempty"`
+	Variables            Variables                 `json:"variables,omitempty"`
+	BackendConfig        []Variable                `json:"backendConfig,omitempty"`
+	AdditionalTargetRefs []string                  `json:"additionalTargetRefs,omitempty"`
+	TerraformConfig      TerraformConfig           `json:"terraform,omitempty"`
+	OpenTofuConfig       OpenTofuConfig            `json:"opentofu,omitempty"`
+	TerragruntConfig     TerragruntConfig          `json:"terragrunt,omitempty"`
+	Repository           TerraformLayerRepository  `json:"repository,omitempty"`
+	RemediationStrategy  RemediationStrategy       `json:"remediationStrategy,omitempty"`
+	DriftDetection       DriftDetection            `json:"driftDetection,omitempty"`
+	Policies             Policies                  `json:"policies,omitempty"`
+	Hooks                []Hook                    `json:"hooks,omitempty"`
+	Timeouts             RunTimeouts               `json:"timeouts,omitempty"`
+	OverrideRunnerSpec   OverrideRunnerSpec        `json:"overrideRunnerSpec,omitempty"`
+	RunHistoryPolicy     RunHistoryPolicy          `json:"runHistoryPolicy,omitempty"`
+	DependsOn            []TerraformLayerReference `json:"dependsOn,omitempty"`
+	Outputs              OutputsPublication        `json:"outputs,omitempty"`
 }
 
 type TerraformLayerRepository struct {
@@ -47,6 +56,27 @@ type TerraformLayerRepository struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// A reference to another layer. The namespace defaults to the one of the referencing layer.
+type TerraformLayerReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Outputs of the layer published in its namespace after each successful apply, so that
+// other layers can consume them as variables
+type OutputsPublication struct {
+	// Sensitive outputs can only be published to the Secret
+	Secret    *OutputsTarget `json:"secret,omitempty"`
+	ConfigMap *OutputsTarget `json:"configMap,omitempty"`
+}
+
+type OutputsTarget struct {
+	Name string `json:"name"`
+	// Names of the outputs to publish, each one under a key named after it
+	// +kubebuilder:validation:MinItems=1
+	Outputs []string `json:"outputs"`
+}
+
 // TerraformLayerStatus defines the observed state of TerraformLayer
 type TerraformLayerStatus struct {
 	Conditions []metav1.Condition  `json:"conditions,omitempty"`
@@ -54,6 +84,10 @@ type TerraformLayerStatus struct {
 	LastResult string              `json:"lastResult,omitempty"`
 	LastRun    TerraformLayerRun   `json:"lastRun,omitempty"`
 	LatestRuns []TerraformLayerRun `json:"latestRuns,omitempty"`
+	// Paths of the repository the layer depends on, resolved from its local module sources and
+	// terragrunt includes at TriggerPathsCommit. Changes under these paths trigger the layer.
+	TriggerPaths       []string `json:"triggerPaths,omitempty"`
+	TriggerPathsCommit string   `json:"triggerPathsCommit,omitempty"`
 }
 
 type TerraformLayerRun struct {
@@ -31,10 +31,17 @@ type TerraformRepositorySpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Repository              TerraformRepositoryRepository `json:"repository,omitempty"`
+	Workspace               string                        `json:"workspace,omitempty"`
+	Variables               Variables                     `json:"variables,omitempty"`
+	BackendConfig           []Variable                    `json:"backendConfig,omitempty"`
 	TerraformConfig         TerraformConfig               `json:"terraform,omitempty"`
 	TerragruntConfig        TerragruntConfig              `json:"terragrunt,omitempty"`
 	OpenTofuConfig          OpenTofuConfig                `json:"opentofu,omitempty"`
 	RemediationStrategy     RemediationStrategy           `json:"remediationStrategy,omitempty"`
+	DriftDetection          DriftDetection                `json:"driftDetection,omitempty"`
+	Policies                Policies                      `json:"policies,omitempty"`
+	Hooks                   []Hook                        `json:"hooks,omitempty"`
+	Timeouts                RunTimeouts                   `json:"timeouts,omitempty"`
 	OverrideRunnerSpec      OverrideRunnerSpec            `json:"overrideRunnerSpec,omitempty"`
 	RunHistoryPolicy        RunHistoryPolicy              `json:"runHistoryPolicy,omitempty"`
 	MaxConcurrentRunnerPods int                           `json:"maxConcurrentRunnerPods,omitempty"`
@@ -25,9 +25,24 @@ import (
 
 // TerraformRunSpec defines the desired state of TerraformRun
 type TerraformRunSpec struct {
-	Action   string            `json:"action,omitempty"`
-	Artifact Artifact          `json:"artifact,omitempty"`
-	Layer    TerraformRunLayer `json:"layer,omitempty"`
+	Action      string            `json:"action,omitempty"`
+	Artifact    Artifact          `json:"artifact,omitempty"`
+	Layer       TerraformRunLayer `json:"layer,omitempty"`
+	PlanOptions PlanOptions       `json:"planOptions,omitempty"`
+}
+
+// PlanOptions restrict or alter a plan with the -target and -replace flags.
+// A plan made with any of them is partial and is not applied automatically
+// unless AutoApply is set.
+type PlanOptions struct {
+	Targets   []string `json:"targets,omitempty"`
+	Replaces  []string `json:"replaces,omitempty"`
+	AutoApply bool     `json:"autoApply,omitempty"`
+}
+
+// IsPartial returns true if the plan is restricted or altered by targets or replaces
+func (o PlanOptions) IsPartial() bool {
+	return len(o.Targets) > 0 || len(o.Replaces) > 0
 }
 
 type Artifact struct {
@@ -49,6 +64,85 @@ type TerraformRunStatus struct {
 	LastRun    string             `json:"lastRun,omitempty"`
 	Attempts   []Attempt          `json:"attempts,omitempty"`
 	RunnerPod  string             `json:"runnerPod,omitempty"`
+	// Reason of the failure of the last attempt, as reported by the runner
+	Reason string `json:"reason,omitempty"`
+	// Results of the units of a terragrunt stack, in dependency order
+	Units []UnitResult `json:"units,omitempty"`
+	// Results of the hooks run during the last attempt
+	Hooks []HookResult `json:"hooks,omitempty"`
+	// Progress of the resources applied during the last attempt, reported when the runner
+	// reads the machine-readable output of terraform. Failed resources are kept first.
+	Resources []ResourceResult `json:"resources,omitempty"`
+	// Number of resources left out of resources to bound the size of the status, the whole
+	// timeline is available from the datastore
+	ResourcesOmitted int `json:"resourcesOmitted,omitempty"`
+	// Result of the last attempt, reported by its runner once it has finished
+	Result *RunResult `json:"result,omitempty"`
+}
+
+// RunResult is the result of an attempt of a run, as reported by its runner
+type RunResult struct {
+	// Number of the attempt
+	Attempt int `json:"attempt"`
+	// Tool running the plans and applies: terraform or tofu
+	Tool    string `json:"tool,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Version of terragrunt, if it wraps the tool
+	TerragruntVersion string `json:"terragruntVersion,omitempty"`
+	// Phases of the runner, in the order they ran
+	Phases []PhaseResult `json:"phases,omitempty"`
+	// Changes planned by plan and destroy runs
+	Plan *ChangeCounts `json:"plan,omitempty"`
+	// Changes made by apply runs, when they are known
+	Apply *ChangeCounts `json:"apply,omitempty"`
+	// One of Succeeded, Failed, Cancelled, TimedOut or Refused
+	ExitReason string `json:"exitReason"`
+	// Error which made the attempt fail, or reason why the apply was refused
+	Message string `json:"message,omitempty"`
+}
+
+type PhaseResult struct {
+	// One of setup, init, workspace, or the action of the run
+	Name     string          `json:"name"`
+	Duration metav1.Duration `json:"duration"`
+}
+
+// ChangeCounts are the numbers of resources changed, as counted by terraform: a replaced resource
+// is both added and destroyed
+type ChangeCounts struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// ResourceResult is the progress of the apply of a resource
+type ResourceResult struct {
+	Address string `json:"address"`
+	// Action applied to the resource: create, update, delete, replace, read...
+	Action string `json:"action"`
+	// One of Applying, Applied or Failed
+	State     string       `json:"state"`
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// Time spent applying the resource, once it is applied or has failed
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// HookResult is the result of a hook, its output is stored in the datastore
+type HookResult struct {
+	Name        string    `json:"name"`
+	Phase       HookPhase `json:"phase"`
+	ExitCode    int       `json:"exitCode"`
+	FailOnError bool      `json:"failOnError,omitempty"`
+}
+
+// UnitResult is the result of the last action on a unit of a terragrunt stack
+type UnitResult struct {
+	// Path of the unit, relative to the layer path
+	Path string `json:"path"`
+	// One of Planned, Applied, Failed or Skipped
+	State string `json:"state"`
+	// Short diff of the plan of the unit
+	Diff string `json:"diff,omitempty"`
 }
 
 type Attempt struct {
@@ -26,6 +26,52 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalPolicy) DeepCopyInto(out *ApprovalPolicy) {
+	*out = *in
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = new(int)
+		**out = **in
+	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalPolicy.
+func (in *ApprovalPolicy) DeepCopy() *ApprovalPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRecord) DeepCopyInto(out *ApprovalRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRecord.
+func (in *ApprovalRecord) DeepCopy() *ApprovalRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Artifact) DeepCopyInto(out *Artifact) {
 	*out = *in
@@ -71,6 +117,41 @@ func (in *BranchState) DeepCopy() *BranchState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeCounts) DeepCopyInto(out *ChangeCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeCounts.
+func (in *ChangeCounts) DeepCopy() *ChangeCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftDetection) DeepCopyInto(out *DriftDetection) {
+	*out = *in
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftDetection.
+func (in *DriftDetection) DeepCopy() *DriftDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in ExtraArgs) DeepCopyInto(out *ExtraArgs) {
 	{
@@ -90,6 +171,66 @@ func (in ExtraArgs) DeepCopy() ExtraArgs {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailOnError != nil {
+		in, out := &in.FailOnError, &out.FailOnError
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookResult) DeepCopyInto(out *HookResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookResult.
+func (in *HookResult) DeepCopy() *HookResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HookResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LayerOutputSelector) DeepCopyInto(out *LayerOutputSelector) {
+	*out = *in
+	if in.Optional != nil {
+		in, out := &in.Optional, &out.Optional
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LayerOutputSelector.
+func (in *LayerOutputSelector) DeepCopy() *LayerOutputSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(LayerOutputSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataOverride) DeepCopyInto(out *MetadataOverride) {
 	*out = *in
@@ -159,6 +300,51 @@ func (in *OpenTofuConfig) DeepCopy() *OpenTofuConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputsPublication) DeepCopyInto(out *OutputsPublication) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(OutputsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(OutputsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputsPublication.
+func (in *OutputsPublication) DeepCopy() *OutputsPublication {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputsPublication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputsTarget) DeepCopyInto(out *OutputsTarget) {
+	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputsTarget.
+func (in *OutputsTarget) DeepCopy() *OutputsTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputsTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OverrideRunnerSpec) DeepCopyInto(out *OverrideRunnerSpec) {
 	*out = *in
@@ -260,6 +446,94 @@ func (in *OverrideRunnerSpec) DeepCopy() *OverrideRunnerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseResult) DeepCopyInto(out *PhaseResult) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseResult.
+func (in *PhaseResult) DeepCopy() *PhaseResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanApprovals) DeepCopyInto(out *PlanApprovals) {
+	*out = *in
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]ApprovalRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rejection != nil {
+		in, out := &in.Rejection, &out.Rejection
+		*out = new(ApprovalRecord)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanApprovals.
+func (in *PlanApprovals) DeepCopy() *PlanApprovals {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanApprovals)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanOptions) DeepCopyInto(out *PlanOptions) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replaces != nil {
+		in, out := &in.Replaces, &out.Replaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanOptions.
+func (in *PlanOptions) DeepCopy() *PlanOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Policies) DeepCopyInto(out *Policies) {
+	*out = *in
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Policies.
+func (in *Policies) DeepCopy() *Policies {
+	if in == nil {
+		return nil
+	}
+	out := new(Policies)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 	*out = *in
@@ -273,7 +547,13 @@ func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DestroyOnDelete != nil {
+		in, out := &in.DestroyOnDelete, &out.DestroyOnDelete
+		*out = new(bool)
+		**out = **in
+	}
 	in.OnError.DeepCopyInto(&out.OnError)
+	in.Approval.DeepCopyInto(&out.Approval)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationStrategy.
@@ -286,6 +566,30 @@ func (in *RemediationStrategy) DeepCopy() *RemediationStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceResult) DeepCopyInto(out *ResourceResult) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceResult.
+func (in *ResourceResult) DeepCopy() *ResourceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunHistoryPolicy) DeepCopyInto(out *RunHistoryPolicy) {
 	*out = *in
@@ -306,6 +610,71 @@ func (in *RunHistoryPolicy) DeepCopy() *RunHistoryPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunResult) DeepCopyInto(out *RunResult) {
+	*out = *in
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]PhaseResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(ChangeCounts)
+		**out = **in
+	}
+	if in.Apply != nil {
+		in, out := &in.Apply, &out.Apply
+		*out = new(ChangeCounts)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunResult.
+func (in *RunResult) DeepCopy() *RunResult {
+	if in == nil {
+		return nil
+	}
+	out := new(RunResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunTimeouts) DeepCopyInto(out *RunTimeouts) {
+	*out = *in
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Apply != nil {
+		in, out := &in.Apply, &out.Apply
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Destroy != nil {
+		in, out := &in.Destroy, &out.Destroy
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunTimeouts.
+func (in *RunTimeouts) DeepCopy() *RunTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(RunTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncWindow) DeepCopyInto(out *SyncWindow) {
 	*out = *in
@@ -410,6 +779,21 @@ func (in *TerraformLayerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerReference) DeepCopyInto(out *TerraformLayerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerReference.
+func (in *TerraformLayerReference) DeepCopy() *TerraformLayerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformLayerRepository) DeepCopyInto(out *TerraformLayerRepository) {
 	*out = *in
@@ -444,6 +828,14 @@ func (in *TerraformLayerRun) DeepCopy() *TerraformLayerRun {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformLayerSpec) DeepCopyInto(out *TerraformLayerSpec) {
 	*out = *in
+	in.Variables.DeepCopyInto(&out.Variables)
+	if in.BackendConfig != nil {
+		in, out := &in.BackendConfig, &out.BackendConfig
+		*out = make([]Variable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.AdditionalTargetRefs != nil {
 		in, out := &in.AdditionalTargetRefs, &out.AdditionalTargetRefs
 		*out = make([]string, len(*in))
@@ -454,8 +846,24 @@ func (in *TerraformLayerSpec) DeepCopyInto(out *TerraformLayerSpec) {
 	in.TerragruntConfig.DeepCopyInto(&out.TerragruntConfig)
 	out.Repository = in.Repository
 	in.RemediationStrategy.DeepCopyInto(&out.RemediationStrategy)
+	in.DriftDetection.DeepCopyInto(&out.DriftDetection)
+	in.Policies.DeepCopyInto(&out.Policies)
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Timeouts.DeepCopyInto(&out.Timeouts)
 	in.OverrideRunnerSpec.DeepCopyInto(&out.OverrideRunnerSpec)
 	in.RunHistoryPolicy.DeepCopyInto(&out.RunHistoryPolicy)
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]TerraformLayerReference, len(*in))
+		copy(*out, *in)
+	}
+	in.Outputs.DeepCopyInto(&out.Outputs)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSpec.
@@ -486,6 +894,11 @@ func (in *TerraformLayerStatus) DeepCopyInto(out *TerraformLayerStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TriggerPaths != nil {
+		in, out := &in.TriggerPaths, &out.TriggerPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerStatus.
@@ -673,10 +1086,28 @@ func (in *TerraformRepositoryRepository) DeepCopy() *TerraformRepositoryReposito
 func (in *TerraformRepositorySpec) DeepCopyInto(out *TerraformRepositorySpec) {
 	*out = *in
 	out.Repository = in.Repository
+	in.Variables.DeepCopyInto(&out.Variables)
+	if in.BackendConfig != nil {
+		in, out := &in.BackendConfig, &out.BackendConfig
+		*out = make([]Variable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.TerraformConfig.DeepCopyInto(&out.TerraformConfig)
 	in.TerragruntConfig.DeepCopyInto(&out.TerragruntConfig)
 	in.OpenTofuConfig.DeepCopyInto(&out.OpenTofuConfig)
 	in.RemediationStrategy.DeepCopyInto(&out.RemediationStrategy)
+	in.DriftDetection.DeepCopyInto(&out.DriftDetection)
+	in.Policies.DeepCopyInto(&out.Policies)
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Timeouts.DeepCopyInto(&out.Timeouts)
 	in.OverrideRunnerSpec.DeepCopyInto(&out.OverrideRunnerSpec)
 	in.RunHistoryPolicy.DeepCopyInto(&out.RunHistoryPolicy)
 	if in.SyncWindows != nil {
@@ -730,7 +1161,7 @@ func (in *TerraformRun) DeepCopyInto(out *TerraformRun) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -804,6 +1235,7 @@ func (in *TerraformRunSpec) DeepCopyInto(out *TerraformRunSpec) {
 	*out = *in
 	out.Artifact = in.Artifact
 	out.Layer = in.Layer
+	in.PlanOptions.DeepCopyInto(&out.PlanOptions)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformRunSpec.
@@ -831,6 +1263,28 @@ func (in *TerraformRunStatus) DeepCopyInto(out *TerraformRunStatus) {
 		*out = make([]Attempt, len(*in))
 		copy(*out, *in)
 	}
+	if in.Units != nil {
+		in, out := &in.Units, &out.Units
+		*out = make([]UnitResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]HookResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Result != nil {
+		in, out := &in.Result, &out.Result
+		*out = new(RunResult)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformRunStatus.
@@ -851,6 +1305,11 @@ func (in *TerragruntConfig) DeepCopyInto(out *TerragruntConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Stack != nil {
+		in, out := &in.Stack, &out.Stack
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerragruntConfig.
@@ -862,3 +1321,95 @@ func (in *TerragruntConfig) DeepCopy() *TerragruntConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitResult) DeepCopyInto(out *UnitResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitResult.
+func (in *UnitResult) DeepCopy() *UnitResult {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Variable) DeepCopyInto(out *Variable) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(VariableSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Variable.
+func (in *Variable) DeepCopy() *Variable {
+	if in == nil {
+		return nil
+	}
+	out := new(Variable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VariableSource) DeepCopyInto(out *VariableSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LayerOutputRef != nil {
+		in, out := &in.LayerOutputRef, &out.LayerOutputRef
+		*out = new(LayerOutputSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VariableSource.
+func (in *VariableSource) DeepCopy() *VariableSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VariableSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Variables) DeepCopyInto(out *Variables) {
+	*out = *in
+	if in.Vars != nil {
+		in, out := &in.Vars, &out.Vars
+		*out = make([]Variable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VarFiles != nil {
+		in, out := &in.VarFiles, &out.VarFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Variables.
+func (in *Variables) DeepCopy() *Variables {
+	if in == nil {
+		return nil
+	}
+	out := new(Variables)
+	in.DeepCopyInto(out)
+	return out
+}
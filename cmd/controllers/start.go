@@ -38,6 +38,10 @@ func buildControllersStartCmd(app *burrito.App) *cobra.Command {
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.OnError, "on-error-period", defaultOnErrorTimer, "period between two runners launch when an error occurred in the controllers. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.WaitAction, "wait-action-period", defaultWaitActionTimer, "period between two runners when a layer is locked. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.FailureGracePeriod, "failure-grace-period", defaultFailureGracePeriod, "initial time before retry, goes exponential function of number failure. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RunTimeouts.Plan, "plan-timeout", 0, "maximum duration of a plan run, 0 disables the timeout. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RunTimeouts.Apply, "apply-timeout", 0, "maximum duration of an apply run, 0 disables the timeout. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RunTimeouts.Destroy, "destroy-timeout", 0, "maximum duration of a destroy plan run, 0 disables the timeout. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RunTimeouts.Drift, "drift-timeout", 0, "maximum duration of a drift check run, 0 disables the timeout. Must end with s, m or h.")
 	cmd.Flags().IntVar(&app.Config.Controller.TerraformMaxRetries, "terraform-max-retries", 5, "default number of retries for terraform actions (can be overriden in CRDs)")
 	cmd.Flags().IntVar(&app.Config.Controller.MaxConcurrentReconciles, "max-concurrent-reconciles", 1, "maximum number of concurrent reconciles")
 	cmd.Flags().IntVar(&app.Config.Controller.MaxConcurrentRunnerPods, "max-concurrent-runner-pods", 0, "maximum number of concurrent runner pods")
@@ -4,6 +4,8 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package runner
 
 import (
+	"time"
+
 	"github.com/padok-team/burrito/internal/burrito"
 	"github.com/spf13/cobra"
 )
@@ -22,5 +24,16 @@ func buildRunnerStartCmd(app *burrito.App) *cobra.Command {
 	cmd.Flags().StringVar(&app.Config.Runner.SSHKnownHostsConfigMapName, "ssh-known-hosts-cm-name", "burrito-ssh-known-hosts", "configmap name to get known hosts file from")
 	cmd.Flags().StringVar(&app.Config.Runner.RunnerBinaryPath, "runner-binary-path", "/runner/bin", "binary path where the runner can expect to find terraform or terragrunt binaries")
 	cmd.Flags().StringVar(&app.Config.Runner.RepositoryPath, "repository-path", "/runner/repository", "path where the runner fetches the Git repository to work on")
+	cmd.Flags().StringVar(&app.Config.Runner.VariablesPath, "variables-path", "/runner/variables", "path where the runner can expect to find variable values taken from ConfigMaps and Secrets")
+	cmd.Flags().StringVar(&app.Config.Runner.BackendConfigPath, "backend-config-path", "/runner/backend-config", "path where the runner can expect to find backend configuration values taken from ConfigMaps and Secrets")
+	cmd.Flags().StringVar(&app.Config.Runner.PoliciesPath, "policies-path", "/runner/policies", "path where the runner can expect to find the policies taken from ConfigMaps, one directory per ConfigMap")
+	cmd.Flags().StringVar(&app.Config.Runner.RedactionPath, "redaction-path", "/runner/redaction", "path where the runner can expect to find the redaction patterns taken from a ConfigMap, one pattern per line")
+	cmd.Flags().StringVar(&app.Config.Runner.PluginCache.Path, "plugin-cache-path", "/runner/plugin-cache", "path where the runner can expect to find the provider plugin cache shared between runners, if enabled")
+	cmd.Flags().DurationVar(&app.Config.Runner.LogsStreamInterval, "logs-stream-interval", 5*time.Second, "period between two uploads of the runner logs to the datastore while the runner is running. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Runner.CancelGracePeriod, "cancel-grace-period", 2*time.Minute, "time given to terraform to stop after the run has been cancelled, before the runner exits. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Runner.PlanMaxAge, "plan-max-age", 0, "maximum age of a plan to be applied, 0 disables the check. Must end with s, m or h.")
+	cmd.Flags().BoolVar(&app.Config.Runner.JSONOutput, "json-output", false, "run plan and apply with the machine-readable output of terraform, to record their events")
+	cmd.Flags().StringVar(&app.Config.Runner.ToolsMirror, "tools-mirror", "", "URL or local directory serving the releases of terraform, opentofu and terragrunt, used instead of their upstream endpoints")
+	cmd.Flags().DurationVar(&app.Config.Runner.Timeout, "timeout", 0, "maximum duration of the run, terraform is interrupted when it is exceeded. 0 disables the timeout. Must end with s, m or h.")
 	return cmd
 }
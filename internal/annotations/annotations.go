@@ -12,11 +12,21 @@ const (
 	LastApplyDate   string = "runner.terraform.padok.cloud/apply-date"
 	LastApplyCommit string = "runner.terraform.padok.cloud/apply-commit"
 	// LastApplyRun    string = "runner.terraform.padok.cloud/apply-run"
-	LastPlanCommit string = "runner.terraform.padok.cloud/plan-commit"
-	LastPlanDate   string = "runner.terraform.padok.cloud/plan-date"
-	LastPlanSum    string = "runner.terraform.padok.cloud/plan-sum"
-	LastPlanRun    string = "runner.terraform.padok.cloud/plan-run"
-	Lock           string = "runner.terraform.padok.cloud/lock"
+	LastPlanCommit       string = "runner.terraform.padok.cloud/plan-commit"
+	LastPlanDate         string = "runner.terraform.padok.cloud/plan-date"
+	LastPlanSum          string = "runner.terraform.padok.cloud/plan-sum"
+	LastPlanRun          string = "runner.terraform.padok.cloud/plan-run"
+	LastPlanAction       string = "runner.terraform.padok.cloud/plan-action"
+	LastPlanPartial      string = "runner.terraform.padok.cloud/plan-partial"
+	LastPlanPolicyPassed string = "runner.terraform.padok.cloud/plan-policy-passed"
+	LastPlanHasChanges   string = "runner.terraform.padok.cloud/plan-has-changes"
+	LastPlanRedactions   string = "runner.terraform.padok.cloud/plan-redactions"
+	Lock                 string = "runner.terraform.padok.cloud/lock"
+
+	LastDriftDate      string = "runner.terraform.padok.cloud/drift-date"
+	LastDriftRun       string = "runner.terraform.padok.cloud/drift-run"
+	LastDriftResources string = "runner.terraform.padok.cloud/drift-resources"
+	LastDriftCount     string = "runner.terraform.padok.cloud/drift-count"
 
 	LastBranchCommit       string = "webhook.terraform.padok.cloud/branch-commit"
 	LastBranchCommitDate   string = "webhook.terraform.padok.cloud/branch-commit-date"
@@ -28,7 +38,11 @@ const (
 	AdditionnalTriggerPaths string = "config.terraform.padok.cloud/additionnal-trigger-paths"
 
 	SyncNow        string = "api.terraform.padok.cloud/sync-now"
+	SyncOptions    string = "api.terraform.padok.cloud/sync-options"
 	ApplyNow       string = "api.terraform.padok.cloud/apply-now"
+	DestroyNow     string = "api.terraform.padok.cloud/destroy-now"
+	Approvals      string = "api.terraform.padok.cloud/approvals"
+	CancelRun      string = "api.terraform.padok.cloud/cancel"
 	AllowedTenants string = "credentials.terraform.padok.cloud/allowed-tenants"
 )
 
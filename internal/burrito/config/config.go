@@ -27,6 +27,8 @@ type DatastoreConfig struct {
 	CertificateSecretName     string        `mapstructure:"certificateSecretName"`
 	Storage                   StorageConfig `mapstructure:"storage"`
 	AuthorizedServiceAccounts []string      `mapstructure:"serviceAccounts"`
+	// Service accounts allowed to read the values of sensitive outputs, in namespace/name format
+	OutputsServiceAccounts []string `mapstructure:"outputsServiceAccounts"`
 }
 
 type StorageConfig struct {
@@ -82,18 +84,48 @@ type ControllerTimers struct {
 	FailureGracePeriod time.Duration `mapstructure:"failureGracePeriod"`
 	RepositorySync     time.Duration `mapstructure:"repositorySync"`
 	CredentialsTTL     time.Duration `mapstructure:"credentialsTTL"`
+	RunTimeouts        RunTimeouts   `mapstructure:"runTimeouts"`
+}
+
+// Default maximum duration of the runs of each action, 0 disables the timeout
+type RunTimeouts struct {
+	Plan    time.Duration `mapstructure:"plan"`
+	Apply   time.Duration `mapstructure:"apply"`
+	Destroy time.Duration `mapstructure:"destroy"`
+	Drift   time.Duration `mapstructure:"drift"`
 }
 
 type RunnerConfig struct {
-	Action                     string      `mapstructure:"action"`
-	Layer                      Layer       `mapstructure:"layer"`
-	Run                        string      `mapstructure:"run"`
-	SSHKnownHostsConfigMapName string      `mapstructure:"sshKnownHostsConfigMapName"`
-	Image                      ImageConfig `mapstructure:"image"`
-	RunnerBinaryPath           string      `mapstructure:"runnerBinaryPath"`
-	RepositoryPath             string      `mapstructure:"repositoryPath"`
-	Args                       []string    `mapstructure:"args"`
-	Command                    []string    `mapstructure:"command"`
+	Action                     string            `mapstructure:"action"`
+	Layer                      Layer             `mapstructure:"layer"`
+	Run                        string            `mapstructure:"run"`
+	SSHKnownHostsConfigMapName string            `mapstructure:"sshKnownHostsConfigMapName"`
+	Image                      ImageConfig       `mapstructure:"image"`
+	RunnerBinaryPath           string            `mapstructure:"runnerBinaryPath"`
+	RepositoryPath             string            `mapstructure:"repositoryPath"`
+	VariablesPath              string            `mapstructure:"variablesPath"`
+	BackendConfigPath          string            `mapstructure:"backendConfigPath"`
+	PoliciesPath               string            `mapstructure:"policiesPath"`
+	LogsStreamInterval         time.Duration     `mapstructure:"logsStreamInterval"`
+	CancelGracePeriod          time.Duration     `mapstructure:"cancelGracePeriod"`
+	Timeout                    time.Duration     `mapstructure:"timeout"`
+	PlanMaxAge                 time.Duration     `mapstructure:"planMaxAge"`
+	JSONOutput                 bool              `mapstructure:"jsonOutput"`
+	ToolsMirror                string            `mapstructure:"toolsMirror"`
+	RedactionConfigMapName     string            `mapstructure:"redactionConfigMapName"`
+	RedactionPath              string            `mapstructure:"redactionPath"`
+	RedactionPatterns          []string          `mapstructure:"redactionPatterns"`
+	StoreUnredacted            bool              `mapstructure:"storeUnredacted"`
+	PluginCache                PluginCacheConfig `mapstructure:"pluginCache"`
+	Args                       []string          `mapstructure:"args"`
+	Command                    []string          `mapstructure:"command"`
+}
+
+type PluginCacheConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	ClaimName string        `mapstructure:"claimName"`
+	Path      string        `mapstructure:"path"`
+	MaxAge    time.Duration `mapstructure:"maxAge"`
 }
 
 type ImageConfig struct {
@@ -233,10 +265,15 @@ func TestConfig() *Config {
 				OnError:            1 * time.Minute,
 				RepositorySync:     5 * time.Minute,
 				CredentialsTTL:     5 * time.Second,
+				RunTimeouts: RunTimeouts{
+					Plan:  1 * time.Hour,
+					Apply: 3 * time.Hour,
+				},
 			},
 		},
 		Runner: RunnerConfig{
 			SSHKnownHostsConfigMapName: "burrito-ssh-known-hosts",
+			RedactionConfigMapName:     "burrito-redaction-patterns",
 			Layer:                      Layer{},
 		},
 	}
@@ -1,5 +1,11 @@
 package burrito
 
+import "github.com/padok-team/burrito/internal/runner"
+
 func (app *App) StartRunner() error {
-	return app.Runner.Exec()
+	err := app.Runner.Exec()
+	if err != nil {
+		runner.WriteTerminationMessage(err)
+	}
+	return err
 }
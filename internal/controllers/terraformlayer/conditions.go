@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
 	terraformrun "github.com/padok-team/burrito/internal/controllers/terraformrun"
+	"github.com/padok-team/burrito/internal/utils/approval"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -50,7 +52,7 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformLayer) (metav1.Conditi
 		condition.Status = metav1.ConditionTrue
 		return condition, true
 	}
-	if run.Status.State != "Succeeded" && run.Status.State != "Failed" {
+	if run.Status.State != "Succeeded" && run.Status.State != "Failed" && run.Status.State != "Cancelled" {
 		condition.Reason = "RunStillRunning"
 		condition.Message = "The last run is still running"
 		condition.Status = metav1.ConditionTrue
@@ -62,7 +64,7 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformLayer) (metav1.Conditi
 	return condition, false
 }
 
-func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "IsLastPlanTooOld",
 		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
@@ -83,16 +85,86 @@ func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer) (metav1.
 		condition.Status = metav1.ConditionFalse
 		return condition, false
 	}
-	nextPlanDate := lastPlanDate.Add(r.Config.Controller.Timers.DriftDetection)
+	schedule := r.getDriftSchedule(t, repo)
+	nextPlanDate := schedule.Next(lastPlanDate)
 	now := r.Clock.Now()
 	if nextPlanDate.After(now) {
 		condition.Reason = "PlanIsRecent"
-		condition.Message = fmt.Sprintf("The plan has been made less than %s ago.", r.Config.Controller.Timers.DriftDetection)
+		condition.Message = fmt.Sprintf("The next plan is due at %s (%s).", nextPlanDate.Format(time.UnixDate), schedule)
 		condition.Status = metav1.ConditionFalse
 		return condition, false
 	}
 	condition.Reason = "PlanIsTooOld"
-	condition.Message = fmt.Sprintf("The plan has been made more than %s ago.", r.Config.Controller.Timers.DriftDetection)
+	condition.Message = fmt.Sprintf("The plan was due at %s (%s).", nextPlanDate.Format(time.UnixDate), schedule)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+func (r *Reconciler) IsLastDriftCheckTooOld(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastDriftCheckTooOld",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	// A layer which has never been checked for drift is considered checked by its last plan
+	value, ok := t.Annotations[annotations.LastDriftDate]
+	if !ok {
+		value, ok = t.Annotations[annotations.LastPlanDate]
+	}
+	if !ok {
+		condition.Reason = "NoDriftCheckHasRunYet"
+		condition.Message = "No drift check nor plan has run on this layer yet"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	lastCheckDate, err := time.Parse(time.UnixDate, value)
+	if err != nil {
+		condition.Reason = "ParseError"
+		condition.Message = "Burrito could not parse the time from the annotation, this is likely a bug, considering drift check is recent to lock the behavior"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	schedule := r.getDriftSchedule(t, repo)
+	nextCheckDate := schedule.Next(lastCheckDate)
+	if nextCheckDate.After(r.Clock.Now()) {
+		condition.Reason = "DriftCheckIsRecent"
+		condition.Message = fmt.Sprintf("The next drift check is due at %s (%s).", nextCheckDate.Format(time.UnixDate), schedule)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "DriftCheckIsTooOld"
+	condition.Message = fmt.Sprintf("The drift check was due at %s (%s).", nextCheckDate.Format(time.UnixDate), schedule)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+func (r *Reconciler) HasDrifted(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "Drifted",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if _, ok := t.Annotations[annotations.LastDriftDate]; !ok {
+		condition.Reason = "NoDriftCheckHasRunYet"
+		condition.Message = "No drift check has run on this layer yet"
+		return condition, false
+	}
+	resources := t.Annotations[annotations.LastDriftResources]
+	if resources == "" {
+		condition.Reason = "NoDrift"
+		condition.Message = "The last drift check found no resource changed outside of Terraform"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	listed := strings.Split(resources, ",")
+	condition.Reason = "ResourcesDrifted"
+	condition.Message = fmt.Sprintf("Resources changed outside of Terraform: %s", strings.Join(listed, ", "))
+	// Only the first resources are listed when many have drifted
+	if count, err := strconv.Atoi(t.Annotations[annotations.LastDriftCount]); err == nil && count > len(listed) {
+		condition.Message = fmt.Sprintf("%s and %d more, see the drift artifact of the last drift run.", condition.Message, count-len(listed))
+	}
 	condition.Status = metav1.ConditionTrue
 	return condition, true
 }
@@ -293,6 +365,20 @@ func (r *Reconciler) HasLastRunReachedRetryLimit(layer *configv1alpha1.Terraform
 		condition.Status = metav1.ConditionFalse
 		return condition, lastRunRetryInfo{}
 	}
+	currentRevision := layer.Annotations[annotations.LastRelevantCommit]
+	// A cancelled run is not retried automatically, until a new revision is available
+	if run.Status.State == "Cancelled" {
+		if currentRevision != "" && run.Spec.Layer.Revision != currentRevision {
+			condition.Reason = "NewRevisionAvailable"
+			condition.Message = "The last run has been cancelled but a new revision is available"
+			condition.Status = metav1.ConditionFalse
+			return condition, lastRunRetryInfo{action: run.Spec.Action}
+		}
+		condition.Reason = "LastRunCancelled"
+		condition.Message = fmt.Sprintf("The last %s run has been cancelled", run.Spec.Action)
+		condition.Status = metav1.ConditionTrue
+		return condition, lastRunRetryInfo{reachedLimit: true, action: run.Spec.Action}
+	}
 	if run.Status.State != "Failed" {
 		condition.Reason = "LastRunNotFailed"
 		condition.Message = "The last run has not failed"
@@ -306,7 +392,6 @@ func (r *Reconciler) HasLastRunReachedRetryLimit(layer *configv1alpha1.Terraform
 		condition.Status = metav1.ConditionFalse
 		return condition, lastRunRetryInfo{action: run.Spec.Action}
 	}
-	currentRevision := layer.Annotations[annotations.LastRelevantCommit]
 	if currentRevision != "" && run.Spec.Layer.Revision != currentRevision {
 		condition.Reason = "NewRevisionAvailable"
 		condition.Message = "The last run reached retry limit but a new revision is available"
@@ -339,6 +424,165 @@ func (r *Reconciler) IsApplyScheduled(t *configv1alpha1.TerraformLayer) (metav1.
 	return condition, false
 }
 
+func (r *Reconciler) IsDestroyScheduled(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsDestroyScheduled",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	// check if annotations.DestroyNow is present
+	if _, ok := t.Annotations[annotations.DestroyNow]; ok {
+		condition.Reason = "DestroyScheduled"
+		condition.Message = "A destroy has been manually scheduled"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NoDestroyScheduled"
+	condition.Message = "No destroy has been manually scheduled"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+func (r *Reconciler) IsLastPlanDestroy(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastPlanDestroy",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if t.Annotations[annotations.LastPlanAction] == string(DestroyAction) {
+		condition.Reason = "LastPlanIsDestroy"
+		condition.Message = "The last plan destroys all the resources of the layer"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "LastPlanIsNotDestroy"
+	condition.Message = "The last plan is not a destroy plan"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+func (r *Reconciler) IsLastPlanPartial(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastPlanPartial",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if t.Annotations[annotations.LastPlanPartial] == "true" {
+		condition.Reason = "LastPlanIsPartial"
+		condition.Message = "The last plan has been made with targets or replaces, it only covers part of the layer"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "LastPlanIsComplete"
+	condition.Message = "The last plan covers the whole layer"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+// HasPolicyPassed checks the policy report of the last plan, a layer with policies is never
+// considered compliant without a report
+func (r *Reconciler) HasPolicyPassed(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "PolicyPassed",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	switch t.Annotations[annotations.LastPlanPolicyPassed] {
+	case "true":
+		condition.Reason = "PolicyPassed"
+		condition.Message = "The last plan complies with the policies of the layer"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	case "false":
+		condition.Reason = "PolicyViolated"
+		condition.Message = "The last plan violates the policies of the layer, it can not be applied"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "NoPolicyEvaluated"
+	condition.Message = "No policy has been evaluated against the last plan"
+	if hasPolicies(t, repo) {
+		condition.Message = "The policies of the layer have not been evaluated against the last plan, it can not be applied"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	return condition, true
+}
+
+// isPolicyReportMissing returns true when the last plan of a layer with policies has no policy report
+func isPolicyReportMissing(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) bool {
+	_, hasPlanned := t.Annotations[annotations.LastPlanDate]
+	return hasPlanned && hasPolicies(t, repo) && t.Annotations[annotations.LastPlanPolicyPassed] == ""
+}
+
+func hasPolicies(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) bool {
+	return len(configv1alpha1.GetPolicies(repo, t).ConfigMaps) > 0
+}
+
+// IsPlanApproved checks that the last plan has received the approvals required by the approval
+// policy of the layer, and has not been rejected
+func (r *Reconciler) IsPlanApproved(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsPlanApproved",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	policy := configv1alpha1.GetApprovalPolicy(repo, t)
+	if approval.Required(policy) == 0 {
+		condition.Reason = "NoApprovalRequired"
+		condition.Message = "Plans of this layer are applied without approval"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	approvals := approval.Current(t)
+	if approvals.Run == "" {
+		condition.Reason = "NoPlan"
+		condition.Message = "This layer has not been planned yet"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if approvals.Rejection != nil {
+		condition.Reason = "PlanRejected"
+		condition.Message = fmt.Sprintf("The last plan has been rejected by %s, it will not be applied", approvals.Rejection.User)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if pending := approval.Pending(policy, approvals); pending > 0 {
+		condition.Reason = "ApprovalsPending"
+		condition.Message = fmt.Sprintf("The last plan is waiting for %d more approval(s) out of %d", pending, approval.Required(policy))
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "PlanApproved"
+	condition.Message = "The last plan has received the required approvals"
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// isApprovalPending returns true when the last plan is waiting for approvals and has not been rejected
+func isApprovalPending(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) bool {
+	policy := configv1alpha1.GetApprovalPolicy(repo, t)
+	approvals := approval.Current(t)
+	return approval.Required(policy) > 0 && approvals.Run != "" && approvals.Rejection == nil && approval.Pending(policy, approvals) > 0
+}
+
+// isDestroyApplied returns true when the last plan is a destroy plan and has been applied
+func isDestroyApplied(t *configv1alpha1.TerraformLayer) bool {
+	planSum := t.Annotations[annotations.LastPlanSum]
+	return t.Annotations[annotations.LastPlanAction] == string(DestroyAction) &&
+		planSum != "" && t.Annotations[annotations.LastApplySum] == planSum
+}
+
+// isDestroyPending returns true when the last plan is a destroy plan which has not been applied yet
+func isDestroyPending(t *configv1alpha1.TerraformLayer) bool {
+	return t.Annotations[annotations.LastPlanAction] == string(DestroyAction) && !isDestroyApplied(t)
+}
+
 func LayerFilesHaveChanged(layer configv1alpha1.TerraformLayer, changedFiles []string) bool {
 	if len(changedFiles) == 0 {
 		return true
@@ -366,6 +610,13 @@ func LayerFilesHaveChanged(layer configv1alpha1.TerraformLayer, changedFiles []s
 				}
 			}
 		}
+		// Check if the file is under a path the layer depends on, computed by the repository controller
+		for _, p := range layer.Status.TriggerPaths {
+			p = ensureAbsPath(p)
+			if f == p || strings.HasPrefix(f, p+"/") || p == "/." {
+				return true
+			}
+		}
 	}
 
 	return false
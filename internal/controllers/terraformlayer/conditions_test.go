@@ -1,11 +1,15 @@
 package terraformlayer_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
 	controller "github.com/padok-team/burrito/internal/controllers/terraformlayer"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -136,6 +140,45 @@ func TestLayerFilesHaveChanged(t *testing.T) {
 			changedFiles: []string{"environments/shared/common.tf"},
 			expected:     true,
 		},
+		{
+			name: "computed trigger path matches",
+			layer: configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path: "environments/dev",
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					TriggerPaths: []string{"live/root.hcl", "modules/vpc"},
+				},
+			},
+			changedFiles: []string{"modules/vpc/main.tf"},
+			expected:     true,
+		},
+		{
+			name: "computed trigger path matches a file",
+			layer: configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path: "environments/dev",
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					TriggerPaths: []string{"live/root.hcl", "modules/vpc"},
+				},
+			},
+			changedFiles: []string{"live/root.hcl"},
+			expected:     true,
+		},
+		{
+			name: "computed trigger path does not match sibling directories",
+			layer: configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path: "environments/dev",
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					TriggerPaths: []string{"modules/vpc"},
+				},
+			},
+			changedFiles: []string{"modules/vpc-peering/main.tf", "other/modules/vpc/main.tf"},
+			expected:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -147,3 +190,143 @@ func TestLayerFilesHaveChanged(t *testing.T) {
 		})
 	}
 }
+
+func newDriftReconciler() *controller.Reconciler {
+	c := &config.Config{}
+	c.Controller.Timers.DriftDetection = 20 * time.Minute
+	return &controller.Reconciler{Config: c, Clock: &MockClock{}}
+}
+
+func TestIsLastDriftCheckTooOld(t *testing.T) {
+	now, _ := time.Parse(time.UnixDate, testTime)
+	recent := now.Add(-5 * time.Minute).Format(time.UnixDate)
+	old := now.Add(-time.Hour).Format(time.UnixDate)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+		reason      string
+	}{
+		{"never checked nor planned", map[string]string{}, true, "NoDriftCheckHasRunYet"},
+		{"recent drift check", map[string]string{annotations.LastDriftDate: recent, annotations.LastPlanDate: old}, false, "DriftCheckIsRecent"},
+		{"old drift check", map[string]string{annotations.LastDriftDate: old, annotations.LastPlanDate: recent}, true, "DriftCheckIsTooOld"},
+		{"never checked but recently planned", map[string]string{annotations.LastPlanDate: recent}, false, "DriftCheckIsRecent"},
+		{"never checked and planned long ago", map[string]string{annotations.LastPlanDate: old}, true, "DriftCheckIsTooOld"},
+		{"unparsable date", map[string]string{annotations.LastDriftDate: "yesterday"}, false, "ParseError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := &configv1alpha1.TerraformLayer{ObjectMeta: metav1.ObjectMeta{Name: "layer", Namespace: "default", Annotations: tt.annotations}}
+			condition, got := newDriftReconciler().IsLastDriftCheckTooOld(layer, &configv1alpha1.TerraformRepository{})
+			if got != tt.expected || condition.Reason != tt.reason {
+				t.Errorf("IsLastDriftCheckTooOld() = %v (%s), want %v (%s)", got, condition.Reason, tt.expected, tt.reason)
+			}
+		})
+	}
+}
+
+func TestHasDrifted(t *testing.T) {
+	many := []string{}
+	for i := 0; i < 20; i++ {
+		many = append(many, "aws_s3_bucket.bucket"+strings.Repeat("_", i))
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+		reason      string
+		message     string
+	}{
+		{"never checked", map[string]string{}, false, "NoDriftCheckHasRunYet", ""},
+		{"no drift", map[string]string{annotations.LastDriftDate: testTime, annotations.LastDriftResources: "", annotations.LastDriftCount: "0"}, false, "NoDrift", ""},
+		{
+			"drifted resources",
+			map[string]string{annotations.LastDriftDate: testTime, annotations.LastDriftResources: "aws_instance.web,aws_s3_bucket.logs", annotations.LastDriftCount: "2"},
+			true, "ResourcesDrifted", "Resources changed outside of Terraform: aws_instance.web, aws_s3_bucket.logs",
+		},
+		{
+			"drifted resources without count",
+			map[string]string{annotations.LastDriftDate: testTime, annotations.LastDriftResources: "aws_instance.web"},
+			true, "ResourcesDrifted", "Resources changed outside of Terraform: aws_instance.web",
+		},
+		{
+			"more drifted resources than listed",
+			map[string]string{annotations.LastDriftDate: testTime, annotations.LastDriftResources: strings.Join(many, ","), annotations.LastDriftCount: "25"},
+			true, "ResourcesDrifted", "and 5 more, see the drift artifact of the last drift run.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := &configv1alpha1.TerraformLayer{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			condition, got := newDriftReconciler().HasDrifted(layer)
+			if got != tt.expected || condition.Reason != tt.reason {
+				t.Errorf("HasDrifted() = %v (%s), want %v (%s)", got, condition.Reason, tt.expected, tt.reason)
+			}
+			if !strings.HasSuffix(condition.Message, tt.message) {
+				t.Errorf("HasDrifted() message = %q, want it to end with %q", condition.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestHasValidDriftSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		layer    configv1alpha1.DriftDetection
+		repo     configv1alpha1.DriftDetection
+		expected bool
+		reason   string
+		message  string
+	}{
+		{"no schedule", configv1alpha1.DriftDetection{}, configv1alpha1.DriftDetection{}, true, "ValidSchedule", "Drift detection: every 20m0s"},
+		{"valid schedule", configv1alpha1.DriftDetection{Schedule: "0 * * * *"}, configv1alpha1.DriftDetection{}, true, "ValidSchedule", `Drift detection: schedule "0 * * * *"`},
+		{"invalid schedule", configv1alpha1.DriftDetection{Schedule: "every hour"}, configv1alpha1.DriftDetection{}, false, "InvalidSchedule", "drift is detected every 20m0s instead"},
+		{"schedule which never fires", configv1alpha1.DriftDetection{Schedule: "0 0 30 2 *"}, configv1alpha1.DriftDetection{}, false, "InvalidSchedule", "drift is detected every 20m0s instead"},
+		{"invalid schedule of the repository", configv1alpha1.DriftDetection{}, configv1alpha1.DriftDetection{Schedule: "61 * * * *"}, false, "InvalidSchedule", "drift is detected every 20m0s instead"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "layer", Namespace: "default"},
+				Spec:       configv1alpha1.TerraformLayerSpec{DriftDetection: tt.layer},
+			}
+			repo := &configv1alpha1.TerraformRepository{Spec: configv1alpha1.TerraformRepositorySpec{DriftDetection: tt.repo}}
+			condition, got := newDriftReconciler().HasValidDriftSchedule(layer, repo)
+			if got != tt.expected || condition.Reason != tt.reason {
+				t.Errorf("HasValidDriftSchedule() = %v (%s), want %v (%s)", got, condition.Reason, tt.expected, tt.reason)
+			}
+			if !strings.HasSuffix(condition.Message, tt.message) {
+				t.Errorf("HasValidDriftSchedule() message = %q, want it to end with %q", condition.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestHasPolicyPassed(t *testing.T) {
+	policies := configv1alpha1.Policies{ConfigMaps: []corev1.LocalObjectReference{{Name: "org-policies"}}}
+	tests := []struct {
+		name        string
+		policies    configv1alpha1.Policies
+		annotations map[string]string
+		expected    bool
+		reason      string
+	}{
+		{"no policy", configv1alpha1.Policies{}, map[string]string{annotations.LastPlanDate: testTime}, true, "NoPolicyEvaluated"},
+		{"policies passed", policies, map[string]string{annotations.LastPlanDate: testTime, annotations.LastPlanPolicyPassed: "true"}, true, "PolicyPassed"},
+		{"policies violated", policies, map[string]string{annotations.LastPlanDate: testTime, annotations.LastPlanPolicyPassed: "false"}, false, "PolicyViolated"},
+		{"missing report", policies, map[string]string{annotations.LastPlanDate: testTime}, false, "NoPolicyEvaluated"},
+		{"empty report", policies, map[string]string{annotations.LastPlanDate: testTime, annotations.LastPlanPolicyPassed: ""}, false, "NoPolicyEvaluated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "layer", Namespace: "default", Annotations: tt.annotations},
+				Spec:       configv1alpha1.TerraformLayerSpec{Policies: tt.policies},
+			}
+			condition, got := newDriftReconciler().HasPolicyPassed(layer, &configv1alpha1.TerraformRepository{})
+			if got != tt.expected || condition.Reason != tt.reason {
+				t.Errorf("HasPolicyPassed() = %v (%s), want %v (%s)", got, condition.Reason, tt.expected, tt.reason)
+			}
+		})
+	}
+}
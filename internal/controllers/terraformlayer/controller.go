@@ -19,6 +19,8 @@ package terraformlayer
 import (
 	"context"
 	e "errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -34,7 +36,9 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	log "github.com/sirupsen/logrus"
@@ -84,15 +88,6 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Errorf("failed to get TerraformLayer: %s", err)
 		return ctrl.Result{}, err
 	}
-	locked, err := lock.IsLayerLocked(ctx, r.Client, layer)
-	if err != nil {
-		log.Errorf("failed to get Lease Resource: %s", err)
-		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, err
-	}
-	if locked {
-		log.Infof("TerraformLayer %s is locked, skipping reconciliation.", layer.Name)
-		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
-	}
 	repository := &configv1alpha1.TerraformRepository{}
 	log.Infof("getting Linked TerraformRepository to layer %s", layer.Name)
 	err = r.Client.Get(ctx, types.NamespacedName{
@@ -107,11 +102,32 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Errorf("failed to get TerraformRepository linked to layer %s: %s", layer.Name, err)
 		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, err
 	}
+	locked, err := lock.IsLayerLocked(ctx, r.Client, layer, repository)
+	if err != nil {
+		log.Errorf("failed to get Lease Resource: %s", err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, err
+	}
+	if locked {
+		log.Infof("TerraformLayer %s is locked, skipping reconciliation.", layer.Name)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+	}
 	err = validateLayerConfig(layer, repository)
+	if err == nil {
+		err = r.validateBackendKey(ctx, layer, repository)
+	}
 	if err != nil {
 		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", err.Error())
 		return ctrl.Result{}, err
 	}
+	err = r.reconcileFinalizer(ctx, layer, repository)
+	if err != nil {
+		log.Errorf("failed to reconcile finalizer of layer %s: %s", layer.Name, err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, err
+	}
+	if !layer.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(layer, DestroyFinalizer) {
+		log.Infof("layer %s is being deleted, nothing to destroy", layer.Name)
+		return ctrl.Result{}, nil
+	}
 	state, conditions := r.GetState(ctx, layer, repository)
 	lastResult := []byte("Layer has never been planned")
 	if layer.Status.LastRun.Name != "" {
@@ -123,13 +139,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 	result, run := state.getHandler()(ctx, r, layer, repository)
+	if _, ok := state.(*Destroyed); ok {
+		log.Infof("layer %s has been destroyed, finished reconciliation cycle", layer.Name)
+		return result, nil
+	}
 	lastRun := layer.Status.LastRun
 	runHistory := layer.Status.LatestRuns
 	if run != nil {
 		lastRun = getRun(*run)
 		runHistory = updateLatestRuns(runHistory, *run, *configv1alpha1.GetRunHistoryPolicy(repository, layer).KeepLastRuns)
 	}
-	layer.Status = configv1alpha1.TerraformLayerStatus{Conditions: conditions, State: getStateString(state), LastResult: string(lastResult), LastRun: lastRun, LatestRuns: runHistory}
+	layer.Status = configv1alpha1.TerraformLayerStatus{Conditions: conditions, State: getStateString(state), LastResult: string(lastResult), LastRun: lastRun, LatestRuns: runHistory, TriggerPaths: layer.Status.TriggerPaths, TriggerPathsCommit: layer.Status.TriggerPathsCommit}
 	err = r.Client.Status().Update(ctx, layer)
 	if err != nil {
 		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Could not update layer status")
@@ -143,6 +163,24 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return result, nil
 }
 
+// Add or remove the destroy finalizer depending on the destroyOnDelete setting of the layer.
+// The finalizer is kept once the layer is being deleted, until its resources are destroyed.
+func (r *Reconciler) reconcileFinalizer(ctx context.Context, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) error {
+	if !layer.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	enabled := configv1alpha1.GetDestroyOnDeleteEnabled(repository, layer)
+	if enabled == controllerutil.ContainsFinalizer(layer, DestroyFinalizer) {
+		return nil
+	}
+	if enabled {
+		controllerutil.AddFinalizer(layer, DestroyFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(layer, DestroyFinalizer)
+	}
+	return r.Client.Update(ctx, layer)
+}
+
 func (r *Reconciler) cleanupRuns(ctx context.Context, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) error {
 	historyPolicy := configv1alpha1.GetRunHistoryPolicy(repository, layer)
 	runs, err := r.getAllRuns(ctx, layer)
@@ -209,6 +247,8 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Clock = RealClock{}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1alpha1.TerraformLayer{}).
+		// Layers are reconciled when a layer they depend on changes, to be planned after its applies
+		Watches(&configv1alpha1.TerraformLayer{}, handler.EnqueueRequestsFromMapFunc(r.getDependentLayers)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.Config.Controller.MaxConcurrentReconciles}).
 		WithEventFilter(ignorePredicate()).
 		Complete(r)
@@ -238,3 +278,93 @@ func validateLayerConfig(layer *configv1alpha1.TerraformLayer, repository *confi
 	}
 	return nil
 }
+
+// Reject the layer if an older layer has the same backend configuration, since both layers would write
+// to the same state. Only layers setting a backend configuration of their own are compared, the one of a
+// repository alone is shared by all its layers. The temporary layers of pull requests are never compared,
+// they plan on the state of the layer they are generated from.
+func (r *Reconciler) validateBackendKey(ctx context.Context, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) error {
+	if len(layer.Spec.BackendConfig) == 0 || isPullRequestLayer(layer) {
+		return nil
+	}
+	key, err := configv1alpha1.GetBackendKey(repository, layer, r.backendConfigResolver(ctx, layer.Namespace))
+	if err != nil {
+		return fmt.Errorf("TerraformLayer configuration is invalid: %w", err)
+	}
+	if key == "" {
+		return nil
+	}
+	layers := &configv1alpha1.TerraformLayerList{}
+	if err := r.Client.List(ctx, layers); err != nil {
+		return err
+	}
+	repositories := map[types.NamespacedName]*configv1alpha1.TerraformRepository{}
+	for _, other := range layers.Items {
+		if other.Namespace == layer.Namespace && other.Name == layer.Name {
+			continue
+		}
+		if len(other.Spec.BackendConfig) == 0 || isPullRequestLayer(&other) || !isOlderLayer(&other, layer) {
+			continue
+		}
+		name := types.NamespacedName{Namespace: other.Spec.Repository.Namespace, Name: other.Spec.Repository.Name}
+		otherRepository, ok := repositories[name]
+		if !ok {
+			otherRepository = &configv1alpha1.TerraformRepository{}
+			if err := r.Client.Get(ctx, name, otherRepository); err != nil {
+				continue
+			}
+			repositories[name] = otherRepository
+		}
+		otherKey, err := configv1alpha1.GetBackendKey(otherRepository, &other, r.backendConfigResolver(ctx, other.Namespace))
+		if err != nil {
+			continue
+		}
+		if otherKey == key {
+			return fmt.Errorf("TerraformLayer configuration is invalid: layer %s/%s has the same backend configuration, both layers would write to the same state", other.Namespace, other.Name)
+		}
+	}
+	return nil
+}
+
+// Return a function resolving the values of backend configuration taken from the ConfigMaps and
+// Secrets of namespace
+func (r *Reconciler) backendConfigResolver(ctx context.Context, namespace string) func(source *configv1alpha1.VariableSource) (string, error) {
+	return func(source *configv1alpha1.VariableSource) (string, error) {
+		switch {
+		case source.SecretKeyRef != nil:
+			secret := &corev1.Secret{}
+			err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.SecretKeyRef.Name}, secret)
+			if errors.IsNotFound(err) && source.SecretKeyRef.Optional != nil && *source.SecretKeyRef.Optional {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(string(secret.Data[source.SecretKeyRef.Key]), "\n"), nil
+		case source.ConfigMapKeyRef != nil:
+			configMap := &corev1.ConfigMap{}
+			err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.ConfigMapKeyRef.Name}, configMap)
+			if errors.IsNotFound(err) && source.ConfigMapKeyRef.Optional != nil && *source.ConfigMapKeyRef.Optional {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(configMap.Data[source.ConfigMapKeyRef.Key], "\n"), nil
+		}
+		return "", fmt.Errorf("unsupported value source")
+	}
+}
+
+func isPullRequestLayer(layer *configv1alpha1.TerraformLayer) bool {
+	return len(layer.OwnerReferences) > 0 && layer.OwnerReferences[0].Kind == "TerraformPullRequest"
+}
+
+// A layer is older than another one if it has been created first. Layers created at the same time are
+// ordered by name, so that only one of two conflicting layers is ever rejected.
+func isOlderLayer(layer *configv1alpha1.TerraformLayer, other *configv1alpha1.TerraformLayer) bool {
+	if !layer.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return layer.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return fmt.Sprintf("%s/%s", layer.Namespace, layer.Name) < fmt.Sprintf("%s/%s", other.Namespace, other.Name)
+}
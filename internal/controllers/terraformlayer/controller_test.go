@@ -114,6 +114,16 @@ func getResult(name types.NamespacedName, r *controller.Reconciler) (reconcile.R
 	return result, layer, reconcileError, err
 }
 
+func getLinkedRepository(layer *configv1alpha1.TerraformLayer) *configv1alpha1.TerraformRepository {
+	repository := &configv1alpha1.TerraformRepository{}
+	err := k8sClient.Get(context.TODO(), types.NamespacedName{
+		Name:      layer.Spec.Repository.Name,
+		Namespace: layer.Spec.Repository.Namespace,
+	}, repository)
+	Expect(err).NotTo(HaveOccurred())
+	return repository
+}
+
 func getLinkedRuns(cl client.Client, layer *configv1alpha1.TerraformLayer) (*configv1alpha1.TerraformRunList, error) {
 	list := &configv1alpha1.TerraformRunList{}
 	selector := labels.NewSelector()
@@ -239,10 +249,11 @@ var _ = Describe("Layer", func() {
 				Expect(layer.Status.State).To(Equal("Idle"))
 			})
 			It("should not be locked", func() {
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeFalse())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeFalse())
 			})
-			It("should set RequeueAfter to DriftDetection", func() {
-				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+			It("should set RequeueAfter to the next drift detection of the layer", func() {
+				Expect(result.RequeueAfter).To(BeNumerically(">=", reconciler.Config.Controller.Timers.DriftDetection))
+				Expect(result.RequeueAfter).To(BeNumerically("<", 22*time.Minute))
 			})
 			It("should not have created any TerraformRun", func() {
 				runs, err := getLinkedRuns(k8sClient, layer)
@@ -265,7 +276,7 @@ var _ = Describe("Layer", func() {
 				Expect(reconcileError).NotTo(HaveOccurred())
 			})
 			It("should be locked", func() {
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should not update status", func() {
 				Expect(layer.Status.State).To(Equal(""))
@@ -372,8 +383,9 @@ var _ = Describe("Layer", func() {
 		It("should end in Idle state", func() {
 			Expect(layer.Status.State).To(Equal("Idle"))
 		})
-		It("should set RequeueAfter to DriftDetection", func() {
-			Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+		It("should set RequeueAfter to the next drift detection of the layer", func() {
+			Expect(result.RequeueAfter).To(BeNumerically(">=", reconciler.Config.Controller.Timers.DriftDetection))
+			Expect(result.RequeueAfter).To(BeNumerically("<", 22*time.Minute))
 		})
 	})
 	Describe("When a TerraformLayer has errored once on plan and not in grace period anymore", Ordered, func() {
@@ -424,8 +436,9 @@ var _ = Describe("Layer", func() {
 		It("should end in Idle state", func() {
 			Expect(layer.Status.State).To(Equal("Idle"))
 		})
-		It("should set RequeueAfter to DriftDetection", func() {
-			Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+		It("should set RequeueAfter to the next drift detection of the layer", func() {
+			Expect(result.RequeueAfter).To(BeNumerically(">=", 14*time.Minute))
+			Expect(result.RequeueAfter).To(BeNumerically("<", 16*time.Minute))
 		})
 	})
 	Describe("When a TerraformLayer has errored on apply and was done before current plan", Ordered, func() {
@@ -999,6 +1012,422 @@ var _ = Describe("Layer", func() {
 			})
 		})
 	})
+	Describe("Stale plan case", Ordered, func() {
+		var layer *configv1alpha1.TerraformLayer
+		var reconcileError error
+		var err error
+		var name types.NamespacedName
+
+		BeforeAll(func() {
+			planMaxAgeConfig := config.TestConfig()
+			planMaxAgeConfig.Runner.PlanMaxAge = 5 * time.Minute
+			name = types.NamespacedName{
+				Name:      "stale-plan-case-1",
+				Namespace: "default",
+			}
+			_, layer, reconcileError, err = getResult(name, getReconcilerWithConfig(planMaxAgeConfig))
+		})
+		It("should still exists", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should not return an error", func() {
+			Expect(reconcileError).NotTo(HaveOccurred())
+		})
+		It("should end in ApplyNeeded state", func() {
+			Expect(layer.Status.State).To(Equal("ApplyNeeded"))
+		})
+		It("should have created a plan TerraformRun instead of an apply TerraformRun", func() {
+			runs, err := getLinkedRuns(k8sClient, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(runs.Items)).To(Equal(1))
+			Expect(runs.Items[0].Spec.Action).To(Equal("plan"))
+		})
+	})
+	Describe("Stale destroy plan case", Ordered, func() {
+		var layer *configv1alpha1.TerraformLayer
+		var reconcileError error
+		var err error
+
+		BeforeAll(func() {
+			planMaxAgeConfig := config.TestConfig()
+			planMaxAgeConfig.Runner.PlanMaxAge = 5 * time.Minute
+			_, layer, reconcileError, err = getResult(types.NamespacedName{
+				Name:      "stale-plan-case-2",
+				Namespace: "default",
+			}, getReconcilerWithConfig(planMaxAgeConfig))
+		})
+		It("should not return an error", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconcileError).NotTo(HaveOccurred())
+		})
+		It("should have created a destroy TerraformRun instead of an apply TerraformRun", func() {
+			runs, err := getLinkedRuns(k8sClient, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(runs.Items)).To(Equal(1))
+			Expect(runs.Items[0].Spec.Action).To(Equal("destroy"))
+		})
+	})
+	Describe("Policies case", Ordered, func() {
+		var layer *configv1alpha1.TerraformLayer
+		var reconcileError error
+		var err error
+
+		BeforeAll(func() {
+			_, layer, reconcileError, err = getResult(types.NamespacedName{
+				Name:      "policies-case-1",
+				Namespace: "default",
+			}, reconciler)
+		})
+		It("should not return an error", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconcileError).NotTo(HaveOccurred())
+		})
+		It("should not consider a plan without policy report as compliant", func() {
+			Expect(layer.Status.Conditions[13].Type).To(Equal("PolicyPassed"))
+			Expect(layer.Status.Conditions[13].Reason).To(Equal("NoPolicyEvaluated"))
+			Expect(layer.Status.Conditions[13].Status).To(Equal(metav1.ConditionFalse))
+		})
+		It("should have created a plan TerraformRun instead of an apply TerraformRun", func() {
+			runs, err := getLinkedRuns(k8sClient, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(runs.Items)).To(Equal(1))
+			Expect(runs.Items[0].Spec.Action).To(Equal("plan"))
+		})
+	})
+	Describe("Dependencies case", func() {
+		Describe("When a layer depends on a layer which has not been applied", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "dependencies-case-1",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in DependenciesPending state", func() {
+				Expect(layer.Status.State).To(Equal("DependenciesPending"))
+			})
+			It("should have the AreDependenciesApplied condition set to False", func() {
+				Expect(layer.Status.Conditions[14].Type).To(Equal("AreDependenciesApplied"))
+				Expect(layer.Status.Conditions[14].Status).To(Equal(metav1.ConditionFalse))
+				Expect(layer.Status.Conditions[14].Reason).To(Equal("DependencyNotApplied"))
+			})
+			It("should not have created any TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(BeEmpty())
+			})
+		})
+		Describe("When the dependencies of a layer form a cycle", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "dependencies-case-2",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in DependenciesPending state", func() {
+				Expect(layer.Status.State).To(Equal("DependenciesPending"))
+			})
+			It("should report the cycle in the AreDependenciesApplied condition", func() {
+				Expect(layer.Status.Conditions[14].Status).To(Equal(metav1.ConditionFalse))
+				Expect(layer.Status.Conditions[14].Reason).To(Equal("DependencyCycle"))
+				Expect(layer.Status.Conditions[14].Message).To(ContainSubstring("default/dependencies-case-2 -> default/dependencies-case-3 -> default/dependencies-case-2"))
+			})
+			It("should not have created any TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(BeEmpty())
+			})
+		})
+		Describe("When a layer it depends on has been planned without changes at its last relevant commit", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "dependencies-case-5",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should have the AreDependenciesApplied condition set to True", func() {
+				Expect(layer.Status.Conditions[14].Status).To(Equal(metav1.ConditionTrue))
+				Expect(layer.Status.Conditions[14].Reason).To(Equal("DependenciesApplied"))
+			})
+			It("should have created a plan TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(HaveLen(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("plan"))
+			})
+		})
+		Describe("When a layer it depends on has applied changes after its last plan", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "dependencies-case-4",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in PlanNeeded state", func() {
+				Expect(layer.Status.State).To(Equal("PlanNeeded"))
+			})
+			It("should have the AreDependenciesApplied condition set to True", func() {
+				Expect(layer.Status.Conditions[14].Status).To(Equal(metav1.ConditionTrue))
+				Expect(layer.Status.Conditions[14].Reason).To(Equal("DependenciesApplied"))
+			})
+			It("should have created a plan TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(HaveLen(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("plan"))
+			})
+		})
+	})
+	Describe("Drift schedule case", func() {
+		Describe("When a layer has a drift detection period longer than the one of the controller", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var result reconcile.Result
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				result, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "drift-schedule-case-1",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in Idle state", func() {
+				Expect(layer.Status.State).To(Equal("Idle"))
+			})
+			It("should have the IsLastPlanTooOld condition set to False", func() {
+				Expect(layer.Status.Conditions[1].Status).To(Equal(metav1.ConditionFalse))
+				Expect(layer.Status.Conditions[1].Reason).To(Equal("PlanIsRecent"))
+			})
+			It("should set RequeueAfter to the next drift detection of the layer", func() {
+				Expect(result.RequeueAfter).To(BeNumerically(">=", 23*time.Hour))
+				Expect(result.RequeueAfter).To(BeNumerically("<", 24*time.Hour))
+			})
+		})
+		Describe("When a tick of the drift detection schedule of a layer has passed since its last plan", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "drift-schedule-case-2",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in PlanNeeded state", func() {
+				Expect(layer.Status.State).To(Equal("PlanNeeded"))
+			})
+			It("should have the IsLastPlanTooOld condition set to True", func() {
+				Expect(layer.Status.Conditions[1].Status).To(Equal(metav1.ConditionTrue))
+				Expect(layer.Status.Conditions[1].Reason).To(Equal("PlanIsTooOld"))
+			})
+			It("should have created a plan TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(HaveLen(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("plan"))
+			})
+		})
+	})
+	Describe("Approvals case", func() {
+		Describe("When the last plan of a layer in autoApply mode is waiting for approvals", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "approvals-case-1",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in ApprovalPending state", func() {
+				Expect(layer.Status.State).To(Equal("ApprovalPending"))
+			})
+			It("should have the IsPlanApproved condition set to False", func() {
+				Expect(layer.Status.Conditions[15].Type).To(Equal("IsPlanApproved"))
+				Expect(layer.Status.Conditions[15].Status).To(Equal(metav1.ConditionFalse))
+				Expect(layer.Status.Conditions[15].Reason).To(Equal("ApprovalsPending"))
+				Expect(layer.Status.Conditions[15].Message).To(ContainSubstring("2 more approval(s) out of 2"))
+			})
+			It("should not have created any TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(BeEmpty())
+			})
+		})
+		Describe("When the last plan of a layer in autoApply mode has been approved", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "approvals-case-2",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in ApplyNeeded state", func() {
+				Expect(layer.Status.State).To(Equal("ApplyNeeded"))
+			})
+			It("should have the IsPlanApproved condition set to True", func() {
+				Expect(layer.Status.Conditions[15].Status).To(Equal(metav1.ConditionTrue))
+				Expect(layer.Status.Conditions[15].Reason).To(Equal("PlanApproved"))
+			})
+			It("should have created an apply TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(HaveLen(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("apply"))
+			})
+		})
+		Describe("When the last plan of a layer waiting for approvals is due for drift detection", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var result reconcile.Result
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				result, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "approvals-case-3",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in ApprovalPending state", func() {
+				Expect(layer.Status.State).To(Equal("ApprovalPending"))
+			})
+			It("should not have created any TerraformRun discarding the approvals", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(BeEmpty())
+			})
+			It("should set RequeueAfter to WaitAction as the drift detection is overdue", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
+			})
+		})
+	})
+	Describe("Backend configuration case", func() {
+		Describe("When a layer has the same backend configuration as a newer layer", Ordered, func() {
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, _, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "backend-config-case-1",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+		})
+		Describe("When a layer has the same backend configuration as an older layer", Ordered, func() {
+			var layer *configv1alpha1.TerraformLayer
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, layer, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "backend-config-case-2",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should return an error naming the older layer", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).To(HaveOccurred())
+				Expect(reconcileError.Error()).To(ContainSubstring("layer default/backend-config-case-1 has the same backend configuration"))
+			})
+			It("should not have created any TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs.Items).To(BeEmpty())
+			})
+		})
+		Describe("When a layer of a pull request has the backend configuration of the layer it is generated from", Ordered, func() {
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, _, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "backend-config-case-3",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+		})
+		Describe("When a layer uses a secret of the same name as a layer of another namespace", Ordered, func() {
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, _, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "backend-config-case-4",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should not return an error since the secrets hold different values", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+		})
+		Describe("When a layer uses another secret holding the backend configuration of an older layer", Ordered, func() {
+			var reconcileError error
+			var err error
+			BeforeAll(func() {
+				_, _, reconcileError, err = getResult(types.NamespacedName{
+					Name:      "backend-config-case-6",
+					Namespace: "default",
+				}, reconciler)
+			})
+			It("should return an error naming the older layer", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).To(HaveOccurred())
+				Expect(reconcileError.Error()).To(ContainSubstring("layer default/backend-config-case-4 has the same backend configuration"))
+			})
+		})
+	})
 })
 
 var _ = AfterSuite(func() {
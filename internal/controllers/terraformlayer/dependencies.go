@@ -0,0 +1,135 @@
+package terraformlayer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/utils/layergraph"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type dependenciesInfo struct {
+	applied bool
+	cycle   bool
+	// An upstream layer has applied changes since the last plan of the layer
+	planOutdated bool
+}
+
+// AreDependenciesApplied checks that the layers of dependsOn have been applied at the
+// last relevant commit of the layer or a newer one, and that they do not form a cycle
+func (r *Reconciler) AreDependenciesApplied(t *configv1alpha1.TerraformLayer) (metav1.Condition, dependenciesInfo) {
+	condition := metav1.Condition{
+		Type:               "AreDependenciesApplied",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if len(t.Spec.DependsOn) == 0 {
+		condition.Reason = "NoDependencies"
+		condition.Message = "This layer does not depend on other layers"
+		condition.Status = metav1.ConditionTrue
+		return condition, dependenciesInfo{applied: true}
+	}
+	layers := &configv1alpha1.TerraformLayerList{}
+	if err := r.Client.List(context.TODO(), layers); err != nil {
+		condition.Reason = "ListError"
+		condition.Message = fmt.Sprintf("Could not list layers to check dependencies: %s", err)
+		return condition, dependenciesInfo{}
+	}
+	graph := layergraph.New(layers.Items)
+	if cycle := graph.FindCycle(layergraph.Key(t.Namespace, t.Name)); cycle != nil {
+		condition.Reason = "DependencyCycle"
+		condition.Message = fmt.Sprintf("Dependencies form a cycle, the layer is neither planned nor applied: %s", layergraph.FormatCycle(cycle))
+		condition.Status = metav1.ConditionFalse
+		return condition, dependenciesInfo{cycle: true}
+	}
+	indexed := map[string]*configv1alpha1.TerraformLayer{}
+	for i, layer := range layers.Items {
+		indexed[layergraph.Key(layer.Namespace, layer.Name)] = &layers.Items[i]
+	}
+	var lastApply time.Time
+	for _, key := range layergraph.Dependencies(t) {
+		upstream, ok := indexed[key]
+		if !ok {
+			condition.Reason = "DependencyNotFound"
+			condition.Message = fmt.Sprintf("Layer %s does not exist", key)
+			condition.Status = metav1.ConditionFalse
+			return condition, dependenciesInfo{}
+		}
+		if !isDependencyApplied(upstream, t) {
+			condition.Reason = "DependencyNotApplied"
+			condition.Message = fmt.Sprintf("Waiting for layer %s to be applied at commit %s or a newer one", key, t.Annotations[annotations.LastRelevantCommit])
+			condition.Status = metav1.ConditionFalse
+			return condition, dependenciesInfo{}
+		}
+		// Applies without changes do not affect the layer
+		if upstream.Annotations[annotations.LastPlanHasChanges] == "false" {
+			continue
+		}
+		if applyDate, err := time.Parse(time.UnixDate, upstream.Annotations[annotations.LastApplyDate]); err == nil && applyDate.After(lastApply) {
+			lastApply = applyDate
+		}
+	}
+	info := dependenciesInfo{applied: true}
+	if planDate, err := time.Parse(time.UnixDate, t.Annotations[annotations.LastPlanDate]); err == nil && planDate.Before(lastApply) {
+		info.planOutdated = true
+	}
+	condition.Reason = "DependenciesApplied"
+	condition.Message = "All the layers this layer depends on have been applied"
+	condition.Status = metav1.ConditionTrue
+	return condition, info
+}
+
+// An upstream layer is applied for a layer when it has been applied at the revision of the layer,
+// or when its last relevant commit has been applied and it has been synced after the revision of the layer.
+// A plan without changes at the last relevant commit of the upstream layer leaves nothing to apply,
+// it counts as an apply of this commit.
+func isDependencyApplied(upstream *configv1alpha1.TerraformLayer, layer *configv1alpha1.TerraformLayer) bool {
+	applyCommit := upstream.Annotations[annotations.LastApplyCommit]
+	if planCommit := upstream.Annotations[annotations.LastPlanCommit]; upstream.Annotations[annotations.LastPlanHasChanges] == "false" && planCommit == upstream.Annotations[annotations.LastRelevantCommit] {
+		applyCommit = planCommit
+	}
+	if applyCommit == "" {
+		return false
+	}
+	if applyCommit == layer.Annotations[annotations.LastRelevantCommit] {
+		return true
+	}
+	if applyCommit != upstream.Annotations[annotations.LastRelevantCommit] {
+		return false
+	}
+	synced, err := time.Parse(time.UnixDate, upstream.Annotations[annotations.LastBranchCommitDate])
+	if err != nil {
+		return false
+	}
+	revisionDate, err := time.Parse(time.UnixDate, layer.Annotations[annotations.LastRelevantCommitDate])
+	if err != nil {
+		return false
+	}
+	return !synced.Before(revisionDate)
+}
+
+// Returns the requests of the layers depending on the given one
+func (r *Reconciler) getDependentLayers(ctx context.Context, obj client.Object) []reconcile.Request {
+	layers := &configv1alpha1.TerraformLayerList{}
+	if err := r.Client.List(ctx, layers); err != nil {
+		log.Errorf("could not list layers depending on %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	key := layergraph.Key(obj.GetNamespace(), obj.GetName())
+	requests := []reconcile.Request{}
+	for _, layer := range layers.Items {
+		if slices.Contains(layergraph.Dependencies(&layer), key) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: layer.Namespace, Name: layer.Name}})
+		}
+	}
+	return requests
+}
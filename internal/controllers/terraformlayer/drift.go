@@ -0,0 +1,68 @@
+package terraformlayer
+
+import (
+	"fmt"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/utils/driftschedule"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getDriftSchedule returns the drift detection schedule of the layer, the drift detection period
+// of the controller is used when the layer has no schedule or an invalid one
+func (r *Reconciler) getDriftSchedule(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) *driftschedule.Schedule {
+	expression, period := configv1alpha1.GetDriftSchedule(repo, t)
+	key := fmt.Sprintf("%s/%s", t.Namespace, t.Name)
+	schedule, err := driftschedule.New(key, expression, period, r.Config.Controller.Timers.DriftDetection)
+	if err != nil {
+		log.Errorf("layer %s falls back to the drift detection period of the controller: %s", t.Name, err)
+		return driftschedule.Every(key, r.Config.Controller.Timers.DriftDetection)
+	}
+	return schedule
+}
+
+// HasValidDriftSchedule reports whether the cron schedule of the drift detections of the layer can be
+// parsed and ever fires, the drift detection period of the controller is used instead when it cannot
+func (r *Reconciler) HasValidDriftSchedule(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasValidDriftSchedule",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	expression, period := configv1alpha1.GetDriftSchedule(repo, t)
+	_, err := driftschedule.New(fmt.Sprintf("%s/%s", t.Namespace, t.Name), expression, period, r.Config.Controller.Timers.DriftDetection)
+	if err != nil {
+		condition.Reason = "InvalidSchedule"
+		condition.Message = fmt.Sprintf("%s, drift is detected every %s instead", err, r.Config.Controller.Timers.DriftDetection)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "ValidSchedule"
+	condition.Message = fmt.Sprintf("Drift detection: %s", r.getDriftSchedule(t, repo))
+	return condition, true
+}
+
+// getDriftRequeue returns the delay until the next drift detection of an idle layer, including its jitter
+func (r *Reconciler) getDriftRequeue(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) time.Duration {
+	// In refresh-only mode, drift checks replace the periodic plans
+	value, ok := t.Annotations[annotations.LastPlanDate]
+	if driftDate, hasDrift := t.Annotations[annotations.LastDriftDate]; hasDrift && configv1alpha1.GetDriftDetectionMode(repo, t) == configv1alpha1.DriftDetectionModeRefreshOnly {
+		value, ok = driftDate, true
+	}
+	if !ok {
+		return r.Config.Controller.Timers.DriftDetection
+	}
+	last, err := time.Parse(time.UnixDate, value)
+	if err != nil {
+		return r.Config.Controller.Timers.DriftDetection
+	}
+	next := r.getDriftSchedule(t, repo).Next(last).Sub(r.Clock.Now())
+	if next <= 0 {
+		return r.Config.Controller.Timers.WaitAction
+	}
+	return next
+}
@@ -16,10 +16,16 @@ import (
 type Action string
 
 const (
-	PlanAction  Action = "plan"
-	ApplyAction Action = "apply"
+	PlanAction    Action = "plan"
+	ApplyAction   Action = "apply"
+	DestroyAction Action = "destroy"
+	DriftAction   Action = "drift"
 )
 
+// DestroyFinalizer is set on layers with destroyOnDelete enabled, so that their
+// resources are destroyed before the layer is removed
+const DestroyFinalizer = "config.terraform.padok.cloud/destroy-on-delete"
+
 func GetDefaultLabels(layer *configv1alpha1.TerraformLayer) map[string]string {
 	return map[string]string{
 		"burrito/managed-by": layer.Name,
@@ -2,16 +2,20 @@ package terraformlayer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	"github.com/padok-team/burrito/internal/utils/syncwindow"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 type Handler func(context.Context, *Reconciler, *configv1alpha1.TerraformLayer, *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun)
@@ -23,38 +27,110 @@ type State interface {
 func (r *Reconciler) GetState(ctx context.Context, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (State, []metav1.Condition) {
 	log := log.WithContext(ctx)
 	c1, IsRunning := r.IsRunning(layer)
-	c2, IsLastPlanTooOld := r.IsLastPlanTooOld(layer)
+	c2, IsLastPlanTooOld := r.IsLastPlanTooOld(layer, repo)
 	c3, IsLastRelevantCommitPlanned := r.IsLastRelevantCommitPlanned(layer)
 	c4, HasLastPlanFailed := r.HasLastPlanFailed(layer)
 	c5, IsApplyUpToDate := r.IsApplyUpToDate(layer)
 	c6, IsSyncScheduled := r.IsSyncScheduled(layer)
 	c7, retryInfo := r.HasLastRunReachedRetryLimit(layer, repo)
 	c8, IsApplyScheduled := r.IsApplyScheduled(layer)
-	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7, c8}
+	c9, IsDestroyScheduled := r.IsDestroyScheduled(layer)
+	c10, IsLastPlanDestroy := r.IsLastPlanDestroy(layer)
+	c11, IsLastDriftCheckTooOld := r.IsLastDriftCheckTooOld(layer, repo)
+	c12, _ := r.HasDrifted(layer)
+	c13, _ := r.IsLastPlanPartial(layer)
+	c14, _ := r.HasPolicyPassed(layer, repo)
+	c15, dependencies := r.AreDependenciesApplied(layer)
+	c16, IsPlanApproved := r.IsPlanApproved(layer, repo)
+	c17, _ := r.HasValidDriftSchedule(layer, repo)
+	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7, c8, c9, c10, c11, c12, c13, c14, c15, c16, c17}
 	LastPlanExhausted := retryInfo.reachedLimit && retryInfo.action == string(PlanAction)
 	LastApplyExhausted := retryInfo.reachedLimit && retryInfo.action == string(ApplyAction)
+	LastDestroyExhausted := retryInfo.reachedLimit && retryInfo.action == string(DestroyAction)
+	LastDriftExhausted := retryInfo.reachedLimit && retryInfo.action == string(DriftAction)
+	// In refresh-only mode, the drift detection period triggers drift checks instead of plans,
+	// a plan is only needed when the layer has never been planned or has new commits
+	_, hasPlanned := layer.Annotations[annotations.LastPlanDate]
+	refreshOnly := configv1alpha1.GetDriftDetectionMode(repo, layer) == configv1alpha1.DriftDetectionModeRefreshOnly && hasPlanned
+	IsPlanDue := IsLastPlanTooOld && !refreshOnly
+	IsApplyPending := !IsApplyUpToDate && !HasLastPlanFailed && !LastApplyExhausted
+	// Planning again discards the approvals, a plan waiting for them is only planned again for new commits or a sync
+	IsAwaitingApprovals := isApprovalPending(layer, repo) && (IsApplyScheduled || (IsApplyPending && configv1alpha1.GetAutoApplyEnabled(repo, layer)))
+	// A plan without the policy report the layer requires is planned again
+	IsPlanOutdated := (IsPlanDue && !IsAwaitingApprovals) || !IsLastRelevantCommitPlanned || dependencies.planOutdated || isPolicyReportMissing(layer, repo)
+	isDeleting := !layer.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(layer, DestroyFinalizer)
 	switch {
 	case IsRunning:
 		log.Infof("layer %s is running, waiting for the run to finish", layer.Name)
 		return &Idle{}, conditions
+	case isDeleting && isDestroyApplied(layer):
+		log.Infof("layer %s is being deleted and its resources have been destroyed", layer.Name)
+		return &Destroyed{}, conditions
+	case IsDestroyScheduled || (isDeleting && !IsLastPlanDestroy && !LastDestroyExhausted):
+		log.Infof("layer %s needs to be destroyed, creating a new destroy run", layer.Name)
+		// Remove annotation only when we actually act on it
+		if IsDestroyScheduled {
+			if err := annotations.Remove(ctx, r.Client, layer, annotations.DestroyNow); err != nil {
+				log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.DestroyNow, layer.Name, err)
+			}
+		}
+		return &DestroyNeeded{}, conditions
+	case isDeleting && IsApplyScheduled && IsPlanApproved:
+		log.Infof("layer %s is being deleted and has a manual apply scheduled, creating a new apply run", layer.Name)
+		if err := annotations.Remove(ctx, r.Client, layer, annotations.ApplyNow); err != nil {
+			log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.ApplyNow, layer.Name, err)
+		}
+		return &ApplyNeeded{isManual: true}, conditions
+	case isDeleting && !HasLastPlanFailed && !LastApplyExhausted && !LastDestroyExhausted && IsPlanApproved:
+		log.Infof("layer %s is being deleted, applying its destroy plan", layer.Name)
+		return &ApplyNeeded{isManual: false}, conditions
+	case isDeleting && (IsApplyScheduled || (!HasLastPlanFailed && !LastApplyExhausted && !LastDestroyExhausted)):
+		log.Infof("layer %s is being deleted, its destroy plan is waiting for approvals", layer.Name)
+		return &ApprovalPending{}, conditions
+	case isDeleting:
+		log.Infof("layer %s is being deleted but its destroy has reached max retries, requires manual intervention", layer.Name)
+		return &MaxRetriesReached{}, conditions
+	case !dependencies.applied:
+		// Manual syncs and applies are kept until the dependencies are applied
+		log.Infof("layer %s is waiting for the layers it depends on to be applied", layer.Name)
+		return &DependenciesPending{cycle: dependencies.cycle}, conditions
 	case IsSyncScheduled:
 		log.Infof("layer %s has a sync scheduled, creating a new run", layer.Name)
-		// Remove annotation only when we actually act on it
-		if err := annotations.Remove(ctx, r.Client, layer, annotations.SyncNow); err != nil {
-			log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.SyncNow, layer.Name, err)
+		options := configv1alpha1.PlanOptions{}
+		if value, ok := layer.Annotations[annotations.SyncOptions]; ok {
+			if err := json.Unmarshal([]byte(value), &options); err != nil {
+				log.Errorf("failed to parse annotation %s of layer %s, ignoring sync options: %s", annotations.SyncOptions, layer.Name, err)
+			}
 		}
-		return &PlanNeeded{}, conditions
-	case IsApplyScheduled:
+		// Remove annotations only when we actually act on them
+		for _, annotation := range []string{annotations.SyncNow, annotations.SyncOptions} {
+			if err := annotations.Remove(ctx, r.Client, layer, annotation); err != nil {
+				log.Errorf("failed to remove annotation %s from layer %s: %s", annotation, layer.Name, err)
+			}
+		}
+		return &PlanNeeded{options: options}, conditions
+	case IsApplyScheduled && IsPlanApproved:
 		log.Infof("layer %s has a manual apply scheduled, creating a new apply run", layer.Name)
 		// Remove annotation only when we actually act on it
 		if err := annotations.Remove(ctx, r.Client, layer, annotations.ApplyNow); err != nil {
 			log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.ApplyNow, layer.Name, err)
 		}
 		return &ApplyNeeded{isManual: true}, conditions
-	case (IsLastPlanTooOld || !IsLastRelevantCommitPlanned) && !LastPlanExhausted:
+	case IsPlanOutdated && !LastPlanExhausted && isDestroyPending(layer):
+		// A manual destroy is only cancelled by a sync
+		log.Infof("layer %s has an outdated destroy plan, creating a new destroy run", layer.Name)
+		return &DestroyNeeded{}, conditions
+	case IsPlanOutdated && !LastPlanExhausted:
 		log.Infof("layer %s has an outdated plan, creating a new run", layer.Name)
 		return &PlanNeeded{}, conditions
-	case !IsApplyUpToDate && !HasLastPlanFailed && !LastApplyExhausted:
+	case refreshOnly && IsLastDriftCheckTooOld && !LastDriftExhausted && !(IsApplyPending && configv1alpha1.GetAutoApplyEnabled(repo, layer)):
+		log.Infof("layer %s has an outdated drift check, creating a new drift run", layer.Name)
+		return &DriftCheckNeeded{}, conditions
+	case !IsPlanApproved && (IsApplyScheduled || (IsApplyPending && configv1alpha1.GetAutoApplyEnabled(repo, layer))):
+		// Manual applies are kept until the plan is approved
+		log.Infof("layer %s needs to be applied, its last plan is waiting for approvals", layer.Name)
+		return &ApprovalPending{}, conditions
+	case IsApplyPending:
 		log.Infof("layer %s needs to be applied, creating a new run", layer.Name)
 		return &ApplyNeeded{isManual: false}, conditions
 	case LastPlanExhausted || LastApplyExhausted:
@@ -70,11 +146,36 @@ type Idle struct{}
 
 func (s *Idle) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
-		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		return ctrl.Result{RequeueAfter: r.getDriftRequeue(layer, repository)}, nil
+	}
+}
+
+type DependenciesPending struct {
+	cycle bool
+}
+
+func (s *DependenciesPending) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		if s.cycle {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Dependencies of the layer form a cycle, it is neither planned nor applied")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		}
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+	}
+}
+
+type ApprovalPending struct{}
+
+func (s *ApprovalPending) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		// Approvals are recorded in an annotation of the layer, which triggers a reconciliation
+		return ctrl.Result{RequeueAfter: r.getDriftRequeue(layer, repository)}, nil
 	}
 }
 
-type PlanNeeded struct{}
+type PlanNeeded struct {
+	options configv1alpha1.PlanOptions
+}
 
 func (s *PlanNeeded) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
@@ -90,6 +191,7 @@ func (s *PlanNeeded) getHandler() Handler {
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
 		}
 		run := r.getRun(layer, revision, PlanAction)
+		run.Spec.PlanOptions = s.options
 		err := r.Client.Create(ctx, &run)
 		if err != nil {
 			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for Plan action")
@@ -115,6 +217,27 @@ func (s *ApplyNeeded) getHandler() Handler {
 				log.Infof("autoApply is disabled for layer %s, no apply action taken", layer.Name)
 				return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
 			}
+			if !r.isLastPlanAutoApplicable(ctx, layer) {
+				log.Infof("last plan of layer %s is partial and was not requested to be applied automatically, no apply action taken", layer.Name)
+				r.Recorder.Event(layer, corev1.EventTypeNormal, "Reconciliation", "Last plan is partial, it must be applied manually")
+				return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+			}
+		}
+		// Plans violating the policies are never applied, even manually
+		if _, passed := r.HasPolicyPassed(layer, repository); !passed {
+			log.Infof("last plan of layer %s violates its policies, no apply action taken", layer.Name)
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Last plan violates the policies of the layer, it can not be applied")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		}
+		// A plan of an older commit, or too old, is planned again instead of being applied,
+		// in destroy mode for a destroy plan
+		if err := r.checkPlanFreshness(layer); err != nil {
+			log.Infof("last plan of layer %s can not be applied, planning again: %s", layer.Name, err)
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", fmt.Sprintf("Last plan can not be applied, planning again: %s", err))
+			if !layer.DeletionTimestamp.IsZero() || isDestroyPending(layer) {
+				return (&DestroyNeeded{}).getHandler()(ctx, r, layer, repository)
+			}
+			return (&PlanNeeded{}).getHandler()(ctx, r, layer, repository)
 		}
 		// Check for sync windows that would block the apply action
 		if isActionBlocked(r, layer, repository, syncwindow.ApplyAction) {
@@ -126,8 +249,16 @@ func (s *ApplyNeeded) getHandler() Handler {
 			log.Errorf("layer %s has no last relevant commit annotation, run not created", layer.Name)
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
 		}
+		options, err := r.getLastPlanOptions(ctx, layer)
+		if err != nil {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to get the options of the last plan, Apply run not created")
+			log.Errorf("failed to get the options of the last plan of layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
 		run := r.getRun(layer, revision, ApplyAction)
-		err := r.Client.Create(ctx, &run)
+		// The runner passes them again when applying without the plan artifact
+		run.Spec.PlanOptions = options
+		err = r.Client.Create(ctx, &run)
 		if err != nil {
 			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for Apply action")
 			log.Errorf("failed to create TerraformRun for Apply action on layer %s: %s", layer.Name, err)
@@ -138,6 +269,85 @@ func (s *ApplyNeeded) getHandler() Handler {
 	}
 }
 
+// The runner checks again that the plan is still fresh, and that its artifact is the one recorded on the layer
+func (r *Reconciler) checkPlanFreshness(layer *configv1alpha1.TerraformLayer) error {
+	if _, planned := r.IsLastRelevantCommitPlanned(layer); !planned {
+		return fmt.Errorf("the last relevant commit %s has not been planned", layer.Annotations[annotations.LastRelevantCommit])
+	}
+	return runnerutils.CheckPlanAge(layer.Annotations[annotations.LastPlanDate], r.Config.Runner.PlanMaxAge, r.Clock.Now())
+}
+
+type DestroyNeeded struct{}
+
+func (s *DestroyNeeded) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		// A destroy run only plans the destruction, it is subject to the plan sync windows
+		if isActionBlocked(r, layer, repository, syncwindow.PlanAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		revision, ok := layer.Annotations[annotations.LastRelevantCommit]
+		if !ok {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last relevant commit annotation, Destroy run not created")
+			log.Errorf("layer %s has no last relevant commit annotation, run not created", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		run := r.getRun(layer, revision, DestroyAction)
+		err := r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for Destroy action")
+			log.Errorf("failed to create TerraformRun for Destroy action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		r.Recorder.Event(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for Destroy action")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
+type DriftCheckNeeded struct{}
+
+func (s *DriftCheckNeeded) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		// A drift check is a refresh-only plan, it is subject to the plan sync windows
+		if isActionBlocked(r, layer, repository, syncwindow.PlanAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		revision, ok := layer.Annotations[annotations.LastRelevantCommit]
+		if !ok {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last relevant commit annotation, Drift run not created")
+			log.Errorf("layer %s has no last relevant commit annotation, run not created", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		run := r.getRun(layer, revision, DriftAction)
+		err := r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for Drift action")
+			log.Errorf("failed to create TerraformRun for Drift action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		r.Recorder.Event(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for Drift action")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
+type Destroyed struct{}
+
+func (s *Destroyed) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		controllerutil.RemoveFinalizer(layer, DestroyFinalizer)
+		err := r.Client.Update(ctx, layer)
+		if err != nil {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to remove destroy finalizer")
+			log.Errorf("failed to remove finalizer %s from layer %s: %s", DestroyFinalizer, layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		log.Infof("removed finalizer %s from layer %s", DestroyFinalizer, layer.Name)
+		return ctrl.Result{}, nil
+	}
+}
+
 type MaxRetriesReached struct{}
 
 func (s *MaxRetriesReached) getHandler() Handler {
@@ -149,6 +359,33 @@ func (s *MaxRetriesReached) getHandler() Handler {
 	}
 }
 
+// A partial plan is only applied automatically when explicitly requested on its run
+func (r *Reconciler) isLastPlanAutoApplicable(ctx context.Context, layer *configv1alpha1.TerraformLayer) bool {
+	if layer.Annotations[annotations.LastPlanPartial] != "true" {
+		return true
+	}
+	options, err := r.getLastPlanOptions(ctx, layer)
+	if err != nil {
+		log.Errorf("failed to get the options of the last plan of layer %s: %s", layer.Name, err)
+		return false
+	}
+	return options.AutoApply
+}
+
+// Return the options of the last plan of the layer, read from its run if the plan is partial
+func (r *Reconciler) getLastPlanOptions(ctx context.Context, layer *configv1alpha1.TerraformLayer) (configv1alpha1.PlanOptions, error) {
+	if layer.Annotations[annotations.LastPlanPartial] != "true" {
+		return configv1alpha1.PlanOptions{}, nil
+	}
+	runName := strings.Split(layer.Annotations[annotations.LastPlanRun], "/")[0]
+	run := &configv1alpha1.TerraformRun{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: layer.Namespace, Name: runName}, run)
+	if err != nil {
+		return configv1alpha1.PlanOptions{}, err
+	}
+	return run.Spec.PlanOptions, nil
+}
+
 func getStateString(state State) string {
 	t := strings.Split(fmt.Sprintf("%T", state), ".")
 	return t[len(t)-1]
@@ -2,10 +2,15 @@ package comment
 
 import (
 	"bytes"
+	"encoding/json"
 	"text/template"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/utils/policy"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 
 	_ "embed"
 )
@@ -21,6 +26,8 @@ type ReportedLayer struct {
 	ShortDiff  string
 	Path       string
 	PrettyPlan string
+	Summary    *runnerutils.PlanSummary
+	Policy     *policy.Report
 }
 
 type DefaultComment struct {
@@ -55,6 +62,30 @@ func (c *DefaultComment) Generate(commit string) (string, error) {
 			ShortDiff:  string(shortDiff),
 			PrettyPlan: string(plan),
 		}
+		content, err := c.datastore.GetPlan(layer.Namespace, layer.Name, layer.Status.LastRun.Name, "", "summary")
+		// Plans made by older runners have no summary
+		if err != nil && !storageerrors.NotFound(err) {
+			return "", err
+		}
+		if err == nil && len(content) > 0 {
+			summary := &runnerutils.PlanSummary{}
+			if err := json.Unmarshal(content, summary); err != nil {
+				return "", err
+			}
+			reportedLayer.Summary = summary
+		}
+		// Policies are only evaluated when the layer has some
+		if layer.Annotations[annotations.LastPlanPolicyPassed] != "" {
+			content, err := c.datastore.GetPlan(layer.Namespace, layer.Name, layer.Status.LastRun.Name, "", "policy")
+			if err != nil {
+				return "", err
+			}
+			report := &policy.Report{}
+			if err := json.Unmarshal(content, report); err != nil {
+				return "", err
+			}
+			reportedLayer.Policy = report
+		}
 		reportedLayers = append(reportedLayers, reportedLayer)
 
 	}
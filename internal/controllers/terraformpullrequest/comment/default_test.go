@@ -2,6 +2,13 @@ package comment
 
 import (
 	_ "embed"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // func TestDefaultComment_Generate(t *testing.T) {
@@ -34,3 +41,43 @@ import (
 // 		})
 // 	}
 // }
+
+type plansDatastore struct {
+	datastore.MockClient
+	plans map[string]string
+}
+
+func (d *plansDatastore) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
+	return []byte(d.plans[format]), nil
+}
+
+func TestDefaultComment_GenerateWithPolicyViolations(t *testing.T) {
+	layer := configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-layer",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotations.LastPlanPolicyPassed: "false",
+			},
+		},
+		Spec: configv1alpha1.TerraformLayerSpec{Path: "terraform/"},
+	}
+	store := &plansDatastore{plans: map[string]string{
+		"pretty":  "pretty plan",
+		"short":   "Plan: 0 to create, 0 to update, 1 to delete",
+		"summary": `{"create":0,"update":0,"delete":1,"replace":0,"resources":[{"address":"aws_db_instance.main","actions":["delete"],"replace":false}],"outputs":[]}`,
+		"policy":  `{"passed":false,"policies":["org/no-database-deletion.cel"],"violations":[{"policy":"org/no-database-deletion.cel","address":"aws_db_instance.main","message":"resource change is not allowed"}]}`,
+	}}
+	got, err := NewDefaultComment([]configv1alpha1.TerraformLayer{layer}, store).Generate("abcdef")
+	if err != nil {
+		t.Fatalf("DefaultComment.Generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"| `aws_db_instance.main` | delete |",
+		"| `org/no-database-deletion.cel` | `aws_db_instance.main` | resource change is not allowed |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DefaultComment.Generate() = %v, want it to contain %v", got, want)
+		}
+	}
+}
@@ -92,12 +92,19 @@ func generateTempLayers(pr *configv1alpha1.TerraformPullRequest, layers []config
 			Spec: configv1alpha1.TerraformLayerSpec{
 				Path:             layer.Spec.Path,
 				Branch:           pr.Spec.Branch,
+				Workspace:        layer.Spec.Workspace,
+				Variables:        layer.Spec.Variables,
+				BackendConfig:    layer.Spec.BackendConfig,
+				Policies:         layer.Spec.Policies,
 				TerraformConfig:  layer.Spec.TerraformConfig,
 				TerragruntConfig: layer.Spec.TerragruntConfig,
 				OpenTofuConfig:   layer.Spec.OpenTofuConfig,
+				Hooks:            layer.Spec.Hooks,
+				Timeouts:         layer.Spec.Timeouts,
 				Repository:       layer.Spec.Repository,
 				RemediationStrategy: configv1alpha1.RemediationStrategy{
-					AutoApply: &[]bool{false}[0],
+					AutoApply:       &[]bool{false}[0],
+					DestroyOnDelete: &[]bool{false}[0],
 				},
 				RunHistoryPolicy:   layer.Spec.RunHistoryPolicy,
 				OverrideRunnerSpec: layer.Spec.OverrideRunnerSpec,
@@ -527,6 +527,16 @@ var _ = Describe("Run", func() {
 				Expect(layer.Annotations).To(HaveKeyWithValue(annotations.LastBranchCommit, mock.GetMockRevision("branch-3")))
 				Expect(layer.Annotations).To(HaveKeyWithValue(annotations.LastRelevantCommit, "LAST_RELEVANT_REVISION"))
 			})
+			It("should update the LastRelevantCommit annotation of the Terraform layers whose local modules changed", func() {
+				layer := &configv1alpha1.TerraformLayer{}
+				Expect(k8sClient.Get(context.TODO(), types.NamespacedName{
+					Name:      "repo-last-sync-too-old-layer-4",
+					Namespace: "default",
+				}, layer)).To(Succeed())
+				Expect(layer.Status.TriggerPaths).To(Equal([]string{"modules/random-pets"}))
+				Expect(layer.Status.TriggerPathsCommit).To(Equal(mock.GetMockRevision("branch-3")))
+				Expect(layer.Annotations).To(HaveKeyWithValue(annotations.LastRelevantCommit, mock.GetMockRevision("branch-3")))
+			})
 			It("should have put multiple bundles in the datastore", func() {
 				check, err := reconciler.Datastore.CheckGitBundle(repo.Namespace, repo.Name, "branch-1", mock.GetMockRevision("branch-1"))
 				Expect(err).NotTo(HaveOccurred())
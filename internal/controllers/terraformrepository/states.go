@@ -11,10 +11,12 @@ import (
 	layerCtrl "github.com/padok-team/burrito/internal/controllers/terraformlayer"
 	repo "github.com/padok-team/burrito/internal/repository"
 	"github.com/padok-team/burrito/internal/repository/types"
+	"github.com/padok-team/burrito/internal/utils/triggerpaths"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -184,6 +186,13 @@ func (r *Reconciler) annotateLayers(gitProvider types.GitProvider, layers []conf
 	for _, layer := range layers {
 		ann := map[string]string{}
 
+		// Resolve the paths the layer depends on at the latest revision, before looking for changes
+		if layer.Status.TriggerPathsCommit != latestRev {
+			if err := r.updateTriggerPaths(gitProvider, &layer, latestRev); err != nil {
+				log.Warnf("could not compute trigger paths of layer %s/%s at revision %s: %s", layer.Namespace, layer.Name, latestRev, err)
+			}
+		}
+
 		// If the layer already has the latest branch commit == latestRev, we skip it
 		if currentLastBranch, ok := layer.Annotations[annotations.LastBranchCommit]; !ok || currentLastBranch != latestRev {
 			ann[annotations.LastBranchCommit] = latestRev
@@ -213,3 +222,19 @@ func (r *Reconciler) annotateLayers(gitProvider types.GitProvider, layers []conf
 	}
 	return err
 }
+
+// Computes the paths the layer depends on from its HCL at the given revision and stores them on its status
+func (r *Reconciler) updateTriggerPaths(gitProvider types.GitProvider, layer *configv1alpha1.TerraformLayer, revision string) error {
+	files, err := gitProvider.GetFiles(revision)
+	if err != nil {
+		return err
+	}
+	paths, err := triggerpaths.Compute(files, layer.Spec.Path)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(layer.DeepCopy())
+	layer.Status.TriggerPaths = paths
+	layer.Status.TriggerPathsCommit = revision
+	return r.Client.Status().Patch(context.TODO(), layer, patch)
+}
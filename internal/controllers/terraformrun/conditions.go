@@ -3,9 +3,11 @@ package terraformrun
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -164,6 +166,86 @@ func (r *Reconciler) IsInFailureGracePeriod(t *configv1alpha1.TerraformRun) (met
 	return condition, false
 }
 
+func (r *Reconciler) HasHookFailed(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasHookFailed",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	failed := []string{}
+	for _, hook := range t.Status.Hooks {
+		if hook.ExitCode == 0 {
+			continue
+		}
+		message := fmt.Sprintf("%s (%s, exit code %d)", hook.Name, hook.Phase, hook.ExitCode)
+		if !hook.FailOnError {
+			message = fmt.Sprintf("%s (%s, exit code %d, ignored)", hook.Name, hook.Phase, hook.ExitCode)
+		}
+		failed = append(failed, message)
+	}
+	if len(failed) > 0 {
+		condition.Reason = "HookFailed"
+		condition.Message = fmt.Sprintf("Some hooks have failed: %s", strings.Join(failed, ", "))
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NoHookFailed"
+	condition.Message = "No hook has failed"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+func (r *Reconciler) IsCancelled(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsCancelled",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if _, ok := t.Annotations[annotations.CancelRun]; ok {
+		condition.Reason = "CancelRequested"
+		condition.Message = "This run has been cancelled"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NotCancelled"
+	condition.Message = "This run has not been cancelled"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+// Beginning of the termination message written by the runner when it has exceeded its timeout
+const timeoutMessagePrefix = "run timed out"
+
+func (r *Reconciler) HasTimedOut(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasTimedOut",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if t.Status.RunnerPod != "" {
+		pod := &corev1.Pod{}
+		err := r.Client.Get(context.Background(), types.NamespacedName{
+			Name:      t.Status.RunnerPod,
+			Namespace: t.Namespace,
+		}, pod)
+		// The pod is stopped by its deadline if the runner could not interrupt terraform itself
+		if err == nil && pod.Status.Phase == corev1.PodFailed &&
+			(pod.Status.Reason == "DeadlineExceeded" || strings.HasPrefix(getRunnerTerminationMessage(pod), timeoutMessagePrefix)) {
+			condition.Reason = "AttemptTimedOut"
+			condition.Message = fmt.Sprintf("The attempt of pod %s has exceeded its timeout", t.Status.RunnerPod)
+			condition.Status = metav1.ConditionTrue
+			return condition, true
+		}
+	}
+	condition.Reason = "NotTimedOut"
+	condition.Message = "The last attempt has not timed out"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
 func getLastActionTime(r *Reconciler, run *configv1alpha1.TerraformRun) (time.Time, error) {
 	lastActionTime, err := time.Parse(time.UnixDate, run.Status.LastRun)
 	if err != nil {
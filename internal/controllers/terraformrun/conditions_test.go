@@ -0,0 +1,76 @@
+package terraformrun_test
+
+import (
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	controller "github.com/padok-team/burrito/internal/controllers/terraformrun"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHasHookFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		hooks    []configv1alpha1.HookResult
+		expected bool
+		reason   string
+		message  string
+	}{
+		{
+			name:     "no hook",
+			expected: false,
+			reason:   "NoHookFailed",
+		},
+		{
+			name: "all hooks succeeded",
+			hooks: []configv1alpha1.HookResult{
+				{Name: "lint", Phase: configv1alpha1.HookPhasePrePlan, ExitCode: 0, FailOnError: true},
+			},
+			expected: false,
+			reason:   "NoHookFailed",
+		},
+		{
+			name: "a hook failed",
+			hooks: []configv1alpha1.HookResult{
+				{Name: "lint", Phase: configv1alpha1.HookPhasePrePlan, ExitCode: 0, FailOnError: true},
+				{Name: "notify", Phase: configv1alpha1.HookPhasePostApply, ExitCode: 1, FailOnError: true},
+			},
+			expected: true,
+			reason:   "HookFailed",
+			message:  "notify (post-apply, exit code 1)",
+		},
+		{
+			name: "an ignored hook failed",
+			hooks: []configv1alpha1.HookResult{
+				{Name: "lint", Phase: configv1alpha1.HookPhasePrePlan, ExitCode: 2, FailOnError: false},
+			},
+			expected: true,
+			reason:   "HookFailed",
+			message:  "lint (pre-plan, exit code 2, ignored)",
+		},
+	}
+	r := &controller.Reconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &configv1alpha1.TerraformRun{Status: configv1alpha1.TerraformRunStatus{Hooks: tt.hooks}}
+			condition, failed := r.HasHookFailed(run)
+			if failed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, failed)
+			}
+			expectedStatus := metav1.ConditionFalse
+			if tt.expected {
+				expectedStatus = metav1.ConditionTrue
+			}
+			if condition.Status != expectedStatus {
+				t.Errorf("expected status %s, got %s", expectedStatus, condition.Status)
+			}
+			if condition.Reason != tt.reason {
+				t.Errorf("expected reason %s, got %s", tt.reason, condition.Reason)
+			}
+			if !strings.Contains(condition.Message, tt.message) {
+				t.Errorf("expected message to contain %q, got %q", tt.message, condition.Message)
+			}
+		})
+	}
+}
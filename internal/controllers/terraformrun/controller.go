@@ -19,6 +19,7 @@ package terraformrun
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -127,15 +128,36 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 		run.Status.Attempts = append(run.Status.Attempts, attempt)
 	}
+	reason := run.Status.Reason
+	units := run.Status.Units
+	hooks := run.Status.Hooks
+	resources := run.Status.Resources
+	resourcesOmitted := run.Status.ResourcesOmitted
+	if runInfo.NewPod {
+		reason = ""
+		units = nil
+		hooks = nil
+		resources = nil
+		resourcesOmitted = 0
+	} else if message := r.getTerminationMessage(runInfo.RunnerPod, run.Namespace); message != "" {
+		reason = message
+	}
+	runResult := r.GetRunResult(run, runInfo)
 	run.Status = configv1alpha1.TerraformRunStatus{
-		Conditions: conditions,
-		State:      getStateString(state),
-		Retries:    runInfo.Retries,
-		LastRun:    runInfo.LastRun,
-		RunnerPod:  runInfo.RunnerPod,
-		Attempts:   run.Status.Attempts,
+		Conditions:       conditions,
+		State:            getStateString(state),
+		Retries:          runInfo.Retries,
+		LastRun:          runInfo.LastRun,
+		RunnerPod:        runInfo.RunnerPod,
+		Attempts:         run.Status.Attempts,
+		Reason:           reason,
+		Units:            units,
+		Hooks:            hooks,
+		Resources:        resources,
+		ResourcesOmitted: resourcesOmitted,
+		Result:           runResult,
 	}
-	err = r.uploadLogs(run)
+	err = r.uploadLogs(ctx, run, layer, repo)
 	if err != nil {
 		r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Failed to upload logs")
 		log.Errorf("failed to upload logs for run %s: %s", run.Name, err)
@@ -149,7 +171,59 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return result, nil
 }
 
-func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
+// GetRunResult returns the result reported by the runner of the current attempt once its pod has finished,
+// the result already on the status until then
+func (r *Reconciler) GetRunResult(run *configv1alpha1.TerraformRun, runInfo RunInfo) *configv1alpha1.RunResult {
+	if runInfo.NewPod || runInfo.RunnerPod == "" {
+		return nil
+	}
+	current := run.Status.Result
+	if current != nil && current.Attempt == runInfo.Retries {
+		return current
+	}
+	switch r.getPodPhase(runInfo.RunnerPod, run.Namespace) {
+	case corev1.PodPending, corev1.PodRunning:
+		return current
+	}
+	content, err := r.Datastore.GetPlan(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(runInfo.Retries), "result")
+	if err != nil || len(content) == 0 {
+		log.Debugf("no result reported by the runner of run %s/%s: %s", run.Namespace, run.Name, err)
+		return current
+	}
+	result := &configv1alpha1.RunResult{}
+	if err := json.Unmarshal(content, result); err != nil {
+		log.Errorf("could not parse the result reported by the runner of run %s/%s: %s", run.Namespace, run.Name, err)
+		return current
+	}
+	return result
+}
+
+// Return the message written by the runner when its pod failed, empty if it has not failed
+func (r *Reconciler) getTerminationMessage(name string, namespace string) string {
+	if name == "" {
+		return ""
+	}
+	pod := &corev1.Pod{}
+	err := r.Client.Get(context.Background(), types.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, pod)
+	if err != nil || pod.Status.Phase != corev1.PodFailed {
+		return ""
+	}
+	return getRunnerTerminationMessage(pod)
+}
+
+func getRunnerTerminationMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "runner" && status.State.Terminated != nil {
+			return status.State.Terminated.Message
+		}
+	}
+	return ""
+}
+
+func (r *Reconciler) uploadLogs(ctx context.Context, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) error {
 	for i, attempt := range run.Status.Attempts {
 		if attempt.LogsUploaded {
 			continue
@@ -171,6 +245,12 @@ func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
 			log.Infof("pod %s is not in a terminal state, ignoring...", attempt.PodName)
 			continue
 		}
+		// The runner streams its own logs, they are complete once it has uploaded their final part.
+		// Otherwise the runner stopped before, and the logs of the pod are uploaded whole.
+		if r.hasFinalLogsPart(run, attempt.Number) {
+			run.Status.Attempts[i].LogsUploaded = true
+			continue
+		}
 		req := r.K8SLogClient.CoreV1().Pods(run.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{})
 		logs, err := req.Stream(context.Background())
 		// Upload logs
@@ -185,7 +265,8 @@ func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
 			log.Errorf("failed to copy logs for pod %s: %s", pod.Name, err)
 			continue
 		}
-		err = r.Datastore.PutLogs(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt.Number), buf.Bytes())
+		content, _ := r.GetLogsRedactor(ctx, run, layer, repo).Redact(buf.Bytes())
+		err = r.Datastore.PutLogs(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt.Number), content)
 		if err != nil {
 			return err
 		}
@@ -194,6 +275,15 @@ func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
 	return nil
 }
 
+func (r *Reconciler) hasFinalLogsPart(run *configv1alpha1.TerraformRun, attempt int) bool {
+	parts, err := r.Datastore.GetLogsParts(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt), 0)
+	if err != nil {
+		log.Errorf("failed to get the logs streamed by the runner of run %s/%s: %s", run.Namespace, run.Name, err)
+		return false
+	}
+	return len(parts) > 0 && parts[len(parts)-1].Final
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Clock = RealClock{}
@@ -9,6 +9,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	"github.com/padok-team/burrito/internal/lock"
 
@@ -120,6 +121,16 @@ func getResultCustomConfig(name types.NamespacedName, r *controller.Reconciler)
 	return result, run, reconcileError, err
 }
 
+func getLinkedRepository(layer *configv1alpha1.TerraformLayer) *configv1alpha1.TerraformRepository {
+	repository := &configv1alpha1.TerraformRepository{}
+	err := k8sClient.Get(context.TODO(), types.NamespacedName{
+		Name:      layer.Spec.Repository.Name,
+		Namespace: layer.Spec.Repository.Namespace,
+	}, repository)
+	Expect(err).NotTo(HaveOccurred())
+	return repository
+}
+
 func updatePodPhase(name types.NamespacedName, phase corev1.PodPhase) error {
 	run := &configv1alpha1.TerraformRun{}
 	err := k8sClient.Get(context.TODO(), name, run)
@@ -187,7 +198,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should set RequeueAfter to 1s", func() {
 				Expect(result.RequeueAfter).To(Equal(time.Duration(1 * time.Second)))
@@ -222,7 +233,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should set RequeueAfter to WaitAction", func() {
 				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
@@ -262,7 +273,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeFalse())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeFalse())
 			})
 			It("should not create any new pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
@@ -301,6 +312,80 @@ var _ = Describe("Run", func() {
 			})
 		})
 	})
+	Describe("Cancel Case", func() {
+		Describe("When a running TerraformRun is cancelled", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "cancel-case-1",
+					Namespace: "default",
+				}
+				_, _, reconcileError, err = getResult(name)
+				Expect(reconcileError).NotTo(HaveOccurred())
+				Expect(err).NotTo(HaveOccurred())
+				run := &configv1alpha1.TerraformRun{}
+				Expect(k8sClient.Get(context.TODO(), name, run)).To(Succeed())
+				Expect(annotations.Add(context.TODO(), k8sClient, run, map[string]string{annotations.CancelRun: "true"})).To(Succeed())
+				result, run, reconcileError, err = getResult(name)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should be in Cancelled state", func() {
+				Expect(run.Status.State).To(Equal("Cancelled"))
+			})
+			It("should keep the lock until the runner pod has stopped", func() {
+				layer := &configv1alpha1.TerraformLayer{}
+				err := k8sClient.Get(context.TODO(), types.NamespacedName{
+					Name:      run.Spec.Layer.Name,
+					Namespace: run.Namespace,
+				}, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
+			})
+			It("should set RequeueAfter to WaitAction", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
+			})
+			It("should have deleted the runner pod", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				var isDeleted = func(p corev1.Pod) bool {
+					return p.DeletionTimestamp != nil
+				}
+				// Pods which are not scheduled on a node are removed right away
+				Expect(pods.Items).To(Or(BeEmpty(), HaveEach(Satisfy(isDeleted))))
+			})
+		})
+		Describe("When the runner pod of a cancelled TerraformRun is gone", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "cancel-case-1",
+					Namespace: "default",
+				}
+				result, run, reconcileError, err = getResult(name)
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should still be in Cancelled state", func() {
+				Expect(run.Status.State).To(Equal("Cancelled"))
+			})
+			It("should have released the lock on the layer", func() {
+				layer := &configv1alpha1.TerraformLayer{}
+				err := k8sClient.Get(context.TODO(), types.NamespacedName{
+					Name:      run.Spec.Layer.Name,
+					Namespace: run.Namespace,
+				}, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeFalse())
+			})
+			It("should not set RequeueAfter", func() {
+				Expect(result.RequeueAfter).To(Equal(time.Duration(0)))
+			})
+		})
+	})
 	Describe("Error Case", func() {
 		Describe("When a TerraformRun is associated to an unknown layer", Ordered, func() {
 			BeforeAll(func() {
@@ -384,7 +469,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should end in FailureGracePeriod state", func() {
 				Expect(run.Status.State).To(Equal("FailureGracePeriod"))
@@ -426,7 +511,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should have created a second pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
@@ -470,7 +555,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeFalse())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeFalse())
 			})
 			It("should not have created any new pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
@@ -514,7 +599,7 @@ var _ = Describe("Run", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should not have created any pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
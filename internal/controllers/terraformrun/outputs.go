@@ -0,0 +1,46 @@
+package terraformrun
+
+import (
+	"context"
+	"strconv"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/utils/outputs"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;create;update
+
+// Publish the outputs captured by the runner of a succeeded apply to the Secret and the ConfigMap
+// of the layer. The apply cannot be undone at this point, errors are reported without failing it.
+func (r *Reconciler) publishOutputs(ctx context.Context, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer) {
+	if run.Spec.Action != string(ApplyAction) || (layer.Spec.Outputs.Secret == nil && layer.Spec.Outputs.ConfigMap == nil) {
+		return
+	}
+	log := log.WithContext(ctx)
+	content, err := r.Datastore.GetUnredactedOutputs(run.Namespace, layer.Name, run.Name, strconv.Itoa(run.Status.Retries))
+	if storageerrors.NotFound(err) {
+		log.Infof("no outputs captured by run %s, nothing to publish", run.Name)
+		return
+	}
+	if err != nil {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Outputs", "Could not get the outputs of the run from the datastore")
+		log.Errorf("could not get outputs of run %s: %s", run.Name, err)
+		return
+	}
+	applied, err := runnerutils.ParseOutputs(content)
+	if err != nil {
+		log.Errorf("could not publish outputs of run %s: %s", run.Name, err)
+		return
+	}
+	err = outputs.Publish(ctx, r.Client, layer, applied)
+	if err != nil {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Outputs", err.Error())
+		log.Errorf("could not publish outputs of run %s: %s", run.Name, err)
+		return
+	}
+	r.Recorder.Event(run, corev1.EventTypeNormal, "Outputs", "Published the outputs of the layer")
+}
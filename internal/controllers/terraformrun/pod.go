@@ -3,7 +3,10 @@ package terraformrun
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/burrito/config"
@@ -13,14 +16,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type Action string
 
 const (
-	PlanAction  Action = "plan"
-	ApplyAction Action = "apply"
+	PlanAction    Action = "plan"
+	ApplyAction   Action = "apply"
+	DestroyAction Action = "destroy"
+	DriftAction   Action = "drift"
+)
+
+const (
+	defaultCancelGracePeriod = 2 * time.Minute
+	cancelLogsUploadDelay    = 30 * time.Second
 )
 
 func getDefaultLabels(run *configv1alpha1.TerraformRun) map[string]string {
@@ -115,6 +126,260 @@ func mountCA(podSpec *corev1.PodSpec, caSecretName, caName string) {
 	})
 }
 
+const policiesMountPath = "/runner/policies"
+const redactionMountPath = "/runner/redaction"
+const pluginCacheMountPath = "/runner/plugin-cache"
+
+// Terraform plugin cache of the runner, in which the providers of the shared cache are linked
+const localPluginCachePath = "/tmp/plugin-cache"
+
+// Default path of the backend configuration values in the runner, see getBackendConfigPath
+const defaultBackendConfigPath = "/runner/backend-config"
+
+// Default path of the variable values in the runner, see getVariablesPath
+const defaultVariablesPath = "/runner/variables"
+
+// Variables taken from ConfigMaps and Secrets are projected as files in the runner pod so that
+// their values never appear in the pod spec. They are mounted at the path the runner is told to read them from.
+func mountVariables(podSpec *corev1.PodSpec, variables configv1alpha1.Variables, path string) {
+	mountValues(podSpec, "burrito-variables", path, variables.Vars)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name:  "BURRITO_RUNNER_VARIABLESPATH",
+		Value: path,
+	})
+}
+
+// Mount the backend configuration values at the path the runner is told to read them from
+func mountBackendConfig(podSpec *corev1.PodSpec, backendConfig []configv1alpha1.Variable, path string) {
+	mountValues(podSpec, "burrito-backend-config", path, backendConfig)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name:  "BURRITO_RUNNER_BACKENDCONFIGPATH",
+		Value: path,
+	})
+}
+
+// Project the values taken from ConfigMaps and Secrets in a volume, in a file named after each value
+func mountValues(podSpec *corev1.PodSpec, volumeName string, mountPath string, values []configv1alpha1.Variable) {
+	sources := []corev1.VolumeProjection{}
+	for _, v := range values {
+		if v.ValueFrom == nil {
+			continue
+		}
+		switch {
+		case v.ValueFrom.SecretKeyRef != nil:
+			sources = append(sources, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: v.ValueFrom.SecretKeyRef.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: v.ValueFrom.SecretKeyRef.Key, Path: v.Name}},
+					Optional:             v.ValueFrom.SecretKeyRef.Optional,
+				},
+			})
+		case v.ValueFrom.ConfigMapKeyRef != nil:
+			sources = append(sources, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: v.ValueFrom.ConfigMapKeyRef.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: v.ValueFrom.ConfigMapKeyRef.Key, Path: v.Name}},
+					Optional:             v.ValueFrom.ConfigMapKeyRef.Optional,
+				},
+			})
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: sources,
+			},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		MountPath: mountPath,
+		Name:      volumeName,
+		ReadOnly:  true,
+	})
+}
+
+// Variables taken from the outputs of other layers are read from the Secret or ConfigMap the
+// outputs are published to. Outputs which are not published are left unresolved: the runner
+// fails to read them, unless they are optional.
+func (r *Reconciler) resolveLayerOutputs(namespace string, variables configv1alpha1.Variables) configv1alpha1.Variables {
+	vars := []configv1alpha1.Variable{}
+	for _, v := range variables.Vars {
+		if v.ValueFrom == nil || v.ValueFrom.LayerOutputRef == nil {
+			vars = append(vars, v)
+			continue
+		}
+		ref := v.ValueFrom.LayerOutputRef
+		layer := &configv1alpha1.TerraformLayer{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.Layer}, layer)
+		if err != nil {
+			log.Warnf("could not get layer %s/%s for the value of variable %s: %s", namespace, ref.Layer, v.Name, err)
+		} else if source := getLayerOutputSource(layer.Spec.Outputs, ref); source != nil {
+			v.ValueFrom = source
+		} else {
+			log.Warnf("output %s of layer %s/%s used by variable %s is not published", ref.Output, namespace, ref.Layer, v.Name)
+		}
+		vars = append(vars, v)
+	}
+	variables.Vars = vars
+	return variables
+}
+
+func getLayerOutputSource(publication configv1alpha1.OutputsPublication, ref *configv1alpha1.LayerOutputSelector) *configv1alpha1.VariableSource {
+	if target := publication.Secret; target != nil && slices.Contains(target.Outputs, ref.Output) {
+		return &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: target.Name},
+			Key:                  ref.Output,
+			Optional:             ref.Optional,
+		}}
+	}
+	if target := publication.ConfigMap; target != nil && slices.Contains(target.Outputs, ref.Output) {
+		return &configv1alpha1.VariableSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: target.Name},
+			Key:                  ref.Output,
+			Optional:             ref.Optional,
+		}}
+	}
+	return nil
+}
+
+// Mount each ConfigMap of policies in its own directory, so that policies are named after their ConfigMap
+func mountPolicies(podSpec *corev1.PodSpec, policies configv1alpha1.Policies) {
+	for i, cm := range policies.ConfigMaps {
+		name := fmt.Sprintf("burrito-policies-%d", i)
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: cm,
+				},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			MountPath: filepath.Join(policiesMountPath, cm.Name),
+			Name:      name,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// Mount the ConfigMap of redaction patterns, if it exists in the namespace of the layer
+func mountRedactionPatterns(podSpec *corev1.PodSpec, configMapName string) {
+	if configMapName == "" {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "burrito-redaction-patterns",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+				Optional: &[]bool{true}[0],
+			},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		MountPath: redactionMountPath,
+		Name:      "burrito-redaction-patterns",
+		ReadOnly:  true,
+	})
+}
+
+// Mount the volume of the provider plugin cache shared between the runners of the namespace
+func mountPluginCache(podSpec *corev1.PodSpec, cache config.PluginCacheConfig) {
+	if !cache.Enabled || cache.ClaimName == "" {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "burrito-plugin-cache",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: cache.ClaimName,
+			},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		MountPath: pluginCacheMountPath,
+		Name:      "burrito-plugin-cache",
+	})
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env,
+		corev1.EnvVar{
+			Name:  "TF_PLUGIN_CACHE_DIR",
+			Value: localPluginCachePath,
+		},
+		corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_PLUGINCACHE_ENABLED",
+			Value: "true",
+		},
+		corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_PLUGINCACHE_MAXAGE",
+			Value: cache.MaxAge.String(),
+		},
+	)
+}
+
+// The runner interrupts terraform when its pod is deleted and waits for the grace period before
+// exiting. The pod is given some more time to upload its logs before being killed.
+func setCancelGracePeriod(podSpec *corev1.PodSpec, gracePeriod time.Duration) {
+	podSpec.TerminationGracePeriodSeconds = &[]int64{int64((gracePeriod + cancelLogsUploadDelay).Seconds())}[0]
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name:  "BURRITO_RUNNER_CANCELGRACEPERIOD",
+		Value: gracePeriod.String(),
+	})
+}
+
+// The runner interrupts terraform itself when the timeout is exceeded. The deadline of the pod
+// only stops runners which could not, once the grace period has passed as well.
+func setRunTimeout(podSpec *corev1.PodSpec, timeout time.Duration, gracePeriod time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	podSpec.ActiveDeadlineSeconds = &[]int64{int64((timeout + gracePeriod + cancelLogsUploadDelay).Seconds())}[0]
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name:  "BURRITO_RUNNER_TIMEOUT",
+		Value: timeout.String(),
+	})
+}
+
+func getCancelGracePeriod(c *config.Config) time.Duration {
+	if c.Runner.CancelGracePeriod <= 0 {
+		return defaultCancelGracePeriod
+	}
+	return c.Runner.CancelGracePeriod
+}
+
+func getVariablesPath(c *config.Config) string {
+	if c.Runner.VariablesPath == "" {
+		return defaultVariablesPath
+	}
+	return c.Runner.VariablesPath
+}
+
+func getBackendConfigPath(c *config.Config) string {
+	if c.Runner.BackendConfigPath == "" {
+		return defaultBackendConfigPath
+	}
+	return c.Runner.BackendConfigPath
+}
+
+func getDefaultRunTimeout(c *config.Config, action Action) time.Duration {
+	switch action {
+	case PlanAction:
+		return c.Controller.Timers.RunTimeouts.Plan
+	case ApplyAction:
+		return c.Controller.Timers.RunTimeouts.Apply
+	case DestroyAction:
+		return c.Controller.Timers.RunTimeouts.Destroy
+	case DriftAction:
+		return c.Controller.Timers.RunTimeouts.Drift
+	}
+	return 0
+}
+
 func (r *Reconciler) getPod(run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) corev1.Pod {
 	defaultSpec := defaultPodSpec(r.Config, layer, run)
 
@@ -159,6 +424,50 @@ func (r *Reconciler) getPod(run *configv1alpha1.TerraformRun, layer *configv1alp
 			Name:  "BURRITO_RUNNER_ACTION",
 			Value: "apply",
 		})
+		if r.Config.Runner.PlanMaxAge > 0 {
+			defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+				Name:  "BURRITO_RUNNER_PLANMAXAGE",
+				Value: r.Config.Runner.PlanMaxAge.String(),
+			})
+		}
+	case DestroyAction:
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_ACTION",
+			Value: "destroy",
+		})
+	case DriftAction:
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_ACTION",
+			Value: "drift",
+		})
+	}
+
+	mountVariables(&defaultSpec, r.resolveLayerOutputs(layer.Namespace, configv1alpha1.GetVariables(repository, layer)), getVariablesPath(r.Config))
+	mountBackendConfig(&defaultSpec, configv1alpha1.GetBackendConfig(repository, layer), getBackendConfigPath(r.Config))
+	mountPolicies(&defaultSpec, configv1alpha1.GetPolicies(repository, layer))
+	mountRedactionPatterns(&defaultSpec, r.Config.Runner.RedactionConfigMapName)
+	mountPluginCache(&defaultSpec, r.Config.Runner.PluginCache)
+	gracePeriod := getCancelGracePeriod(r.Config)
+	setCancelGracePeriod(&defaultSpec, gracePeriod)
+	timeout := configv1alpha1.GetRunTimeout(repository, layer, string(run.Spec.Action), getDefaultRunTimeout(r.Config, Action(run.Spec.Action)))
+	setRunTimeout(&defaultSpec, timeout, gracePeriod)
+	if r.Config.Runner.StoreUnredacted {
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_STOREUNREDACTED",
+			Value: "true",
+		})
+	}
+	if r.Config.Runner.JSONOutput {
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_JSONOUTPUT",
+			Value: "true",
+		})
+	}
+	if r.Config.Runner.ToolsMirror != "" {
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_TOOLSMIRROR",
+			Value: r.Config.Runner.ToolsMirror,
+		})
 	}
 
 	overrideSpec := configv1alpha1.GetOverrideRunnerSpec(repository, layer)
@@ -45,13 +45,31 @@ var _ = Describe("Pod", func() {
 					Namespace: run.Namespace,
 				}, layer)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer)).To(BeTrue())
+				Expect(lock.IsLayerLocked(context.TODO(), k8sClient, layer, getLinkedRepository(layer))).To(BeTrue())
 			})
 			It("should have created a pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(pods.Items)).To(Equal(1))
 			})
+			It("should give the runner the time to interrupt terraform when the run is cancelled", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items[0].Spec.TerminationGracePeriodSeconds).To(Equal(&[]int64{150}[0]))
+				Expect(pods.Items[0].Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+					Name:  "BURRITO_RUNNER_CANCELGRACEPERIOD",
+					Value: "2m0s",
+				}))
+			})
+			It("should stop the pod if the runner has not stopped after the plan timeout", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items[0].Spec.ActiveDeadlineSeconds).To(Equal(&[]int64{3750}[0]))
+				Expect(pods.Items[0].Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+					Name:  "BURRITO_RUNNER_TIMEOUT",
+					Value: "1h0m0s",
+				}))
+			})
 			It("should have passed the extra args env variables to the pod", func() {
 				pods, err := reconciler.GetLinkedPods(run)
 				Expect(err).NotTo(HaveOccurred())
@@ -78,5 +96,121 @@ var _ = Describe("Pod", func() {
 				}))
 			})
 		})
+		Describe("When a TerraformRun is created for a layer consuming the outputs of another layer", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "nominal-case-layer-outputs-plan",
+					Namespace: "default",
+				}
+				_, run, reconcileError, err = getResult(name)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should project the outputs from the Secret and the ConfigMap they are published to", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				var sources []corev1.VolumeProjection
+				for _, volume := range pods.Items[0].Spec.Volumes {
+					if volume.Name == "burrito-variables" {
+						sources = volume.Projected.Sources
+					}
+				}
+				Expect(sources).To(ConsistOf(
+					corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "network-outputs"},
+						Items:                []corev1.KeyToPath{{Key: "vpc_id", Path: "vpc_id"}},
+					}},
+					corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "network-public-outputs"},
+						Items:                []corev1.KeyToPath{{Key: "region", Path: "region"}},
+					}},
+				))
+			})
+		})
+		Describe("When a TerraformRun is created for a layer with a backend configuration", Ordered, func() {
+			BeforeAll(func() {
+				reconciler.Config.Runner.BackendConfigPath = "/custom/backend-config"
+				name = types.NamespacedName{
+					Name:      "nominal-case-backend-config-plan",
+					Namespace: "default",
+				}
+				_, run, reconcileError, err = getResult(name)
+			})
+			AfterAll(func() {
+				reconciler.Config.Runner.BackendConfigPath = ""
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should mount the backend configuration at the configured path and pass it to the runner", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				container := pods.Items[0].Spec.Containers[0]
+				Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "burrito-backend-config",
+					MountPath: "/custom/backend-config",
+					ReadOnly:  true,
+				}))
+				Expect(container.Env).To(ContainElement(corev1.EnvVar{
+					Name:  "BURRITO_RUNNER_BACKENDCONFIGPATH",
+					Value: "/custom/backend-config",
+				}))
+			})
+		})
+		Describe("When a TerraformRun is created for a layer with variables", Ordered, func() {
+			BeforeAll(func() {
+				reconciler.Config.Runner.VariablesPath = "/custom/variables"
+				name = types.NamespacedName{
+					Name:      "nominal-case-variables-plan",
+					Namespace: "default",
+				}
+				_, run, reconcileError, err = getResult(name)
+			})
+			AfterAll(func() {
+				reconciler.Config.Runner.VariablesPath = ""
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should project the values taken from Secrets and ConfigMaps at the configured path and pass it to the runner", func() {
+				pods, err := reconciler.GetLinkedPods(run)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				var sources []corev1.VolumeProjection
+				for _, volume := range pods.Items[0].Spec.Volumes {
+					if volume.Name == "burrito-variables" {
+						sources = volume.Projected.Sources
+					}
+				}
+				optional := true
+				Expect(sources).To(ConsistOf(
+					corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "api-credentials"},
+						Items:                []corev1.KeyToPath{{Key: "api_key", Path: "api_key"}},
+					}},
+					corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+						Items:                []corev1.KeyToPath{{Key: "region", Path: "region"}},
+						Optional:             &optional,
+					}},
+				))
+				container := pods.Items[0].Spec.Containers[0]
+				Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "burrito-variables",
+					MountPath: "/custom/variables",
+					ReadOnly:  true,
+				}))
+				Expect(container.Env).To(ContainElement(corev1.EnvVar{
+					Name:  "BURRITO_RUNNER_VARIABLESPATH",
+					Value: "/custom/variables",
+				}))
+			})
+		})
 	})
 })
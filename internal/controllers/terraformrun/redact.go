@@ -0,0 +1,63 @@
+package terraformrun
+
+import (
+	"context"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/utils/redact"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GetLogsRedactor returns a redactor masking what the runner of an attempt masks in its logs:
+// the configured patterns, the ones of the redaction ConfigMap of the namespace, and the values of
+// the variables and of the backend configuration taken from Secrets. The sensitive values of the
+// plan are only known by the runner, they are never stored in clear text.
+func (r *Reconciler) GetLogsRedactor(ctx context.Context, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) *redact.Redactor {
+	patterns := append([]string{}, r.Config.Runner.RedactionPatterns...)
+	if name := r.Config.Runner.RedactionConfigMapName; name != "" {
+		cm := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: run.Namespace, Name: name}, cm)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Errorf("could not get redaction patterns of namespace %s: %s", run.Namespace, err)
+		}
+		for _, content := range cm.Data {
+			patterns = append(patterns, redact.ParsePatterns([]byte(content))...)
+		}
+	}
+	redactor, err := redact.New(patterns)
+	if err != nil {
+		log.Errorf("invalid redaction patterns, using the default ones: %s", err)
+		redactor, _ = redact.New(nil)
+	}
+
+	// Outputs of other layers published to Secrets are sensitive as well
+	variables := r.resolveLayerOutputs(run.Namespace, configv1alpha1.GetVariables(repo, layer))
+	r.addSecretValues(ctx, redactor, run.Namespace, variables.Vars)
+	r.addSecretValues(ctx, redactor, run.Namespace, configv1alpha1.GetBackendConfig(repo, layer))
+
+	return redactor
+}
+
+// Register the values taken from Secrets, they are read by the runner from files mounted in its pod
+func (r *Reconciler) addSecretValues(ctx context.Context, redactor *redact.Redactor, namespace string, values []configv1alpha1.Variable) {
+	for _, v := range values {
+		if v.ValueFrom == nil || v.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: v.ValueFrom.SecretKeyRef.Name}, secret)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Errorf("could not get secret %s/%s of variable %s: %s", namespace, v.ValueFrom.SecretKeyRef.Name, v.Name, err)
+			}
+			continue
+		}
+		if value, ok := secret.Data[v.ValueFrom.SecretKeyRef.Key]; ok {
+			redactor.AddValues(strings.TrimSuffix(string(value), "\n"))
+		}
+	}
+}
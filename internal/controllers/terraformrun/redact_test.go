@@ -0,0 +1,78 @@
+package terraformrun_test
+
+import (
+	"context"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	controller "github.com/padok-team/burrito/internal/controllers/terraformrun"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetLogsRedactor(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = configv1alpha1.AddToScheme(scheme)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "burrito-redaction-patterns", Namespace: "default"},
+			Data:       map[string]string{"patterns": "# internal tokens\ninternal-token-[0-9]+\n"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "default"},
+			Data:       map[string][]byte{"api-key": []byte("my-secret-api-key\n"), "bucket": []byte("state-bucket")},
+		},
+		&configv1alpha1.TerraformLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "default"},
+			Spec: configv1alpha1.TerraformLayerSpec{Outputs: configv1alpha1.OutputsPublication{
+				Secret: &configv1alpha1.OutputsTarget{Name: "database-outputs", Outputs: []string{"password"}},
+			}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "database-outputs", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("generated-password")},
+		},
+	).Build()
+	r := &controller.Reconciler{
+		Client:    client,
+		Config:    &config.Config{Runner: config.RunnerConfig{RedactionConfigMapName: "burrito-redaction-patterns", RedactionPatterns: []string{`company-[a-z]+`}}},
+		Datastore: datastore.NewMockClient(),
+	}
+	fromSecret := func(key string) *configv1alpha1.VariableSource {
+		return &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"},
+			Key:                  key,
+		}}
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Variables: configv1alpha1.Variables{Vars: []configv1alpha1.Variable{
+				{Name: "api_key", ValueFrom: fromSecret("api-key")},
+				{Name: "environment", Value: "production"},
+				{Name: "db_password", ValueFrom: &configv1alpha1.VariableSource{LayerOutputRef: &configv1alpha1.LayerOutputSelector{Layer: "database", Output: "password"}}},
+			}},
+			BackendConfig: []configv1alpha1.Variable{{Name: "bucket", ValueFrom: fromSecret("bucket")}},
+		},
+	}
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-layer-plan-abcde", Namespace: "default"},
+		Spec:       configv1alpha1.TerraformRunSpec{Layer: configv1alpha1.TerraformRunLayer{Name: "my-layer"}},
+	}
+
+	redactor := r.GetLogsRedactor(context.Background(), run, layer, &configv1alpha1.TerraformRepository{})
+	logs := "token=internal-token-42 org=company-abc key=my-secret-api-key bucket=state-bucket password=generated-password env=production"
+	content, count := redactor.Redact([]byte(logs))
+	expected := "token=[REDACTED] org=[REDACTED] key=[REDACTED] bucket=[REDACTED] password=[REDACTED] env=production"
+	if string(content) != expected {
+		t.Errorf("expected the logs to be redacted like the runner does, got %q", content)
+	}
+	if count != 5 {
+		t.Errorf("expected 5 redactions, got %d", count)
+	}
+}
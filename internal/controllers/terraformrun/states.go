@@ -10,9 +10,11 @@ import (
 	"github.com/padok-team/burrito/internal/lock"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -45,7 +47,10 @@ func (r *Reconciler) GetState(ctx context.Context, run *configv1alpha1.Terraform
 	c3, hasSucceeded := r.HasSucceeded(run)
 	c4, isRunning := r.IsRunning(run)
 	c5, isInFailureGracePeriod := r.IsInFailureGracePeriod(run)
-	conditions := []metav1.Condition{c1, c2, c3, c4, c5}
+	c6, _ := r.HasHookFailed(run)
+	c7, isCancelled := r.IsCancelled(run)
+	c8, _ := r.HasTimedOut(run)
+	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7, c8}
 	switch {
 	case !hasStatus:
 		log.Infof("run %s is in initial state", run.Name)
@@ -53,6 +58,9 @@ func (r *Reconciler) GetState(ctx context.Context, run *configv1alpha1.Terraform
 	case hasSucceeded:
 		log.Infof("run %s has succeeded", run.Name)
 		return &Succeeded{}, conditions
+	case isCancelled:
+		log.Infof("run %s has been cancelled", run.Name)
+		return &Cancelled{}, conditions
 	case isInFailureGracePeriod && !hasReachedRetryLimit && !isRunning:
 		log.Infof("run %s is in failure grace period", run.Name)
 		return &FailureGracePeriod{}, conditions
@@ -76,7 +84,7 @@ type Initial struct{}
 func (s *Initial) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
 		log := log.WithContext(ctx)
-		err := lock.CreateLock(ctx, r.Client, layer, run)
+		err := lock.CreateLock(ctx, r.Client, layer, repo, run)
 		if err != nil {
 			r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could set lock on run")
 			log.Errorf("could not set lock on run %s for layer %s, requeuing resource: %s", run.Name, layer.Name, err)
@@ -178,12 +186,8 @@ type Succeeded struct{}
 
 func (s *Succeeded) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
-		// Try to delete lock if it still exists
-		log := log.WithContext(ctx)
-		err := lock.DeleteLock(ctx, r.Client, layer, run)
-		if err != nil {
-			r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could not delete lock for run")
-			log.Errorf("could not delete lock for run %s: %s", run.Name, err)
+		r.publishOutputs(ctx, run, layer)
+		if err := r.releaseLock(ctx, run, layer, repo); err != nil {
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
 		}
 		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
@@ -194,16 +198,72 @@ type Failed struct{}
 
 func (s *Failed) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
-		// Try to delete lock if it still exists
+		if err := r.releaseLock(ctx, run, layer, repo); err != nil {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+		}
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
+	}
+}
+
+type Cancelled struct{}
+
+func (s *Cancelled) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
 		log := log.WithContext(ctx)
-		err := lock.DeleteLock(ctx, r.Client, layer, run)
+		stopped, err := r.stopRunnerPod(ctx, run)
 		if err != nil {
-			r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could not delete lock for run")
-			log.Errorf("could not delete lock for run %s: %s", run.Name, err)
+			r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Could not stop runner pod of cancelled run")
+			log.Errorf("could not stop runner pod %s of cancelled run %s: %s", run.Status.RunnerPod, run.Name, err)
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
 		}
-		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
+		if !stopped {
+			// The lock is kept until terraform has been interrupted and the pod is gone
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
+		}
+		if err := r.releaseLock(ctx, run, layer, repo); err != nil {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+		}
+		// Nothing left to do, the run is in its terminal state
+		return ctrl.Result{}, getRunInfo(run)
+	}
+}
+
+// Try to delete the lock of the layer if it still exists
+func (r *Reconciler) releaseLock(ctx context.Context, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) error {
+	err := lock.DeleteLock(ctx, r.Client, layer, repo, run)
+	if err != nil && !errors.IsNotFound(err) {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could not delete lock for run")
+		log.WithContext(ctx).Errorf("could not delete lock for run %s: %s", run.Name, err)
+		return err
+	}
+	return nil
+}
+
+// Delete the runner pod of a cancelled run, the runner interrupts terraform when it receives the
+// termination signal. Returns true once the pod has exited.
+func (r *Reconciler) stopRunnerPod(ctx context.Context, run *configv1alpha1.TerraformRun) (bool, error) {
+	if run.Status.RunnerPod == "" {
+		return true, nil
 	}
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: run.Status.RunnerPod, Namespace: run.Namespace}, pod)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true, nil
+	}
+	if pod.DeletionTimestamp == nil {
+		err = r.Client.Delete(ctx, pod)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		r.Recorder.Event(run, corev1.EventTypeNormal, "Run", fmt.Sprintf("Deleted pod %s to cancel the run", pod.Name))
+	}
+	return false, nil
 }
 
 func getStateString(state State) string {
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -22,6 +23,11 @@ import (
 var API *api.API
 var e *echo.Echo
 
+var outputsBody = []byte(`{
+	"vpc_id": {"sensitive": false, "type": "string", "value": "vpc-0123"},
+	"db_password": {"sensitive": true, "type": "string", "value": "hunter2"}
+}`)
+
 func TestDatastoreAPI(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -53,6 +59,7 @@ var _ = BeforeSuite(func() {
 	API.Storage.PutPlan("default", "test1", "test1", "0", "bin", []byte("test1"))
 	API.Storage.PutPlan("default", "test1", "test1", "0", "short", []byte("test1"))
 	API.Storage.PutPlan("default", "test1", "test1", "0", "pretty", []byte("test1"))
+	API.Storage.PutPlan("default", "test1", "test1", "0", storage.UnitPlanFormat("network/vpc", "json"), []byte("test1"))
 	API.Storage.PutGitBundle("default", "test1", "main", "abc123", []byte("test-bundle"))
 
 	e = echo.New()
@@ -174,6 +181,30 @@ var _ = Describe("Datastore API", func() {
 						Expect(err).NotTo(HaveOccurred())
 						Expect(context.Response().Status).To(Equal(http.StatusOK))
 					})
+					It("should return 403 Forbidden if the format is unredacted", func() {
+						context := getContext(http.MethodGet, "/plans", map[string]string{
+							"namespace": "default",
+							"layer":     "test1",
+							"run":       "test1",
+							"attempt":   "0",
+							"format":    "pretty-unredacted",
+						}, nil)
+						err := API.GetPlanHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusForbidden))
+					})
+					It("should return 403 Forbidden if the format is the unredacted plan of a unit", func() {
+						context := getContext(http.MethodGet, "/plans", map[string]string{
+							"namespace": "default",
+							"layer":     "test1",
+							"run":       "test1",
+							"attempt":   "0",
+							"format":    "units/network/json-unredacted",
+						}, nil)
+						err := API.GetPlanHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusForbidden))
+					})
 				})
 			})
 			Describe("Plan does not exist", func() {
@@ -243,6 +274,51 @@ var _ = Describe("Datastore API", func() {
 					Expect(context.Response().Status).To(Equal(http.StatusOK))
 				})
 			})
+			Describe("Logs parts", func() {
+				It("should store the parts in order and serve them from a part number", func() {
+					for part, content := range []string{"first line\n", "second line\n", "last line"} {
+						context := getContext(http.MethodPut, "/logs", map[string]string{
+							"namespace": "default",
+							"layer":     "streamed",
+							"run":       "streamed",
+							"attempt":   "0",
+							"part":      strconv.Itoa(part),
+							"final":     strconv.FormatBool(part == 2),
+						}, []byte(content))
+						err := API.PutLogsHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusOK))
+					}
+					parts, err := API.Storage.GetLogsParts("default", "streamed", "streamed", "0", 1)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(parts).To(Equal([]storage.LogsPart{
+						{Number: 1, Content: []byte("second line\n")},
+						{Number: 2, Final: true, Content: []byte("last line")},
+					}))
+					logs, err := API.Storage.GetLogs("default", "streamed", "streamed", "0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(logs)).To(Equal("first line\nsecond line\nlast line"))
+				})
+				It("should prefer the logs uploaded whole by the controller", func() {
+					Expect(API.Storage.PutLogsPart("default", "reconciled", "reconciled", "0", 0, false, []byte("first line\n"))).To(Succeed())
+					Expect(API.Storage.PutLogs("default", "reconciled", "reconciled", "0", []byte("first line\nlast line\n"))).To(Succeed())
+					logs, err := API.Storage.GetLogs("default", "reconciled", "reconciled", "0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(logs)).To(Equal("first line\nlast line\n"))
+				})
+				It("should return 400 Bad Request for an invalid part number", func() {
+					context := getContext(http.MethodPut, "/logs", map[string]string{
+						"namespace": "default",
+						"layer":     "streamed",
+						"run":       "streamed",
+						"attempt":   "0",
+						"part":      "-1",
+					}, []byte("logs"))
+					err := API.PutLogsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusBadRequest))
+				})
+			})
 			Describe("Plans", func() {
 				It("should return 200 OK", func() {
 					body := []byte(`test1`)
@@ -258,6 +334,43 @@ var _ = Describe("Datastore API", func() {
 					Expect(context.Response().Status).To(Equal(http.StatusOK))
 				})
 			})
+			Describe("Outputs", Ordered, func() {
+				params := map[string]string{
+					"namespace": "default",
+					"layer":     "outputs",
+					"run":       "outputs",
+					"attempt":   "0",
+				}
+				It("should drop the values of sensitive outputs without encryption", func() {
+					context := getContext(http.MethodPut, "/outputs", params, outputsBody)
+					err := API.PutOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					stored, err := API.Storage.GetOutputs("default", "outputs", "outputs", "0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(stored)).To(ContainSubstring("vpc-0123"))
+					Expect(string(stored)).NotTo(ContainSubstring("hunter2"))
+				})
+				It("should return the outputs with a 200 OK", func() {
+					context := getContext(http.MethodGet, "/outputs", map[string]string{
+						"namespace": "default",
+						"layer":     "outputs",
+						"run":       "outputs",
+					}, nil)
+					err := API.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					body := context.Response().Writer.(*httptest.ResponseRecorder).Body.String()
+					Expect(body).To(ContainSubstring("vpc-0123"))
+					Expect(body).To(ContainSubstring("db_password"))
+				})
+				It("should return 400 Bad Request when the outputs are not valid", func() {
+					context := getContext(http.MethodPut, "/outputs", params, []byte("not json"))
+					err := API.PutOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusBadRequest))
+				})
+			})
 		})
 		Describe("Write with Encryption", func() {
 			Describe("Plans", func() {
@@ -311,6 +424,70 @@ var _ = Describe("Datastore API", func() {
 					Expect(retrievedData).To(Equal(body), "decrypted data should match original")
 				})
 			})
+			Describe("Outputs", func() {
+				It("should store sensitive values encrypted and never return them", func() {
+					err := os.Setenv("BURRITO_DATASTORE_STORAGE_ENCRYPTION_KEY", "test-encryption-key-for-api-testing-123")
+					Expect(err).NotTo(HaveOccurred())
+					defer os.Unsetenv("BURRITO_DATASTORE_STORAGE_ENCRYPTION_KEY")
+					encryptedStorage := storage.New(config.Config{
+						Datastore: config.DatastoreConfig{
+							Storage: config.StorageConfig{
+								Mock:       true,
+								Encryption: config.EncryptionConfig{Enabled: true},
+							},
+						},
+					})
+					encryptedAPI := &api.API{Storage: encryptedStorage}
+					params := map[string]string{
+						"namespace": "encrypted-test",
+						"layer":     "test-layer",
+						"run":       "test-run",
+						"attempt":   "0",
+					}
+
+					context := getContext(http.MethodPut, "/outputs", params, outputsBody)
+					err = encryptedAPI.PutOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+
+					storedData, err := encryptedStorage.Backend.Get("layers/encrypted-test/test-layer/test-run/0/outputs.json")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(storedData)).NotTo(ContainSubstring("hunter2"))
+					retrievedData, err := encryptedStorage.GetOutputs("encrypted-test", "test-layer", "test-run", "0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(retrievedData)).To(ContainSubstring("hunter2"))
+
+					context = getContext(http.MethodGet, "/outputs", params, nil)
+					err = encryptedAPI.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					body := context.Response().Writer.(*httptest.ResponseRecorder).Body.String()
+					Expect(body).To(ContainSubstring("vpc-0123"))
+					Expect(body).NotTo(ContainSubstring("hunter2"))
+
+					unredacted := map[string]string{"unredacted": "true"}
+					for k, v := range params {
+						unredacted[k] = v
+					}
+					context = getContext(http.MethodGet, "/outputs", unredacted, nil)
+					context.Set("serviceAccount", "system:serviceaccount:tenant:burrito-runner")
+					err = encryptedAPI.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusForbidden))
+
+					controllersAPI := api.New(&config.Config{Datastore: config.DatastoreConfig{
+						OutputsServiceAccounts: []string{"burrito-system/burrito-controllers"},
+					}})
+					controllersAPI.Storage = encryptedStorage
+					context = getContext(http.MethodGet, "/outputs", unredacted, nil)
+					context.Set("serviceAccount", "system:serviceaccount:burrito-system:burrito-controllers")
+					err = controllersAPI.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					body = context.Response().Writer.(*httptest.ResponseRecorder).Body.String()
+					Expect(body).To(ContainSubstring("hunter2"))
+				})
+			})
 		})
 	})
 })
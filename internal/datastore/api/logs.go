@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/padok-team/burrito/internal/datastore/storage"
 	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
 )
 
@@ -14,6 +16,10 @@ type GetLogsResponse struct {
 	Results []string `json:"results"`
 }
 
+type GetLogsPartsResponse struct {
+	Parts []storage.LogsPart `json:"parts"`
+}
+
 func getLogsArgs(c echo.Context) (string, string, string, string, error) {
 	namespace := c.QueryParam("namespace")
 	layer := c.QueryParam("layer")
@@ -62,10 +68,40 @@ func (a *API) PutLogsHandler(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusBadRequest, "could not read request body: "+err.Error())
 	}
-	err = a.Storage.PutLogs(namespace, layer, run, attempt, content)
+	if part := c.QueryParam("part"); part != "" {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return c.String(http.StatusBadRequest, "invalid part number")
+		}
+		err = a.Storage.PutLogsPart(namespace, layer, run, attempt, number, c.QueryParam("final") == "true", content)
+	} else {
+		err = a.Storage.PutLogs(namespace, layer, run, attempt, content)
+	}
 	if err != nil {
 		c.Logger().Errorf("Could not put logs, there's an issue with the storage backend : %s", err)
 		return c.String(http.StatusInternalServerError, "could not put logs, there's an issue with the storage backend")
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+// GetLogsPartsHandler returns the parts of the logs streamed by the runner of an attempt, starting
+// from the part number given in the from query parameter
+func (a *API) GetLogsPartsHandler(c echo.Context) error {
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil || attempt == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	from := 0
+	if param := c.QueryParam("from"); param != "" {
+		from, err = strconv.Atoi(param)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid part number")
+		}
+	}
+	parts, err := a.Storage.GetLogsParts(namespace, layer, run, attempt, from)
+	if err != nil {
+		c.Logger().Errorf("Could not get logs, there's an issue with the storage backend : %s", err)
+		return c.String(http.StatusInternalServerError, "could not get logs, there's an issue with the storage backend")
+	}
+	return c.JSON(http.StatusOK, &GetLogsPartsResponse{Parts: parts})
+}
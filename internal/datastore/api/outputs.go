@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+// Outputs share the query parameters of logs
+func (a *API) GetOutputsHandler(c echo.Context) error {
+	var err error
+	var content []byte
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if attempt == "" {
+		content, err = a.Storage.GetLatestOutputs(namespace, layer, run)
+	} else {
+		content, err = a.Storage.GetOutputs(namespace, layer, run, attempt)
+	}
+	if storageerrors.NotFound(err) {
+		return c.String(http.StatusNotFound, "No outputs for this attempt")
+	}
+	if err != nil {
+		c.Logger().Errorf("Could not get outputs, there's an issue with the storage backend : %s", err)
+		return c.String(http.StatusInternalServerError, "could not get outputs, there's an issue with the storage backend")
+	}
+	outputs, err := runnerutils.ParseOutputs(content)
+	if err != nil {
+		c.Logger().Errorf("Could not parse stored outputs: %s", err)
+		return c.String(http.StatusInternalServerError, "could not parse outputs")
+	}
+	if c.QueryParam("unredacted") == "true" {
+		// Only the controllers publish sensitive outputs, to the Secret of the layer
+		if !a.canReadSensitiveOutputs(c) {
+			return c.String(http.StatusForbidden, "sensitive outputs cannot be read by this service account")
+		}
+		return c.JSON(http.StatusOK, outputs)
+	}
+	// Like unredacted plans, the values of sensitive outputs are write-only for other clients
+	return c.JSON(http.StatusOK, outputs.Redact())
+}
+
+func (a *API) canReadSensitiveOutputs(c echo.Context) bool {
+	if a.config == nil {
+		return false
+	}
+	user, _ := c.Get("serviceAccount").(string)
+	for _, sa := range a.config.Datastore.OutputsServiceAccounts {
+		namespace, name, ok := strings.Cut(sa, "/")
+		if ok && user == fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) PutOutputsHandler(c echo.Context) error {
+	var err error
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if attempt == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	content, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "could not read request body: "+err.Error())
+	}
+	if _, err := runnerutils.ParseOutputs(content); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	err = a.Storage.PutOutputs(namespace, layer, run, attempt, content)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not put outputs, there's an issue with the storage backend: "+err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/padok-team/burrito/internal/datastore/storage"
 	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
 )
 
@@ -21,6 +22,9 @@ func getPlanArgs(c echo.Context) (string, string, string, string, string, error)
 	if format == "" {
 		format = "json"
 	}
+	if !storage.IsValidPlanFormat(format) {
+		return "", "", "", "", "", fmt.Errorf("invalid format %s", format)
+	}
 	return namespace, layer, run, attempt, format, nil
 }
 
@@ -31,6 +35,10 @@ func (a *API) GetPlanHandler(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
+	// Unredacted plans are write-only, they can only be read directly from the storage backend
+	if storage.IsUnredactedPlanFormat(format) {
+		return c.String(http.StatusForbidden, "unredacted plans cannot be read from the datastore API")
+	}
 	if attempt == "" {
 		content, err = a.Storage.GetLatestPlan(namespace, layer, run, format)
 	} else {
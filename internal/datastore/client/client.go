@@ -8,9 +8,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 
 	"github.com/padok-team/burrito/internal/burrito/config"
 	"github.com/padok-team/burrito/internal/datastore/api"
+	"github.com/padok-team/burrito/internal/datastore/storage"
 	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
 	log "github.com/sirupsen/logrus"
 )
@@ -24,6 +26,11 @@ type Client interface {
 	PutPlan(namespace string, layer string, run string, attempt string, format string, content []byte) error
 	GetLogs(namespace string, layer string, run string, attempt string) ([]string, error)
 	PutLogs(namespace string, layer string, run string, attempt string, content []byte) error
+	GetLogsParts(namespace string, layer string, run string, attempt string, from int) ([]storage.LogsPart, error)
+	PutLogsPart(namespace string, layer string, run string, attempt string, part int, final bool, content []byte) error
+	GetOutputs(namespace string, layer string, run string, attempt string) ([]byte, error)
+	GetUnredactedOutputs(namespace string, layer string, run string, attempt string) ([]byte, error)
+	PutOutputs(namespace string, layer string, run string, attempt string, content []byte) error
 	PutGitBundle(namespace, name, ref, revision string, bundle []byte) error
 	CheckGitBundle(namespace, name, ref, revision string) (bool, error)
 	GetGitBundle(namespace, name, ref, revision string) ([]byte, error)
@@ -198,6 +205,143 @@ func (c *DefaultClient) PutLogs(namespace string, layer string, run string, atte
 	return nil
 }
 
+func (c *DefaultClient) GetLogsParts(namespace string, layer string, run string, attempt string, from int) ([]storage.LogsPart, error) {
+	queryParams := url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"from":      {strconv.Itoa(from)},
+	}
+	req, err := c.buildRequest(
+		"/api/logs/parts",
+		queryParams,
+		http.MethodGet,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get logs, there's an issue with the storage backend")
+	}
+	jresp := api.GetLogsPartsResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&jresp)
+	if err != nil {
+		return nil, err
+	}
+	return jresp.Parts, nil
+}
+
+func (c *DefaultClient) PutLogsPart(namespace string, layer string, run string, attempt string, part int, final bool, content []byte) error {
+	queryParams := url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"part":      {strconv.Itoa(part)},
+		"final":     {strconv.FormatBool(final)},
+	}
+	req, err := c.buildRequest(
+		"/api/logs",
+		queryParams,
+		http.MethodPut,
+		bytes.NewBuffer(content),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not put logs, there's an issue with the storage backend")
+	}
+	return nil
+}
+
+func (c *DefaultClient) GetOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	return c.getOutputs(url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+	})
+}
+
+// GetUnredactedOutputs returns the values of sensitive outputs as well, the datastore only
+// serves them to the service accounts allowed to publish them
+func (c *DefaultClient) GetUnredactedOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	return c.getOutputs(url.Values{
+		"namespace":  {namespace},
+		"layer":      {layer},
+		"run":        {run},
+		"attempt":    {attempt},
+		"unredacted": {"true"},
+	})
+}
+
+func (c *DefaultClient) getOutputs(query url.Values) ([]byte, error) {
+	req, err := c.buildRequest("/api/outputs", query, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &storageerrors.StorageError{
+			Err: fmt.Errorf("no outputs for this attempt"),
+			Nil: true,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get outputs, there's an issue with the storage backend")
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *DefaultClient) PutOutputs(namespace string, layer string, run string, attempt string, content []byte) error {
+	req, err := c.buildRequest(
+		"/api/outputs",
+		url.Values{
+			"namespace": {namespace},
+			"layer":     {layer},
+			"run":       {run},
+			"attempt":   {attempt},
+		},
+		http.MethodPut,
+		bytes.NewBuffer(content),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not put outputs, there's an issue reading the response from datastore: %s", err)
+		}
+		return fmt.Errorf("could not put outputs, there's an issue with the storage backend: %s", string(message))
+	}
+	return nil
+}
+
 func (c *DefaultClient) PutGitBundle(namespace, name, ref, revision string, bundle []byte) error {
 	req, err := c.buildRequest(
 		"/api/repository/revision/bundle",
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/padok-team/burrito/internal/datastore/storage"
 	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
 	log "github.com/sirupsen/logrus"
 )
@@ -51,6 +52,26 @@ func (c *MockClient) PutLogs(namespace string, layer string, run string, attempt
 	return nil
 }
 
+func (c *MockClient) GetLogsParts(namespace string, layer string, run string, attempt string, from int) ([]storage.LogsPart, error) {
+	return nil, nil
+}
+
+func (c *MockClient) PutLogsPart(namespace string, layer string, run string, attempt string, part int, final bool, content []byte) error {
+	return nil
+}
+
+func (c *MockClient) GetOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *MockClient) GetUnredactedOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *MockClient) PutOutputs(namespace string, layer string, run string, attempt string, content []byte) error {
+	return nil
+}
+
 func (c *MockClient) GetAttempts(namespace string, layer string, run string) (int, error) {
 	return 0, nil
 }
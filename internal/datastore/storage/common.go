@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
+	"path"
 	"slices"
 	"strconv"
 	"strings"
@@ -14,14 +16,26 @@ import (
 	"github.com/padok-team/burrito/internal/datastore/storage/gcs"
 	"github.com/padok-team/burrito/internal/datastore/storage/mock"
 	"github.com/padok-team/burrito/internal/datastore/storage/s3"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 )
 
 const (
 	LogFile                string = "run.log"
+	LogsPartsPrefix        string = "logs"
+	FinalLogsPartSuffix    string = "-final"
 	PlanBinFile            string = "plan.bin"
 	PlanJsonFile           string = "plan.json"
 	PrettyPlanFile         string = "pretty.plan"
 	ShortDiffFile          string = "short.diff"
+	DriftJsonFile          string = "drift.json"
+	PolicyJsonFile         string = "policy.json"
+	PlanSummaryFile        string = "summary.json"
+	HooksJsonFile          string = "hooks.json"
+	EventsJsonFile         string = "events.json"
+	ResultJsonFile         string = "result.json"
+	OutputsJsonFile        string = "outputs.json"
+	UnredactedPrefix       string = "unredacted"
+	UnitsPrefix            string = "units"
 	GitBundleFileExtension string = ".gitbundle"
 	RevisionFile           string = "latest"
 	LayersPrefix           string = "layers"
@@ -32,9 +46,58 @@ func computeLogsKey(namespace string, layer string, run string, attempt string)
 	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogFile)
 }
 
+// Parts are numbered with leading zeros so that they are listed in order
+func computeLogsPartKey(namespace string, layer string, run string, attempt string, part int, final bool) string {
+	name := fmt.Sprintf("%08d", part)
+	if final {
+		name += FinalLogsPartSuffix
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogsPartsPrefix, name)
+}
+
+// UnitPlanFormat returns the format of a plan of a unit of a terragrunt stack,
+// stored under the units prefix of the attempt
+func UnitPlanFormat(unit string, format string) string {
+	return fmt.Sprintf("%s/%s/%s", UnitsPrefix, unit, format)
+}
+
+// Split a unit plan format into the unit and the format of the plan, ok is false for layer formats
+func splitUnitPlanFormat(format string) (unit string, planFormat string, ok bool) {
+	if !strings.HasPrefix(format, UnitsPrefix+"/") {
+		return "", format, false
+	}
+	separator := strings.LastIndex(format, "/")
+	unit = strings.TrimPrefix(format[:separator], UnitsPrefix+"/")
+	return unit, format[separator+1:], unit != ""
+}
+
+// IsValidPlanFormat returns false for unit plan formats escaping the units prefix
+func IsValidPlanFormat(format string) bool {
+	unit, _, ok := splitUnitPlanFormat(format)
+	if !ok {
+		return true
+	}
+	for _, part := range strings.Split(unit, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// IsUnredactedPlanFormat returns true for the formats of unredacted plans, of layers and of units
+func IsUnredactedPlanFormat(format string) bool {
+	_, planFormat, _ := splitUnitPlanFormat(format)
+	return strings.HasSuffix(planFormat, "-unredacted")
+}
+
 func computePlanKey(namespace string, layer string, run string, attempt string, format string) string {
 	key := ""
 	prefix := fmt.Sprintf("%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt)
+	if unit, planFormat, ok := splitUnitPlanFormat(format); ok {
+		prefix = fmt.Sprintf("%s/%s/%s", prefix, UnitsPrefix, unit)
+		format = planFormat
+	}
 	switch format {
 	case "json":
 		key = fmt.Sprintf("%s/%s", prefix, PlanJsonFile)
@@ -44,12 +107,32 @@ func computePlanKey(namespace string, layer string, run string, attempt string,
 		key = fmt.Sprintf("%s/%s", prefix, ShortDiffFile)
 	case "bin":
 		key = fmt.Sprintf("%s/%s", prefix, PlanBinFile)
+	case "drift":
+		key = fmt.Sprintf("%s/%s", prefix, DriftJsonFile)
+	case "policy":
+		key = fmt.Sprintf("%s/%s", prefix, PolicyJsonFile)
+	case "summary":
+		key = fmt.Sprintf("%s/%s", prefix, PlanSummaryFile)
+	case "hooks":
+		key = fmt.Sprintf("%s/%s", prefix, HooksJsonFile)
+	case "events":
+		key = fmt.Sprintf("%s/%s", prefix, EventsJsonFile)
+	case "result":
+		key = fmt.Sprintf("%s/%s", prefix, ResultJsonFile)
+	case "pretty-unredacted":
+		key = fmt.Sprintf("%s/%s/%s", prefix, UnredactedPrefix, PrettyPlanFile)
+	case "json-unredacted":
+		key = fmt.Sprintf("%s/%s/%s", prefix, UnredactedPrefix, PlanJsonFile)
 	default:
 		key = fmt.Sprintf("%s/%s", prefix, PlanJsonFile)
 	}
 	return key
 }
 
+func computeOutputsKey(namespace string, layer string, run string, attempt string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, OutputsJsonFile)
+}
+
 func computeGitBundleKey(namespace string, repository string, branch string, revision string) string {
 	return fmt.Sprintf("%s/%s/%s/%s/%s%s", RepositoriesPrefix, namespace, repository, branch, revision, GitBundleFileExtension)
 }
@@ -94,8 +177,13 @@ func New(config config.Config) Storage {
 	return storage
 }
 
+// GetLogs returns the logs of an attempt uploaded whole by the controller, or the parts streamed
+// by the runner put together when the controller did not upload them
 func (s *Storage) GetLogs(namespace string, layer string, run string, attempt string) ([]byte, error) {
 	data, err := s.Backend.Get(computeLogsKey(namespace, layer, run, attempt))
+	if errors.NotFound(err) {
+		return s.getStreamedLogs(namespace, layer, run, attempt)
+	}
 	if err != nil {
 		return nil, err
 	} else {
@@ -103,6 +191,73 @@ func (s *Storage) GetLogs(namespace string, layer string, run string, attempt st
 	}
 }
 
+func (s *Storage) getStreamedLogs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	parts, err := s.GetLogsParts(namespace, layer, run, attempt, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, &errors.StorageError{Err: fmt.Errorf("no logs for attempt %s of run %s", attempt, run), Nil: true}
+	}
+	logs := []byte{}
+	for _, part := range parts {
+		logs = append(logs, part.Content...)
+	}
+	return logs, nil
+}
+
+// LogsPart is a chunk of the logs of an attempt uploaded by its runner while it is running.
+// The last part uploaded by a runner is final, unless it has been stopped before.
+type LogsPart struct {
+	Number  int    `json:"number"`
+	Final   bool   `json:"final"`
+	Content []byte `json:"content"`
+}
+
+// GetLogsParts returns the parts of the logs of an attempt numbered from from, in order
+func (s *Storage) GetLogsParts(namespace string, layer string, run string, attempt string, from int) ([]LogsPart, error) {
+	prefix := fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogsPartsPrefix)
+	keys, err := s.Backend.List(prefix)
+	if errors.NotFound(err) {
+		return []LogsPart{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	parts := []LogsPart{}
+	for _, key := range keys {
+		name, final := strings.CutSuffix(path.Base(strings.TrimSuffix(key, "/")), FinalLogsPartSuffix)
+		number, err := strconv.Atoi(name)
+		if err != nil || number < from {
+			continue
+		}
+		data, err := s.Backend.Get(computeLogsPartKey(namespace, layer, run, attempt, number, final))
+		if err != nil {
+			return nil, err
+		}
+		content, err := s.EncryptionManager.Decrypt(namespace, data)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, LogsPart{Number: number, Final: final, Content: content})
+	}
+	slices.SortFunc(parts, func(a, b LogsPart) int { return a.Number - b.Number })
+	return parts, nil
+}
+
+// PutLogsPart stores a part of the logs of an attempt streamed by its runner
+func (s *Storage) PutLogsPart(namespace string, layer string, run string, attempt string, part int, final bool, logs []byte) error {
+	dataToStore, err := s.EncryptionManager.Encrypt(namespace, logs)
+	if err != nil {
+		return err
+	}
+	err = s.Backend.Set(computeLogsPartKey(namespace, layer, run, attempt, part, final), dataToStore, 0)
+	if err != nil {
+		return fmt.Errorf("failed to store logs: %w", err)
+	}
+	return nil
+}
+
 func (s *Storage) GetLatestLogs(namespace string, layer string, run string) ([]byte, error) {
 	latestAttempt, err := s.GetLatestAttempt(namespace, layer, run)
 	if err != nil {
@@ -164,6 +319,49 @@ func (s *Storage) PutPlan(namespace string, layer string, run string, attempt st
 	return nil
 }
 
+func (s *Storage) GetOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	data, err := s.Backend.Get(computeOutputsKey(namespace, layer, run, attempt))
+	if err != nil {
+		return nil, err
+	}
+	return s.EncryptionManager.Decrypt(namespace, data)
+}
+
+func (s *Storage) GetLatestOutputs(namespace string, layer string, run string) ([]byte, error) {
+	latestAttempt, err := s.GetLatestAttempt(namespace, layer, run)
+	if err != nil {
+		return nil, err
+	}
+	if latestAttempt == "-1" {
+		return nil, &errors.StorageError{Nil: true}
+	}
+	return s.GetOutputs(namespace, layer, run, latestAttempt)
+}
+
+// PutOutputs stores the outputs of an apply. Sensitive values are only stored encrypted:
+// they are dropped when the encryption of the datastore is disabled.
+func (s *Storage) PutOutputs(namespace string, layer string, run string, attempt string, content []byte) error {
+	if s.EncryptionManager.DefaultEncryptor == nil {
+		outputs, err := runnerutils.ParseOutputs(content)
+		if err != nil {
+			return err
+		}
+		content, err = json.Marshal(outputs.Redact())
+		if err != nil {
+			return err
+		}
+	}
+	dataToStore, err := s.EncryptionManager.Encrypt(namespace, content)
+	if err != nil {
+		return err
+	}
+	err = s.Backend.Set(computeOutputsKey(namespace, layer, run, attempt), dataToStore, 0)
+	if err != nil {
+		return fmt.Errorf("failed to store outputs: %w", err)
+	}
+	return nil
+}
+
 func (s *Storage) GetLatestAttempt(namespace string, layer string, run string) (string, error) {
 	attempts, err := s.GetAttempts(namespace, layer, run)
 
@@ -21,13 +21,20 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
-func getLeaseName(layer *configv1alpha1.TerraformLayer) string {
-	return fmt.Sprintf("%s-%d", lockPrefix, hash(layer.Spec.Repository.Name+layer.Spec.Repository.Namespace+layer.Spec.Path))
+// The lease name is derived from the repository, the path and the workspace of the layer,
+// so that layers sharing a path but targeting different workspaces do not block each other.
+func getLeaseName(layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) string {
+	key := layer.Spec.Repository.Name + layer.Spec.Repository.Namespace + layer.Spec.Path
+	// Keep the previous lease name for the default workspace
+	if workspace := configv1alpha1.GetWorkspace(repository, layer); workspace != "" {
+		key += "/" + workspace
+	}
+	return fmt.Sprintf("%s-%d", lockPrefix, hash(key))
 }
 
-func getLeaseLock(layer *configv1alpha1.TerraformLayer, run *configv1alpha1.TerraformRun) *coordination.Lease {
+func getLeaseLock(layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository, run *configv1alpha1.TerraformRun) *coordination.Lease {
 	identity := "burrito-controller"
-	name := getLeaseName(layer)
+	name := getLeaseName(layer, repository)
 	lease := &coordination.Lease{
 		Spec: coordination.LeaseSpec{
 			HolderIdentity: &identity,
@@ -46,9 +53,9 @@ func getLeaseLock(layer *configv1alpha1.TerraformLayer, run *configv1alpha1.Terr
 	return lease
 }
 
-func IsLayerLocked(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer) (bool, error) {
+func IsLayerLocked(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (bool, error) {
 	err := c.Get(ctx, types.NamespacedName{
-		Name:      getLeaseName(layer),
+		Name:      getLeaseName(layer, repository),
 		Namespace: layer.Namespace,
 	}, &coordination.Lease{})
 	if errors.IsNotFound(err) {
@@ -60,12 +67,12 @@ func IsLayerLocked(ctx context.Context, c client.Client, layer *configv1alpha1.T
 	return true, nil
 }
 
-func CreateLock(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, run *configv1alpha1.TerraformRun) error {
-	leaseLock := getLeaseLock(layer, run)
+func CreateLock(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository, run *configv1alpha1.TerraformRun) error {
+	leaseLock := getLeaseLock(layer, repository, run)
 	return c.Create(ctx, leaseLock)
 }
 
-func DeleteLock(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, run *configv1alpha1.TerraformRun) error {
-	leaseLock := getLeaseLock(layer, run)
+func DeleteLock(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository, run *configv1alpha1.TerraformRun) error {
+	leaseLock := getLeaseLock(layer, repository, run)
 	return c.Delete(ctx, leaseLock)
 }
@@ -56,9 +56,11 @@ var _ = BeforeSuite(func() {
 
 var _ = Describe("Lock", func() {
 	var layer *configv1alpha1.TerraformLayer
+	var repository *configv1alpha1.TerraformRepository
 	var run *configv1alpha1.TerraformRun
 	var getErrLayer error
 	var getErrRun error
+	var getErrRepository error
 	Describe("Add check remove flow", Ordered, func() {
 		BeforeAll(func() {
 			layer = &configv1alpha1.TerraformLayer{}
@@ -66,36 +68,49 @@ var _ = Describe("Lock", func() {
 				Namespace: "default",
 				Name:      "test",
 			}, layer)
+			repository = &configv1alpha1.TerraformRepository{}
+			getErrRepository = k8sClient.Get(context.TODO(), types.NamespacedName{
+				Namespace: "default",
+				Name:      "burrito",
+			}, repository)
 			run = &configv1alpha1.TerraformRun{}
 			getErrRun = k8sClient.Get(context.TODO(), types.NamespacedName{
 				Namespace: "default",
 				Name:      "test-run",
 			}, run)
 		})
-		It("layer and run should exist", func() {
+		It("layer, repository and run should exist", func() {
 			Expect(getErrLayer).NotTo(HaveOccurred())
+			Expect(getErrRepository).NotTo(HaveOccurred())
 			Expect(getErrRun).NotTo(HaveOccurred())
 		})
 		It("should return false since layer is not locked", func() {
-			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer)
+			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer, repository)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(locked).To(Equal(false))
 		})
 		It("should not return error when creating Lease object", func() {
-			err := lock.CreateLock(context.TODO(), k8sClient, layer, run)
+			err := lock.CreateLock(context.TODO(), k8sClient, layer, repository, run)
 			Expect(err).NotTo(HaveOccurred())
 		})
 		It("should return true since layer is locked", func() {
-			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer)
+			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer, repository)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(locked).To(Equal(true))
 		})
+		It("should return false for the same layer in another workspace", func() {
+			other := layer.DeepCopy()
+			other.Spec.Workspace = "staging"
+			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, other, repository)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(Equal(false))
+		})
 		It("should not return error when deleting Lease object", func() {
-			err := lock.DeleteLock(context.TODO(), k8sClient, layer, run)
+			err := lock.DeleteLock(context.TODO(), k8sClient, layer, repository, run)
 			Expect(err).NotTo(HaveOccurred())
 		})
 		It("should return false since layer is not locked anymore", func() {
-			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer)
+			locked, err := lock.IsLayerLocked(context.TODO(), k8sClient, layer, repository)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(locked).To(Equal(false))
 		})
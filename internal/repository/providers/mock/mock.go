@@ -3,7 +3,9 @@ package mock
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"testing/fstest"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/controllers/terraformpullrequest/comment"
@@ -55,12 +57,27 @@ func (p *GitProvider) GetChanges(previousCommit, currentCommit string) []string
 		return []string{
 			"layer-with-files-changed/main.tf",
 			"other-files-changed/inputs.hcl",
+			"modules/random-pets/main.tf",
 		}
 	}
 
 	return []string{}
 }
 
+// Used in TerraformRepository Controller tests, the layer at layer-with-module calls a local module
+func (p *GitProvider) GetFiles(commit string) (fs.FS, error) {
+	if p.testfail() {
+		return nil, errors.New("mock provider: get files failed")
+	}
+	return fstest.MapFS{
+		"layer-with-module/main.tf": &fstest.MapFile{Data: []byte(`module "pets" {
+  source = "../modules/random-pets"
+}
+`)},
+		"modules/random-pets/main.tf": &fstest.MapFile{Data: []byte(`resource "random_pet" "this" {}`)},
+	}, nil
+}
+
 const mock_revision = "MOCK_REVISION"
 
 func GetMockRevision(ref string) string {
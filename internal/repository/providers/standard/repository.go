@@ -3,6 +3,7 @@ package standard
 import (
 	"crypto/sha256"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -243,6 +244,24 @@ func (p *GitProvider) GetChanges(previousCommit, currentCommit string) []string
 	return paths
 }
 
+// GetFiles returns the files of the repository at the given commit
+func (p *GitProvider) GetFiles(commit string) (fs.FS, error) {
+	if p.gitRepository == nil {
+		if err := p.clone(); err != nil {
+			return nil, err
+		}
+	}
+	c, err := p.gitRepository.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commit, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", commit, err)
+	}
+	return treeFS{tree: tree}, nil
+}
+
 // Create a git bundle with `git bundle create` and return the content as a byte array
 func createGitBundle(sourceDir, destination, ref string) ([]byte, error) {
 	cmd := exec.Command("git", "-C", sourceDir, "bundle", "create", destination, ref)
@@ -0,0 +1,107 @@
+package standard
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// treeFS exposes the files of a git tree as a read-only file system
+type treeFS struct {
+	tree *object.Tree
+}
+
+func (t treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &treeDir{info: treeFileInfo{name: ".", mode: fs.ModeDir | 0755}, tree: t.tree}, nil
+	}
+	entry, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.Mode == filemode.Dir {
+		tree, err := t.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &treeDir{info: treeFileInfo{name: entry.Name, mode: fs.ModeDir | 0755}, tree: tree}, nil
+	}
+	file, err := t.tree.File(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &treeFile{
+		info:   treeFileInfo{name: entry.Name, size: file.Size, mode: 0644},
+		Reader: bytes.NewReader([]byte(content)),
+	}, nil
+}
+
+type treeFileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (i treeFileInfo) Name() string       { return i.name }
+func (i treeFileInfo) Size() int64        { return i.size }
+func (i treeFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i treeFileInfo) ModTime() time.Time { return time.Time{} }
+func (i treeFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i treeFileInfo) Sys() any           { return nil }
+
+type treeFile struct {
+	info treeFileInfo
+	*bytes.Reader
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Close() error               { return nil }
+
+type treeDir struct {
+	info   treeFileInfo
+	tree   *object.Tree
+	offset int
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDir) Close() error               { return nil }
+
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.tree.Entries[d.offset:]
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	d.offset += len(entries)
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		info := treeFileInfo{name: entry.Name, mode: fs.ModeDir | 0755}
+		if entry.Mode != filemode.Dir {
+			size, err := d.tree.Size(entry.Name)
+			if err != nil {
+				return list, err
+			}
+			info.size = size
+			info.mode = 0644
+		}
+		list = append(list, fs.FileInfoToDirEntry(info))
+	}
+	return list, nil
+}
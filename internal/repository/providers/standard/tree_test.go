@@ -0,0 +1,60 @@
+package standard
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestTreeFS(t *testing.T) {
+	worktreeFS := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), worktreeFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"layers/app/main.tf":     `module "vpc" { source = "../../modules/vpc" }`,
+		"modules/vpc/main.tf":    `resource "null_resource" "this" {}`,
+		"modules/vpc/outputs.tf": `output "id" { value = null_resource.this.id }`,
+		"README.md":              "# infrastructure",
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		f, err := worktreeFS.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "burrito", Email: "burrito@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &GitProvider{gitRepository: repo}
+	fsys, err := p.GetFiles(hash.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fstest.TestFS(fsys, "layers/app/main.tf", "modules/vpc/main.tf", "modules/vpc/outputs.tf", "README.md"); err != nil {
+		t.Fatal(err)
+	}
+}
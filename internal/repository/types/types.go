@@ -1,6 +1,7 @@
 package types
 
 import (
+	"io/fs"
 	"net/http"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
@@ -19,6 +20,7 @@ type GitProvider interface {
 	GetLatestRevisionForRef(ref string) (string, error)
 	Bundle(ref string) ([]byte, error)
 	GetChanges(previousCommit, currentCommit string) []string
+	GetFiles(commit string) (fs.FS, error)
 }
 
 type WebhookProvider interface {
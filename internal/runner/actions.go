@@ -9,16 +9,22 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	tfjson "github.com/hashicorp/terraform-json"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/utils/policy"
 	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	log "github.com/sirupsen/logrus"
 )
 
 const PlanArtifact string = "/tmp/plan.out"
+const DriftArtifact string = "/tmp/drift.out"
+
+// Resources drifted listed in the annotations of the layer
+const maxDriftedResourcesListed = 20
 
 // Execute the actions defined in the runner configuration. The runner must
 // be initialized.
@@ -26,21 +32,88 @@ func (r *Runner) ExecAction() error {
 	ann := map[string]string{}
 
 	switch r.config.Runner.Action {
-	case "plan":
-		sum, err := r.execPlan()
+	case "plan", "destroy":
+		var args []string
+		if r.config.Runner.Action == "destroy" {
+			args = append(args, "-destroy")
+		}
+		args = append(args, getPlanOptionsArgs(r.Run.Spec.PlanOptions)...)
+		err := r.execHooks(configv1alpha1.HookPhasePrePlan)
+		if err != nil {
+			return err
+		}
+		var result *planResult
+		if r.isStack() {
+			result, err = r.execStackPlan(args...)
+		} else {
+			result, err = r.execPlan(args...)
+		}
 		if err != nil {
 			return err
 		}
+		err = r.execHooks(configv1alpha1.HookPhasePostPlan)
+		if err != nil {
+			return err
+		}
+		planChanges := result.summary.ChangeCounts()
+		r.updateResult(func(runResult *configv1alpha1.RunResult) {
+			runResult.Plan = &planChanges
+		})
+		ann[annotations.LastPlanPolicyPassed] = ""
+		if result.policy != nil {
+			ann[annotations.LastPlanPolicyPassed] = strconv.FormatBool(result.policy.Passed)
+		}
 		ann[annotations.LastPlanDate] = time.Now().Format(time.UnixDate)
 		ann[annotations.LastPlanRun] = fmt.Sprintf("%s/%s", r.Run.Name, strconv.Itoa(r.Run.Status.Retries))
-		ann[annotations.LastPlanSum] = sum
+		ann[annotations.LastPlanSum] = result.sum
+		ann[annotations.LastPlanHasChanges] = strconv.FormatBool(result.summary.HasChanges())
+		ann[annotations.LastPlanRedactions] = strconv.Itoa(result.redactions)
 		ann[annotations.LastPlanCommit] = r.Run.Spec.Layer.Revision
+		ann[annotations.LastPlanAction] = r.config.Runner.Action
+		ann[annotations.LastPlanPartial] = strconv.FormatBool(r.Run.Spec.PlanOptions.IsPartial())
+
+	case "drift":
+		if r.isStack() {
+			return errors.New("drift detection is not supported for terragrunt stacks")
+		}
+		drifted, err := r.execDriftCheck()
+		if err != nil {
+			return err
+		}
+		ann[annotations.LastDriftDate] = time.Now().Format(time.UnixDate)
+		ann[annotations.LastDriftRun] = fmt.Sprintf("%s/%s", r.Run.Name, strconv.Itoa(r.Run.Status.Retries))
+		// The list is bounded to keep the layer small, the drift artifact lists every resource
+		listed := drifted
+		if len(listed) > maxDriftedResourcesListed {
+			listed = listed[:maxDriftedResourcesListed]
+		}
+		ann[annotations.LastDriftResources] = strings.Join(listed, ",")
+		ann[annotations.LastDriftCount] = strconv.Itoa(len(drifted))
 
 	case "apply":
-		sum, err := r.execApply()
+		if err := r.checkPlanFreshness(); err != nil {
+			return r.refuseApply(err)
+		}
+		err := r.execHooks(configv1alpha1.HookPhasePreApply)
 		if err != nil {
 			return err
 		}
+		if r.isInterrupted() {
+			return errInterrupted
+		}
+		var sum string
+		if r.isStack() {
+			sum, err = r.execStackApply()
+		} else {
+			sum, err = r.execApply()
+		}
+		if errors.Is(err, errStalePlan) {
+			return r.refuseApply(err)
+		}
+		if err != nil {
+			return err
+		}
+		// The apply is recorded on the layer before running the post-apply hooks
 		ann[annotations.LastApplyDate] = time.Now().Format(time.UnixDate)
 		ann[annotations.LastApplySum] = sum
 		ann[annotations.LastApplyCommit] = r.Run.Spec.Layer.Revision
@@ -55,9 +128,23 @@ func (r *Runner) ExecAction() error {
 	}
 	log.Infof("successfully updated TerraformLayer annotations")
 
+	if r.config.Runner.Action == "apply" {
+		r.exportOutputs()
+		r.execPostApplyHooks()
+	}
 	return nil
 }
 
+// Run the post-apply hooks once the apply has been recorded. A failing hook does not fail the run,
+// since a failed run would be retried and apply again: the failure is only reported in the status
+// of the run, see the HasHookFailed condition.
+func (r *Runner) execPostApplyHooks() {
+	err := r.execHooks(configv1alpha1.HookPhasePostApply)
+	if err != nil {
+		log.Errorf("%s, the apply has succeeded and is not retried", err)
+	}
+}
+
 // Run the `init` command
 func (r *Runner) ExecInit() error {
 	log.Infof("launching %s init in %s", r.exec.TenvName(), r.workingDir)
@@ -65,7 +152,15 @@ func (r *Runner) ExecInit() error {
 		err := errors.New("terraform or terragrunt binary not installed")
 		return err
 	}
-	err := r.exec.Init(r.workingDir)
+	args, err := getBackendConfigArgs(configv1alpha1.GetBackendConfig(r.Repository, r.Layer), r.config.Runner.BackendConfigPath, os.TempDir())
+	if err != nil {
+		log.Errorf("error computing %s backend configuration: %s", r.exec.TenvName(), err)
+		return err
+	}
+	if r.isStack() {
+		return r.execStackInit(args...)
+	}
+	err = r.exec.Init(r.workingDir, args...)
 	if err != nil {
 		log.Errorf("error executing %s init: %s", r.exec.TenvName(), err)
 		return err
@@ -73,41 +168,106 @@ func (r *Runner) ExecInit() error {
 	return nil
 }
 
+// Select the workspace configured on the layer, creating it if needed.
+// Does nothing when the layer uses the default workspace.
+func (r *Runner) ExecWorkspace() error {
+	workspace := configv1alpha1.GetWorkspace(r.Repository, r.Layer)
+	if workspace == "" {
+		return nil
+	}
+	if r.isStack() {
+		return errors.New("workspaces are not supported for terragrunt stacks")
+	}
+	if r.exec == nil {
+		err := errors.New("terraform or terragrunt binary not installed")
+		return err
+	}
+	log.Infof("selecting %s workspace %s", r.exec.TenvName(), workspace)
+	err := r.exec.SelectWorkspace(workspace)
+	if err != nil {
+		log.Errorf("error selecting %s workspace %s: %s", r.exec.TenvName(), workspace, err)
+		return err
+	}
+	return nil
+}
+
+// Build the -target and -replace arguments of a partial plan
+func getPlanOptionsArgs(options configv1alpha1.PlanOptions) []string {
+	args := []string{}
+	for _, target := range options.Targets {
+		args = append(args, fmt.Sprintf("-target=%s", target))
+	}
+	for _, replace := range options.Replaces {
+		args = append(args, fmt.Sprintf("-replace=%s", replace))
+	}
+	return args
+}
+
+type planResult struct {
+	// sha256 sum of the plan artifact
+	sum     string
+	summary runnerutils.PlanSummary
+	// nil if the layer has no policies
+	policy *policy.Report
+	// number of values masked in the stored plans
+	redactions int
+}
+
 // Run the `plan` command and save the plan artifact in the datastore
-// Returns the sha256 sum of the plan artifact
-func (r *Runner) execPlan() (string, error) {
+func (r *Runner) execPlan(extraArgs ...string) (*planResult, error) {
 	log.Infof("running %s plan", r.exec.TenvName())
 	if r.exec == nil {
 		err := errors.New("terraform or terragrunt binary not installed")
-		return "", err
+		return nil, err
 	}
-	err := r.exec.Plan(PlanArtifact)
+	args, err := r.getVariablesArgs()
+	if err != nil {
+		log.Errorf("error computing %s variables: %s", r.exec.TenvName(), err)
+		return nil, err
+	}
+	args = append(args, r.startJSONUI()...)
+	err = r.exec.Plan(PlanArtifact, append(extraArgs, args...)...)
+	r.endJSONUI(false)
 	if err != nil {
 		log.Errorf("error executing %s plan: %s", r.exec.TenvName(), err)
-		return "", err
+		return nil, err
 	}
 	planJsonBytes, err := r.exec.Show(PlanArtifact, "json")
 	if err != nil {
 		log.Errorf("error getting %s plan json: %s", r.exec.TenvName(), err)
-		return "", err
+		return nil, err
 	}
 	prettyPlan, err := r.exec.Show(PlanArtifact, "pretty")
 	if err != nil {
 		log.Errorf("error getting %s pretty plan: %s", r.exec.TenvName(), err)
-		return "", err
-	}
-	log.Infof("sending plan to datastore")
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "pretty", prettyPlan)
-	if err != nil {
-		log.Errorf("could not put pretty plan in datastore: %s", err)
+		return nil, err
 	}
 	plan := &tfjson.Plan{}
 	err = json.Unmarshal(planJsonBytes, plan)
 	if err != nil {
 		log.Errorf("error parsing %s json plan: %s", r.exec.TenvName(), err)
-		return "", err
+		return nil, err
+	}
+	err = os.WriteFile(PlanJsonArtifact, planJsonBytes, 0600)
+	if err != nil {
+		log.Errorf("could not write json plan for post-plan hooks: %s", err)
+	}
+	if r.config.Runner.StoreUnredacted {
+		r.putUnredactedPlan("pretty", prettyPlan)
+		r.putUnredactedPlan("json", planJsonBytes)
+	}
+	r.getRedactor().AddSensitiveValues(plan)
+	prettyPlan, prettyRedactions := r.getRedactor().Redact(prettyPlan)
+	planJsonBytes, jsonRedactions := r.getRedactor().Redact(planJsonBytes)
+	log.Infof("sending plan to datastore, %d values redacted", prettyRedactions+jsonRedactions)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "pretty", prettyPlan)
+	if err != nil {
+		log.Errorf("could not put pretty plan in datastore: %s", err)
 	}
 	_, shortDiff := runnerutils.GetDiff(plan)
+	if r.Run.Spec.PlanOptions.IsPartial() {
+		shortDiff = fmt.Sprintf("Partial %s", shortDiff)
+	}
 	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "json", planJsonBytes)
 	if err != nil {
 		log.Errorf("could not put json plan in datastore: %s", err)
@@ -116,19 +276,130 @@ func (r *Runner) execPlan() (string, error) {
 	if err != nil {
 		log.Errorf("could not put short plan in datastore: %s", err)
 	}
+	summary := runnerutils.GetPlanSummary(plan)
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		log.Errorf("error computing plan summary: %s", err)
+		return nil, err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "summary", summaryBytes)
+	if err != nil {
+		log.Errorf("could not put plan summary in datastore: %s", err)
+	}
+	report, err := r.execPolicies(plan)
+	if err != nil {
+		log.Errorf("error evaluating policies: %s", err)
+		return nil, err
+	}
 	planBin, err := os.ReadFile(PlanArtifact)
 	if err != nil {
 		log.Errorf("could not read plan output: %s", err)
-		return "", err
+		return nil, err
 	}
 	sum := sha256.Sum256(planBin)
 	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "bin", planBin)
 	if err != nil {
 		log.Errorf("could not put plan binary in cache: %s", err)
-		return "", err
+		return nil, err
 	}
 	log.Infof("%s plan ran successfully", r.exec.TenvName())
-	return b64.StdEncoding.EncodeToString(sum[:]), nil
+	return &planResult{
+		sum:        b64.StdEncoding.EncodeToString(sum[:]),
+		summary:    summary,
+		policy:     report,
+		redactions: prettyRedactions + jsonRedactions,
+	}, nil
+}
+
+// Save a plan before redaction in the datastore, in a format which is never served by the datastore API
+func (r *Runner) putUnredactedPlan(format string, content []byte) {
+	err := r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), fmt.Sprintf("%s-unredacted", format), content)
+	if err != nil {
+		log.Errorf("could not put unredacted %s plan in datastore: %s", format, err)
+	}
+}
+
+// Evaluate the policies of the layer against the plan and save the report in the datastore
+func (r *Runner) execPolicies(plan *tfjson.Plan) (*policy.Report, error) {
+	policies, err := r.loadPolicies()
+	if err != nil || policies == nil {
+		return nil, err
+	}
+	log.Infof("evaluating %d policies against the plan", len(policies))
+	report, err := policy.Evaluate(policies, plan)
+	if err != nil {
+		return nil, err
+	}
+	for _, violation := range report.Violations {
+		log.Warnf("policy %s violated by %s: %s", violation.Policy, violation.Address, violation.Message)
+	}
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "policy", reportBytes)
+	if err != nil {
+		log.Errorf("could not put policy report in datastore: %s", err)
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Load the policies mounted in the runner, nil when the layer has no policies
+// A layer with policies but none mounted must not get a compliant report without any evaluation
+func (r *Runner) loadPolicies() ([]policy.Policy, error) {
+	if len(configv1alpha1.GetPolicies(r.Repository, r.Layer).ConfigMaps) == 0 {
+		return nil, nil
+	}
+	policies, err := policy.Load(r.config.Runner.PoliciesPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("the layer has policies but none could be loaded from %s", r.config.Runner.PoliciesPath)
+	}
+	return policies, nil
+}
+
+// Run a refresh-only `plan` command and save its json output in the datastore as the drift artifact
+// Returns the addresses of the resources which have drifted
+func (r *Runner) execDriftCheck() ([]string, error) {
+	log.Infof("running %s refresh-only plan", r.exec.TenvName())
+	if r.exec == nil {
+		err := errors.New("terraform or terragrunt binary not installed")
+		return nil, err
+	}
+	args, err := r.getVariablesArgs()
+	if err != nil {
+		log.Errorf("error computing %s variables: %s", r.exec.TenvName(), err)
+		return nil, err
+	}
+	err = r.exec.Plan(DriftArtifact, append([]string{"-refresh-only"}, args...)...)
+	if err != nil {
+		log.Errorf("error executing %s refresh-only plan: %s", r.exec.TenvName(), err)
+		return nil, err
+	}
+	driftJsonBytes, err := r.exec.Show(DriftArtifact, "json")
+	if err != nil {
+		log.Errorf("error getting %s refresh-only plan json: %s", r.exec.TenvName(), err)
+		return nil, err
+	}
+	plan := &tfjson.Plan{}
+	err = json.Unmarshal(driftJsonBytes, plan)
+	if err != nil {
+		log.Errorf("error parsing %s json refresh-only plan: %s", r.exec.TenvName(), err)
+		return nil, err
+	}
+	drifted := runnerutils.GetDriftedResources(plan)
+	r.getRedactor().AddSensitiveValues(plan)
+	driftJsonBytes, _ = r.getRedactor().Redact(driftJsonBytes)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "drift", driftJsonBytes)
+	if err != nil {
+		log.Errorf("could not put drift artifact in datastore: %s", err)
+		return nil, err
+	}
+	log.Infof("%s refresh-only plan ran successfully, %d resources have drifted", r.exec.TenvName(), len(drifted))
+	return drifted, nil
 }
 
 // Run the `apply` command, by default with the plan artifact from the previous plan run
@@ -146,26 +417,122 @@ func (r *Runner) execApply() (string, error) {
 		return "", err
 	}
 	sum := sha256.Sum256(plan)
+	if err := r.checkPlanSum(b64.StdEncoding.EncodeToString(sum[:])); err != nil {
+		return "", err
+	}
 	err = os.WriteFile(PlanArtifact, plan, 0644)
 	if err != nil {
 		log.Errorf("could not write plan artifact to disk: %s", err)
 		return "", err
 	}
-	log.Infof("launching %s apply", r.exec.TenvName())
-	if configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer) {
+	withoutArtifact := configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer)
+	var args []string
+	if withoutArtifact {
 		log.Infof("applying without reusing plan artifact from previous plan run")
-		err = r.exec.Apply("")
+		args, err = r.getApplyWithoutArtifactArgs()
+		if err != nil {
+			log.Errorf("error computing %s apply arguments: %s", r.exec.TenvName(), err)
+			return "", err
+		}
+	}
+	log.Infof("launching %s apply", r.exec.TenvName())
+	jsonArgs := r.startJSONUI()
+	if withoutArtifact {
+		err = r.exec.Apply("", append(args, jsonArgs...)...)
 	} else {
-		err = r.exec.Apply(PlanArtifact)
+		err = r.exec.Apply(PlanArtifact, jsonArgs...)
 	}
+	events := r.endJSONUI(true)
 	if err != nil {
 		log.Errorf("error executing %s apply: %s", r.exec.TenvName(), err)
 		return "", err
 	}
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "short", []byte("Apply Successful"))
+	changes := runnerutils.GetAppliedChanges(events)
+	if changes == nil && !withoutArtifact {
+		changes = r.getArtifactChanges()
+	}
+	shortDiff := r.setApplyChanges(changes)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "short", []byte(shortDiff))
 	if err != nil {
 		log.Errorf("could not put short plan in datastore: %s", err)
 	}
 	log.Infof("%s apply ran successfully", r.exec.TenvName())
 	return b64.StdEncoding.EncodeToString(sum[:]), nil
 }
+
+var errStalePlan = errors.New("stale plan")
+
+// Arguments of an apply without the plan artifact. Variables, the destroy intent and the options of
+// the last plan are embedded in its artifact: they must be passed again, so that the apply matches
+// the plan. A partial plan whose options are not known is refused.
+func (r *Runner) getApplyWithoutArtifactArgs() ([]string, error) {
+	if r.Layer.Annotations[annotations.LastPlanPartial] == "true" && !r.Run.Spec.PlanOptions.IsPartial() {
+		return nil, fmt.Errorf("%w: the last plan is partial and its targets and replaces are not known", errStalePlan)
+	}
+	args, err := r.getVariablesArgs()
+	if err != nil {
+		return nil, err
+	}
+	if r.Layer.Annotations[annotations.LastPlanAction] == "destroy" {
+		args = append([]string{"-destroy"}, args...)
+	}
+	return append(args, getPlanOptionsArgs(r.Run.Spec.PlanOptions)...), nil
+}
+
+// Check that the plan to apply is still the last plan of the layer, made at the last relevant commit
+// the apply was scheduled for, and that it is not too old
+func (r *Runner) checkPlanFreshness() error {
+	if commit := r.Layer.Annotations[annotations.LastRelevantCommit]; commit != "" {
+		if commit != r.Run.Spec.Layer.Revision {
+			return fmt.Errorf("%w: commit %s has been pushed since the apply was scheduled for commit %s", errStalePlan, commit, r.Run.Spec.Layer.Revision)
+		}
+		if planCommit := r.Layer.Annotations[annotations.LastPlanCommit]; planCommit != commit {
+			return fmt.Errorf("%w: the last plan was made at commit %q, not at the last relevant commit %s", errStalePlan, planCommit, commit)
+		}
+	}
+	if err := runnerutils.CheckPlanAge(r.Layer.Annotations[annotations.LastPlanDate], r.config.Runner.PlanMaxAge, time.Now()); err != nil {
+		return fmt.Errorf("%w: %w", errStalePlan, err)
+	}
+	return nil
+}
+
+// Check that the plan artifact fetched from the datastore is the one recorded by the last plan of
+// the layer. The artifact is not used when applying without it, its sum is not checked then.
+func (r *Runner) checkPlanSum(sum string) error {
+	if configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer) {
+		return nil
+	}
+	if expected := r.Layer.Annotations[annotations.LastPlanSum]; sum != expected {
+		return fmt.Errorf("%w: the sum of the plan artifact %s does not match the sum of the last plan %s", errStalePlan, sum, expected)
+	}
+	return nil
+}
+
+// A stale plan is not applied. The run succeeds without applying anything, and a new plan of the
+// layer is requested instead, a destroy plan for a destroy plan.
+func (r *Runner) refuseApply(reason error) error {
+	log.Warnf("refusing to apply: %s", reason)
+	message := fmt.Sprintf("Apply refused: %s", reason)
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		result.ExitReason = ExitReasonRefused
+		result.Message = message
+	})
+	err := r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "short", []byte(message))
+	if err != nil {
+		log.Errorf("could not put short plan in datastore: %s", err)
+	}
+	r.patchRunStatus("reason", func(status *configv1alpha1.TerraformRunStatus) {
+		status.Reason = message
+	})
+	request := annotations.SyncNow
+	if r.Layer.Annotations[annotations.LastPlanAction] == "destroy" {
+		request = annotations.DestroyNow
+	}
+	err = annotations.Add(context.TODO(), r.Client, r.Layer, map[string]string{request: "true"})
+	if err != nil {
+		log.Errorf("could not request a new plan of the TerraformLayer: %s", err)
+		return err
+	}
+	log.Infof("a new plan of the TerraformLayer has been requested")
+	return nil
+}
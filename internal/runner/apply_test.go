@@ -0,0 +1,185 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/datastore/storage"
+	tg "github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Datastore serving the plans of a run, by format
+type fakePlans struct {
+	datastore.Client
+	plans map[string][]byte
+}
+
+func (f *fakePlans) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
+	plan, ok := f.plans[format]
+	if !ok {
+		return nil, fmt.Errorf("plan %s not found", format)
+	}
+	return plan, nil
+}
+
+func (f *fakePlans) PutPlan(namespace string, layer string, run string, attempt string, format string, content []byte) error {
+	if f.plans == nil {
+		f.plans = map[string][]byte{}
+	}
+	f.plans[format] = content
+	return nil
+}
+
+func newApplyRunner(layerAnnotations map[string]string, plans map[string][]byte) *Runner {
+	return &Runner{
+		config:    &config.Config{},
+		Datastore: &fakePlans{plans: plans},
+		Layer: &configv1alpha1.TerraformLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-layer", Namespace: "default", Annotations: layerAnnotations},
+		},
+		Run: &configv1alpha1.TerraformRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-layer-apply-abcde", Namespace: "default"},
+			Spec: configv1alpha1.TerraformRunSpec{
+				Layer:    configv1alpha1.TerraformRunLayer{Revision: "abcdef"},
+				Artifact: configv1alpha1.Artifact{Run: "my-layer-plan-abcde", Attempt: "0"},
+			},
+		},
+		Repository: &configv1alpha1.TerraformRepository{},
+	}
+}
+
+func TestCheckPlanFreshness(t *testing.T) {
+	now := time.Now().Format(time.UnixDate)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		stale       bool
+	}{
+		{"plan at the last relevant commit", map[string]string{annotations.LastRelevantCommit: "abcdef", annotations.LastPlanCommit: "abcdef", annotations.LastPlanDate: now}, false},
+		{"no relevant commit known", map[string]string{annotations.LastPlanDate: now}, false},
+		{"commit pushed since the apply was scheduled", map[string]string{annotations.LastRelevantCommit: "123456", annotations.LastPlanCommit: "abcdef", annotations.LastPlanDate: now}, true},
+		{"plan made at a previous commit", map[string]string{annotations.LastRelevantCommit: "abcdef", annotations.LastPlanCommit: "123456", annotations.LastPlanDate: now}, true},
+		{"plan without commit", map[string]string{annotations.LastRelevantCommit: "abcdef", annotations.LastPlanDate: now}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := newApplyRunner(test.annotations, nil).checkPlanFreshness()
+			if stale := errors.Is(err, errStalePlan); stale != test.stale {
+				t.Errorf("expected stale to be %t, got %v", test.stale, err)
+			}
+		})
+	}
+
+	r := newApplyRunner(map[string]string{annotations.LastPlanDate: "Mon May  8 11:21:53 UTC 2023"}, nil)
+	r.config.Runner.PlanMaxAge = time.Hour
+	if err := r.checkPlanFreshness(); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected a plan older than the maximum age to be stale, got %v", err)
+	}
+}
+
+func TestCheckPlanSum(t *testing.T) {
+	r := newApplyRunner(map[string]string{annotations.LastPlanSum: "sum"}, nil)
+	if err := r.checkPlanSum("sum"); err != nil {
+		t.Errorf("expected the sum of the last plan to be valid, got %s", err)
+	}
+	if err := r.checkPlanSum("other"); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected a different sum to be refused, got %v", err)
+	}
+
+	withoutArtifact := true
+	r.Layer.Spec.RemediationStrategy.ApplyWithoutPlanArtifact = &withoutArtifact
+	if err := r.checkPlanSum("other"); err != nil {
+		t.Errorf("expected the sum not to be checked when applying without plan artifact, got %s", err)
+	}
+}
+
+func TestGetStackPlans(t *testing.T) {
+	units := []tg.Unit{{Path: "network"}, {Path: "app", Dependencies: []string{"network"}}}
+	bins := map[string][]byte{"network": []byte("network plan"), "app": []byte("app plan")}
+	plans := map[string][]byte{
+		storage.UnitPlanFormat("network", "bin"): bins["network"],
+		storage.UnitPlanFormat("app", "bin"):     bins["app"],
+	}
+	sum := getStackSum(units, bins)
+
+	r := newApplyRunner(map[string]string{annotations.LastPlanSum: sum}, plans)
+	fetched, err := r.getStackPlans(units)
+	if err != nil {
+		t.Fatalf("expected the plans of the stack to be fetched, got %s", err)
+	}
+	if string(fetched["app"]) != "app plan" {
+		t.Errorf("unexpected plan of unit app %q", fetched["app"])
+	}
+
+	r = newApplyRunner(map[string]string{annotations.LastPlanSum: "other"}, plans)
+	if _, err := r.getStackPlans(units); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected the stack apply to be refused when its sum does not match, got %v", err)
+	}
+
+	delete(plans, storage.UnitPlanFormat("app", "bin"))
+	r = newApplyRunner(map[string]string{annotations.LastPlanSum: sum}, plans)
+	if _, err := r.getStackPlans(units); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected the stack apply to be refused when a plan artifact is missing, got %v", err)
+	}
+
+	withoutArtifact := true
+	r.Layer.Spec.RemediationStrategy.ApplyWithoutPlanArtifact = &withoutArtifact
+	if _, err := r.getStackPlans(units); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected the stack apply to be refused when a plan artifact is missing without plan artifact, got %v", err)
+	}
+}
+
+func TestGetApplyWithoutArtifactArgs(t *testing.T) {
+	r := newApplyRunner(map[string]string{
+		annotations.LastPlanAction:  "destroy",
+		annotations.LastPlanPartial: "true",
+	}, nil)
+	r.Run.Spec.PlanOptions = configv1alpha1.PlanOptions{Targets: []string{"module.network"}, Replaces: []string{"aws_instance.web"}}
+	args, err := r.getApplyWithoutArtifactArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"-destroy", "-target=module.network", "-replace=aws_instance.web"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected the destroy intent and the options of the plan to be passed again, got %v", args)
+	}
+
+	r.Run.Spec.PlanOptions = configv1alpha1.PlanOptions{}
+	if _, err := r.getApplyWithoutArtifactArgs(); !errors.Is(err, errStalePlan) {
+		t.Errorf("expected a partial plan without its options to be refused, got %v", err)
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	r := newApplyRunner(map[string]string{}, nil)
+	r.config.Runner.PoliciesPath = t.TempDir()
+	policies, err := r.loadPolicies()
+	if err != nil || policies != nil {
+		t.Fatalf("loadPolicies() of a layer without policies = %v, %v, want nil", policies, err)
+	}
+	r.Layer.Spec.Policies.ConfigMaps = []corev1.LocalObjectReference{{Name: "org-policies"}}
+	if _, err := r.loadPolicies(); err == nil {
+		t.Fatal("loadPolicies() should fail when the policies of the layer are not mounted")
+	}
+	if err := os.MkdirAll(filepath.Join(r.config.Runner.PoliciesPath, "org-policies"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(r.config.Runner.PoliciesPath, "org-policies", "no-deletion.cel"), []byte("true"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err = r.loadPolicies()
+	if err != nil || len(policies) != 1 {
+		t.Fatalf("loadPolicies() = %v, %v, want one policy", policies, err)
+	}
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	tg "github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	log "github.com/sirupsen/logrus"
+)
+
+// JSON plan written for the post-plan hooks, before redaction
+const PlanJsonArtifact string = "/tmp/plan.json"
+
+// HookReport is the result of a hook stored in the datastore
+type HookReport struct {
+	Name        string                   `json:"name"`
+	Phase       configv1alpha1.HookPhase `json:"phase"`
+	Command     []string                 `json:"command"`
+	ExitCode    int                      `json:"exitCode"`
+	FailOnError bool                     `json:"failOnError"`
+	Output      string                   `json:"output"`
+}
+
+// Run the hooks of the layer for the given phase, in the order they are defined.
+// Returns an error if a hook which must fail the run has failed.
+func (r *Runner) execHooks(phase configv1alpha1.HookPhase) error {
+	for _, hook := range configv1alpha1.GetHooks(r.Repository, r.Layer) {
+		if hook.Phase != phase {
+			continue
+		}
+		report := r.execHook(hook)
+		r.hooks = append(r.hooks, report)
+		r.putHookReports()
+		if report.ExitCode == 0 {
+			continue
+		}
+		if report.FailOnError {
+			return fmt.Errorf("%s hook %s failed with exit code %d", phase, hook.Name, report.ExitCode)
+		}
+		log.Warnf("%s hook %s failed with exit code %d, ignoring", phase, hook.Name, report.ExitCode)
+	}
+	return nil
+}
+
+func (r *Runner) execHook(hook configv1alpha1.Hook) HookReport {
+	report := HookReport{
+		Name:        hook.Name,
+		Phase:       hook.Phase,
+		Command:     hook.Command,
+		FailOnError: hook.IsFailOnError(),
+	}
+	if len(hook.Command) == 0 {
+		report.ExitCode = -1
+		report.Output = "hook has no command"
+		return report
+	}
+	log.Infof("running %s hook %s", hook.Phase, hook.Name)
+	output := &bytes.Buffer{}
+	cmd := exec.Command(hook.Command[0], hook.Command[1:]...)
+	c.Verbose(cmd)
+	cmd.Stdout = io.MultiWriter(cmd.Stdout, output)
+	cmd.Stderr = io.MultiWriter(cmd.Stderr, output)
+	cmd.Dir = r.workingDir
+	cmd.Env = r.getHookEnv(hook.Phase)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		report.ExitCode = exitErr.ExitCode()
+	default:
+		report.ExitCode = -1
+		fmt.Fprintf(output, "could not run hook: %s\n", err)
+	}
+	redacted, _ := r.getRedactor().Redact(output.Bytes())
+	report.Output = string(redacted)
+	return report
+}
+
+// Hooks can call the binaries of the layer by their name, and get the JSON plan after a plan
+func (r *Runner) getHookEnv(phase configv1alpha1.HookPhase) []string {
+	paths := []string{}
+	if r.exec != nil {
+		paths = append(paths, filepath.Dir(r.exec.GetExecPath()))
+		if terragrunt, ok := r.exec.(*tg.Terragrunt); ok {
+			paths = append(paths, filepath.Dir(terragrunt.ChildExecPath))
+		}
+	}
+	paths = append(paths, os.Getenv("PATH"))
+	env := append(os.Environ(),
+		fmt.Sprintf("PATH=%s", strings.Join(paths, string(os.PathListSeparator))),
+		fmt.Sprintf("BURRITO_HOOK_PHASE=%s", phase),
+	)
+	if phase == configv1alpha1.HookPhasePostPlan {
+		if _, err := os.Stat(PlanJsonArtifact); err == nil {
+			env = append(env, fmt.Sprintf("BURRITO_PLAN_JSON=%s", PlanJsonArtifact))
+		}
+	}
+	return env
+}
+
+// Save the reports of the hooks run so far in the datastore and their results on the status of the run
+func (r *Runner) putHookReports() {
+	content, err := json.Marshal(r.hooks)
+	if err != nil {
+		log.Errorf("could not marshal hook reports: %s", err)
+		return
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "hooks", content)
+	if err != nil {
+		log.Errorf("could not put hook reports in datastore: %s", err)
+	}
+	results := []configv1alpha1.HookResult{}
+	for _, report := range r.hooks {
+		results = append(results, configv1alpha1.HookResult{
+			Name:        report.Name,
+			Phase:       report.Phase,
+			ExitCode:    report.ExitCode,
+			FailOnError: report.FailOnError,
+		})
+	}
+	r.patchRunStatus("hook results", func(status *configv1alpha1.TerraformRunStatus) {
+		status.Hooks = results
+	})
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newHooksRunner(t *testing.T, hooks ...configv1alpha1.Hook) (*Runner, *fakePlans) {
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	r := newApplyRunner(map[string]string{}, nil)
+	r.workingDir = t.TempDir()
+	r.Layer.Spec.Hooks = hooks
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(r.Run).WithStatusSubresource(r.Run).Build()
+	return r, r.Datastore.(*fakePlans)
+}
+
+func getRunHooks(t *testing.T, r *Runner) []configv1alpha1.HookResult {
+	run := &configv1alpha1.TerraformRun{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(r.Run), run); err != nil {
+		t.Fatal(err)
+	}
+	return run.Status.Hooks
+}
+
+func TestExecHooks(t *testing.T) {
+	ignored := false
+	r, datastore := newHooksRunner(t,
+		configv1alpha1.Hook{Name: "lint", Phase: configv1alpha1.HookPhasePrePlan, Command: []string{"sh", "-c", "echo linting; exit 2"}, FailOnError: &ignored},
+		configv1alpha1.Hook{Name: "check", Phase: configv1alpha1.HookPhasePrePlan, Command: []string{"sh", "-c", "echo checking; exit 3"}},
+		configv1alpha1.Hook{Name: "never", Phase: configv1alpha1.HookPhasePrePlan, Command: []string{"true"}},
+		configv1alpha1.Hook{Name: "notify", Phase: configv1alpha1.HookPhasePostApply, Command: []string{"true"}},
+	)
+
+	if err := r.execHooks(configv1alpha1.HookPhasePrePlan); err == nil {
+		t.Fatal("expected a failing hook with failOnError to fail the phase")
+	}
+	reports := []HookReport{}
+	if err := json.Unmarshal(datastore.plans["hooks"], &reports); err != nil {
+		t.Fatalf("could not read the hook reports of the datastore: %s", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected the hooks following a failing hook not to run, got %+v", reports)
+	}
+	if reports[0].Name != "lint" || reports[0].ExitCode != 2 || reports[0].Output != "linting\n" {
+		t.Errorf("unexpected report of the ignored hook %+v", reports[0])
+	}
+	if reports[1].Name != "check" || reports[1].ExitCode != 3 || !reports[1].FailOnError {
+		t.Errorf("unexpected report of the failing hook %+v", reports[1])
+	}
+	hooks := getRunHooks(t, r)
+	if len(hooks) != 2 || hooks[1].Name != "check" || hooks[1].ExitCode != 3 {
+		t.Errorf("expected the hook results to be reported on the run, got %+v", hooks)
+	}
+}
+
+func TestExecPostApplyHooks(t *testing.T) {
+	r, _ := newHooksRunner(t,
+		configv1alpha1.Hook{Name: "notify", Phase: configv1alpha1.HookPhasePostApply, Command: []string{"false"}},
+	)
+
+	// A failing post-apply hook must not fail the run, the failure is only reported on the run
+	r.execPostApplyHooks()
+	hooks := getRunHooks(t, r)
+	if len(hooks) != 1 || hooks[0].Name != "notify" || hooks[0].ExitCode != 1 || !hooks[0].FailOnError {
+		t.Errorf("expected the failure of the post-apply hook to be reported on the run, got %+v", hooks)
+	}
+}
+
+func TestExecHookWithoutCommand(t *testing.T) {
+	r, _ := newHooksRunner(t)
+	report := r.execHook(configv1alpha1.Hook{Name: "empty", Phase: configv1alpha1.HookPhasePreInit})
+	if report.ExitCode != -1 {
+		t.Errorf("expected a hook without command to fail, got exit code %d", report.ExitCode)
+	}
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultCancelGracePeriod = 2 * time.Minute
+
+// Reasons of the interruption, the controller recognizes the timeouts by their termination message
+const (
+	cancelledReason string = "run cancelled"
+	timedOutReason  string = "run timed out"
+)
+
+// Returned by the steps of the runner which are not started once the runner has been interrupted
+var errInterrupted = errors.New("stopped before the next step")
+
+// The runner is interrupted when the controller deletes its pod to cancel the run, or when the
+// run exceeds its timeout. The running command receives an interrupt so that terraform can stop
+// cleanly and release the state lock. If it has not stopped after the grace period, the runner
+// uploads its logs and exits.
+func (r *Runner) handleInterrupts(streamer *logStreamer) {
+	gracePeriod := r.config.Runner.CancelGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultCancelGracePeriod
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	var timeout <-chan time.Time
+	if r.config.Runner.Timeout > 0 {
+		timeout = time.After(r.config.Runner.Timeout)
+	}
+	go func() {
+		select {
+		case sig := <-signals:
+			r.interruption.Store(cancelledReason)
+			log.Warnf("received %s, interrupting the running command (grace period %s)", sig, gracePeriod)
+		case <-timeout:
+			r.interruption.Store(fmt.Sprintf("%s after %s", timedOutReason, r.config.Runner.Timeout))
+			log.Errorf("the run has exceeded its timeout of %s, interrupting the running command (grace period %s)", r.config.Runner.Timeout, gracePeriod)
+		}
+		pids, err := c.InterruptChildren()
+		if err != nil {
+			log.Errorf("could not interrupt the running command: %s", err)
+		}
+		if len(pids) == 0 {
+			log.Infof("no command is running, the runner will stop before the next step")
+		}
+		time.Sleep(gracePeriod)
+		log.Errorf("the running command did not stop within %s, exiting", gracePeriod)
+		err = fmt.Errorf("%s: the running command did not stop within %s", r.getInterruption(), gracePeriod)
+		r.putResult(err)
+		streamer.Close()
+		WriteTerminationMessage(err)
+		os.Exit(1)
+	}()
+}
+
+// Returns why the runner has been interrupted, empty if it has not
+func (r *Runner) getInterruption() string {
+	reason, _ := r.interruption.Load().(string)
+	return reason
+}
+
+func (r *Runner) isInterrupted() bool {
+	return r.getInterruption() != ""
+}
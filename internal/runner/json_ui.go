@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"encoding/json"
+	"strconv"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+)
+
+// Resources reported on the status of a run, the status of a large apply would exceed the size
+// of a Kubernetes object otherwise
+const maxStatusResources = 100
+
+// When the JSON output is enabled, plan and apply write the machine-readable UI of terraform,
+// which is parsed to record their events. Returns the arguments to add to the command.
+func (r *Runner) startJSONUI() []string {
+	if !r.config.Runner.JSONOutput {
+		return nil
+	}
+	r.ui = runnerutils.NewJSONUIRecorder(c.Stdout())
+	r.exec.SetStdout(r.ui)
+	return []string{"-json"}
+}
+
+// Save the events recorded since startJSONUI in the datastore and return them. After an apply,
+// the progress of its resources is reported on the status of the run.
+func (r *Runner) endJSONUI(apply bool) []runnerutils.JSONEvent {
+	if r.ui == nil {
+		return nil
+	}
+	r.exec.SetStdout(nil)
+	if err := r.ui.Flush(); err != nil {
+		log.Errorf("could not write the last event of %s: %s", r.exec.TenvName(), err)
+	}
+	events := r.ui.Events()
+	r.ui = nil
+	content, err := json.Marshal(events)
+	if err != nil {
+		log.Errorf("could not marshal %s events: %s", r.exec.TenvName(), err)
+		return events
+	}
+	content, _ = r.getRedactor().Redact(content)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "events", content)
+	if err != nil {
+		log.Errorf("could not put %s events in datastore: %s", r.exec.TenvName(), err)
+	}
+	if !apply {
+		return events
+	}
+	resources, omitted := runnerutils.LimitResourceTimeline(runnerutils.GetResourceTimeline(events), maxStatusResources)
+	r.patchRunStatus("resource results", func(status *configv1alpha1.TerraformRunStatus) {
+		status.Resources = resources
+		status.ResourcesOmitted = omitted
+	})
+	return events
+}
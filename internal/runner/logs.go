@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultLogsStreamInterval = 5 * time.Second
+
+// Logs not uploaded yet are kept up to this size, only their end is kept past it
+const maxStreamedLogsSize = 10 << 20
+
+// Returned by the upload of logs before the attempt they belong to is known
+var errLogsAttemptUnknown = errors.New("the attempt of the logs is not known yet")
+
+// Start of a private key, which is only redacted whole
+var privateKeyStart = []byte("-----BEGIN ")
+
+// logStreamer keeps a copy of everything written by the runner and periodically
+// uploads what was written since the last upload to the datastore, as numbered parts,
+// so the logs of a run can be followed while it is running and are kept even if the
+// runner pod disappears before the controller uploads its logs.
+type logStreamer struct {
+	mu       sync.Mutex
+	pending  bytes.Buffer
+	upload   func(part int, final bool, content []byte) error
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	closed   sync.Once
+
+	// Number of the next part, and offset of the first pending byte in the logs
+	part   int
+	offset int
+
+	// Size above which the beginning of the pending logs is dropped, and number of bytes dropped
+	maxSize   int
+	truncated int
+}
+
+func newLogStreamer(interval time.Duration, upload func(part int, final bool, content []byte) error) *logStreamer {
+	if interval <= 0 {
+		interval = defaultLogsStreamInterval
+	}
+	return &logStreamer{
+		maxSize:  maxStreamedLogsSize,
+		upload:   upload,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *logStreamer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := s.pending.Write(p)
+	if excess := s.pending.Len() - s.maxSize; excess > 0 {
+		// Dropped up to the end of a line, so that no partial value escapes redaction
+		if i := bytes.IndexByte(s.pending.Bytes()[excess:], '\n'); i >= 0 {
+			excess += i + 1
+		}
+		s.pending.Next(excess)
+		s.offset += excess
+		s.truncated += excess
+	}
+	return n, err
+}
+
+// Start uploads the logs every interval until Close is called
+func (s *logStreamer) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(false)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the periodic uploads and uploads the remaining logs as the final part
+func (s *logStreamer) Close() {
+	s.closed.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.flush(true)
+	})
+}
+
+// Upload the pending logs as a new part. Only complete lines are uploaded before the final part,
+// and a private key is only uploaded once it ends, so that they are redacted whole.
+func (s *logStreamer) flush(final bool) {
+	s.mu.Lock()
+	size := s.pending.Len()
+	if !final {
+		size = streamableSize(s.pending.Bytes())
+	}
+	if size == 0 && !final {
+		s.mu.Unlock()
+		return
+	}
+	content := bytes.Clone(s.pending.Bytes()[:size])
+	truncated := s.truncated
+	if truncated > 0 {
+		content = append([]byte(fmt.Sprintf("[%d bytes of earlier logs truncated]\n", truncated)), content...)
+	}
+	part, end := s.part, s.offset+size
+	s.mu.Unlock()
+	err := s.upload(part, final, content)
+	if errors.Is(err, errLogsAttemptUnknown) {
+		return
+	}
+	if err != nil {
+		// Not logged with logrus, the message would be written to the streamer again
+		fmt.Fprintf(os.Stderr, "could not upload runner logs to the datastore: %s\n", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The beginning of the uploaded logs may have been dropped in the meantime
+	if end > s.offset {
+		s.pending.Next(end - s.offset)
+		s.offset = end
+	}
+	s.truncated -= truncated
+	s.part++
+}
+
+// Return the size of the beginning of logs made of complete lines, up to the start of a private key
+// which does not end yet
+func streamableSize(logs []byte) int {
+	size := bytes.LastIndexByte(logs, '\n') + 1
+	if start := bytes.LastIndex(logs[:size], privateKeyStart); start >= 0 && !bytes.Contains(logs[start:size], []byte("-----END ")) {
+		size = bytes.LastIndexByte(logs[:start], '\n') + 1
+	}
+	return size
+}
+
+// Upload a part of the logs of the current attempt
+func (r *Runner) uploadLogs(part int, final bool, content []byte) error {
+	if r.Run == nil || r.Datastore == nil {
+		return errLogsAttemptUnknown
+	}
+	content, _ = r.getRedactor().Redact(content)
+	return r.Datastore.PutLogsPart(r.Run.Namespace, r.Run.Spec.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), part, final, content)
+}
@@ -0,0 +1,26 @@
+package runner
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Capture the outputs of the layer after an apply and save them in the datastore, from which the
+// controllers publish the selected ones. The apply has already succeeded at this point, errors are
+// logged and do not fail the run.
+func (r *Runner) exportOutputs() {
+	if r.isStack() {
+		log.Infof("outputs of terragrunt stacks are not captured")
+		return
+	}
+	content, err := r.exec.Output()
+	if err != nil {
+		log.Errorf("could not get outputs of the layer: %s", err)
+		return
+	}
+	err = r.Datastore.PutOutputs(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), content)
+	if err != nil {
+		log.Errorf("could not put outputs in datastore: %s", err)
+	}
+}
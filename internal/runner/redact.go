@@ -0,0 +1,50 @@
+package runner
+
+import (
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/utils/redact"
+	log "github.com/sirupsen/logrus"
+)
+
+// Build the redactor of the runner from the configured patterns and the ones of the
+// redaction ConfigMap. Falls back to the default patterns if they cannot be loaded.
+func (r *Runner) initRedactor() {
+	patterns, err := redact.LoadPatterns(r.config.Runner.RedactionPath)
+	if err != nil {
+		log.Errorf("could not load redaction patterns, using the default ones: %s", err)
+	}
+	redactor, err := redact.New(append(r.config.Runner.RedactionPatterns, patterns...))
+	if err != nil {
+		log.Errorf("invalid redaction patterns, using the default ones: %s", err)
+		redactor, _ = redact.New(nil)
+	}
+	r.redactor = redactor
+}
+
+// Return the redactor of the runner, with the default patterns if it has not been initialized
+func (r *Runner) getRedactor() *redact.Redactor {
+	if r.redactor == nil {
+		r.redactor, _ = redact.New(nil)
+	}
+	return r.redactor
+}
+
+// Register the values of the variables and of the backend configuration taken from Secrets
+func (r *Runner) addSecretValues() {
+	addSecretValues(r.getRedactor(), configv1alpha1.GetVariables(r.Repository, r.Layer).Vars, r.config.Runner.VariablesPath)
+	addSecretValues(r.getRedactor(), configv1alpha1.GetBackendConfig(r.Repository, r.Layer), r.config.Runner.BackendConfigPath)
+}
+
+func addSecretValues(redactor *redact.Redactor, values []configv1alpha1.Variable, path string) {
+	for _, v := range values {
+		if v.ValueFrom == nil || v.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+		value, ok, err := readValue(v, path)
+		if err != nil || !ok {
+			// Reported when the arguments of the command are built
+			continue
+		}
+		redactor.AddValues(value)
+	}
+}
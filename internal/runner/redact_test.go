@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAddSecretValues(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api_key"), []byte("my-secret-api-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "region"), []byte("eu-west-3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := newApplyRunner(nil, nil)
+	r.config.Runner.VariablesPath = dir
+	r.Layer.Spec.Variables.Vars = []configv1alpha1.Variable{
+		{Name: "api_key", ValueFrom: &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "api-key"}}},
+		{Name: "region", ValueFrom: &configv1alpha1.VariableSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "region"}}},
+		{Name: "token", ValueFrom: &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}}},
+	}
+	r.addSecretValues()
+	content, _ := r.getRedactor().Redact([]byte("api_key=my-secret-api-key region=eu-west-3"))
+	if string(content) != "api_key=[REDACTED] region=eu-west-3" {
+		t.Errorf("expected only the values taken from Secrets to be redacted, got %q", content)
+	}
+}
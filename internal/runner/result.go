@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	tg "github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Exit reasons of the result of an attempt
+const (
+	ExitReasonSucceeded string = "Succeeded"
+	ExitReasonFailed    string = "Failed"
+	ExitReasonCancelled string = "Cancelled"
+	ExitReasonTimedOut  string = "TimedOut"
+	ExitReasonRefused   string = "Refused"
+)
+
+// Run a phase of the runner, recording its duration in the result of the attempt
+func (r *Runner) runPhase(name string, phase func() error) error {
+	start := time.Now()
+	err := phase()
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		result.Phases = append(result.Phases, configv1alpha1.PhaseResult{
+			Name:     name,
+			Duration: metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)},
+		})
+	})
+	return err
+}
+
+// The result is updated by the steps of the runner, and read when the runner is interrupted
+func (r *Runner) updateResult(update func(result *configv1alpha1.RunResult)) {
+	r.resultMutex.Lock()
+	defer r.resultMutex.Unlock()
+	update(&r.result)
+}
+
+func (r *Runner) setToolVersions() {
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		if terragrunt, ok := r.exec.(*tg.Terragrunt); ok {
+			result.Tool = terragrunt.ChildName
+			result.Version = terragrunt.ChildVersion
+			result.TerragruntVersion = terragrunt.Version
+			return
+		}
+		result.Tool = r.exec.TenvName()
+		result.Version = r.exec.GetVersion()
+	})
+}
+
+// Returns the changes of the plan applied by the run, from the summary stored with the plan
+func (r *Runner) getArtifactChanges() *configv1alpha1.ChangeCounts {
+	content, err := r.Datastore.GetPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, "summary")
+	if err != nil {
+		log.Warnf("could not get the summary of the applied plan: %s", err)
+		return nil
+	}
+	summary := runnerutils.PlanSummary{}
+	if err := json.Unmarshal(content, &summary); err != nil {
+		log.Warnf("could not parse the summary of the applied plan: %s", err)
+		return nil
+	}
+	counts := summary.ChangeCounts()
+	return &counts
+}
+
+// Report the changes made by an apply in its result and returns the short diff of the apply
+func (r *Runner) setApplyChanges(changes *configv1alpha1.ChangeCounts) string {
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		result.Apply = changes
+	})
+	if changes == nil {
+		return "Apply Successful"
+	}
+	return fmt.Sprintf("Apply complete! Resources: %d added, %d changed, %d destroyed.", changes.Add, changes.Change, changes.Destroy)
+}
+
+// Save the result of the attempt in the datastore, for the controller to report it on the status of the run
+func (r *Runner) putResult(err error) {
+	if r.Run == nil || r.Datastore == nil {
+		return
+	}
+	r.resultMutex.Lock()
+	defer r.resultMutex.Unlock()
+	r.result.Attempt = r.Run.Status.Retries
+	interruption := r.getInterruption()
+	switch {
+	case err == nil && r.result.ExitReason == "":
+		r.result.ExitReason = ExitReasonSucceeded
+	case err == nil:
+		// The reason has been set by the runner, an apply has been refused
+	case strings.HasPrefix(interruption, timedOutReason):
+		r.result.ExitReason = ExitReasonTimedOut
+		r.result.Message = err.Error()
+	case interruption != "":
+		r.result.ExitReason = ExitReasonCancelled
+		r.result.Message = err.Error()
+	default:
+		r.result.ExitReason = ExitReasonFailed
+		r.result.Message = err.Error()
+	}
+	content, marshalErr := json.Marshal(r.result)
+	if marshalErr != nil {
+		log.Errorf("could not marshal the result of the run: %s", marshalErr)
+		return
+	}
+	content, _ = r.getRedactor().Redact(content)
+	putErr := r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "result", content)
+	if putErr != nil {
+		log.Errorf("could not put the result of the run in datastore: %s", putErr)
+	}
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	"github.com/padok-team/burrito/internal/controllers/terraformrun"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Save the result of an attempt like the runner does when it exits, and read it back like the
+// controller does when it reconciles the run once the runner pod has finished
+func roundTripResult(t *testing.T, r *Runner, err error, phase corev1.PodPhase) *configv1alpha1.RunResult {
+	t.Helper()
+	r.putResult(err)
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-layer-apply-abcde-runner", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+	reconciler := &terraformrun.Reconciler{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build(),
+		Config:    &config.Config{},
+		Datastore: r.Datastore,
+	}
+	return reconciler.GetRunResult(r.Run, terraformrun.RunInfo{Retries: r.Run.Status.Retries, RunnerPod: pod.Name})
+}
+
+func TestResultRoundTrip(t *testing.T) {
+	r := newApplyRunner(nil, nil)
+	r.Run.Status.Retries = 2
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		result.Tool = "terraform"
+		result.Version = "1.9.0"
+		result.Phases = []configv1alpha1.PhaseResult{{Name: "apply", Duration: metav1.Duration{Duration: 3 * time.Second}}}
+	})
+	r.setApplyChanges(&configv1alpha1.ChangeCounts{Add: 1, Change: 2})
+	result := roundTripResult(t, r, nil, corev1.PodSucceeded)
+	if result == nil {
+		t.Fatal("expected the result saved by the runner to be reported on the status of the run")
+	}
+	if result.Attempt != 2 || result.ExitReason != ExitReasonSucceeded || result.Tool != "terraform" || result.Version != "1.9.0" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(result.Phases) != 1 || result.Phases[0].Duration.Duration != 3*time.Second {
+		t.Errorf("unexpected phases %+v", result.Phases)
+	}
+	if result.Apply == nil || *result.Apply != (configv1alpha1.ChangeCounts{Add: 1, Change: 2}) {
+		t.Errorf("unexpected changes of the apply %+v", result.Apply)
+	}
+
+	r = newApplyRunner(nil, nil)
+	r.config.Runner.RedactionPatterns = []string{`internal-token-[0-9]+`}
+	r.initRedactor()
+	result = roundTripResult(t, r, errors.New("could not authenticate with internal-token-1234"), corev1.PodFailed)
+	if result == nil || result.ExitReason != ExitReasonFailed || result.Message != "could not authenticate with [REDACTED]" {
+		t.Errorf("expected the redacted error of a failed attempt to be reported, got %+v", result)
+	}
+
+	r = newApplyRunner(nil, nil)
+	// As set by refuseApply
+	r.updateResult(func(result *configv1alpha1.RunResult) {
+		result.ExitReason = ExitReasonRefused
+		result.Message = "Apply refused: stale plan"
+	})
+	result = roundTripResult(t, r, nil, corev1.PodSucceeded)
+	if result == nil || result.ExitReason != ExitReasonRefused || result.Message != "Apply refused: stale plan" {
+		t.Errorf("expected a refused apply to be reported, got %+v", result)
+	}
+
+	r = newApplyRunner(nil, nil)
+	if result := roundTripResult(t, r, nil, corev1.PodRunning); result != nil {
+		t.Errorf("expected no result to be reported while the runner pod is running, got %+v", result)
+	}
+}
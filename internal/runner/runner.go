@@ -3,16 +3,21 @@ package runner
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
 	"github.com/padok-team/burrito/internal/runner/tools"
 	"github.com/padok-team/burrito/internal/utils"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	"github.com/padok-team/burrito/internal/utils/redact"
 	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/types"
@@ -29,6 +34,15 @@ type Runner struct {
 	Repository *configv1alpha1.TerraformRepository
 	repoDir    string
 	workingDir string
+	redactor   *redact.Redactor
+	hooks      []HookReport
+	// Events of the machine-readable UI of the running plan or apply, see startJSONUI
+	ui *runnerutils.JSONUIRecorder
+	// Reason why the runner has been interrupted, see handleInterrupts
+	interruption atomic.Value
+	// Result of the attempt, saved in the datastore when the runner exits
+	result      configv1alpha1.RunResult
+	resultMutex sync.Mutex
 }
 
 func New(c *config.Config) *Runner {
@@ -38,26 +52,81 @@ func New(c *config.Config) *Runner {
 }
 
 // Entrypoint function of the runner. Initializes the runner and executes its action.
-func (r *Runner) Exec() error {
-	err := r.initClients()
+func (r *Runner) Exec() (err error) {
+	r.initRedactor()
+	streamer := newLogStreamer(r.config.Runner.LogsStreamInterval, r.uploadLogs)
+	log.SetOutput(io.MultiWriter(os.Stderr, streamer))
+	c.Tee(streamer)
+	streamer.Start()
+	defer streamer.Close()
+	r.handleInterrupts(streamer)
+	defer func() {
+		if err != nil && r.isInterrupted() {
+			err = fmt.Errorf("%s: %w", r.getInterruption(), err)
+		}
+		r.putResult(err)
+	}()
+
+	err = r.initClients()
 	if err != nil {
 		log.Errorf("error initializing runner clients: %s", err)
 		return err
 	}
 
-	err = r.Init()
+	err = r.runPhase("setup", r.Init)
 	if err != nil {
 		log.Errorf("error initializing runner: %s", err)
 		return err
 	}
+	r.setToolVersions()
+
+	err = r.runPhase("init", func() error {
+		if r.config.Runner.PluginCache.Enabled {
+			r.linkPluginCache()
+		}
+
+		err := r.execHooks(configv1alpha1.HookPhasePreInit)
+		if err != nil {
+			log.Errorf("error executing pre-init hooks: %s", err)
+			return err
+		}
+
+		if r.isInterrupted() {
+			return errInterrupted
+		}
+		err = r.ExecInit()
+		if err != nil {
+			log.Errorf("error executing init: %s", err)
+			return err
+		}
+
+		if r.config.Runner.PluginCache.Enabled {
+			r.publishPluginCache()
+		}
+
+		err = r.execHooks(configv1alpha1.HookPhasePostInit)
+		if err != nil {
+			log.Errorf("error executing post-init hooks: %s", err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-	err = r.ExecInit()
+	if r.isInterrupted() {
+		return errInterrupted
+	}
+	err = r.runPhase("workspace", r.ExecWorkspace)
 	if err != nil {
-		log.Errorf("error executing init: %s", err)
+		log.Errorf("error selecting workspace: %s", err)
 		return err
 	}
 
-	return r.ExecAction()
+	if r.isInterrupted() {
+		return errInterrupted
+	}
+	return r.runPhase(r.config.Runner.Action, r.ExecAction)
 }
 
 // Initialize the runner clients (kubernetes, datastore).
@@ -84,6 +153,7 @@ func (r *Runner) Init() error {
 		log.Errorf("error getting kubernetes resources: %s", err)
 		return err
 	}
+	r.addSecretValues()
 
 	r.repoDir = filepath.Join(r.config.Runner.RepositoryPath, "content")
 	r.workingDir = filepath.Join(r.repoDir, r.Layer.Spec.Path)
@@ -95,7 +165,7 @@ func (r *Runner) Init() error {
 	}
 
 	log.Infof("installing binaries...")
-	r.exec, err = tools.InstallBinaries(r.Layer, r.Repository, r.config.Runner.RunnerBinaryPath, r.workingDir)
+	r.exec, err = tools.InstallBinaries(r.Layer, r.Repository, r.config.Runner.RunnerBinaryPath, r.workingDir, r.config.Runner.ToolsMirror)
 	if err != nil {
 		log.Errorf("error installing binaries: %s", err)
 		return err
@@ -119,6 +189,44 @@ func (r *Runner) EnableHermitcrab() error {
 	return err
 }
 
+// Link the providers of the shared plugin cache in the plugin cache of the runner.
+// Failing to use the shared cache is not an error, providers are downloaded instead.
+func (r *Runner) linkPluginCache() {
+	localDir := os.Getenv("TF_PLUGIN_CACHE_DIR")
+	if localDir == "" {
+		log.Warnf("plugin cache is enabled but TF_PLUGIN_CACHE_DIR is not set, ignoring shared plugin cache")
+		return
+	}
+	err := runnerutils.LinkPluginCache(r.config.Runner.PluginCache.Path, localDir)
+	if err != nil {
+		log.Errorf("error linking shared plugin cache: %s", err)
+	}
+}
+
+// Publish the providers downloaded during init in the shared plugin cache, and remove
+// the ones which have not been used for the configured max age.
+func (r *Runner) publishPluginCache() {
+	localDir := os.Getenv("TF_PLUGIN_CACHE_DIR")
+	if localDir == "" {
+		return
+	}
+	sharedDir := r.config.Runner.PluginCache.Path
+	err := runnerutils.PublishPluginCache(localDir, sharedDir)
+	if err != nil {
+		log.Errorf("error publishing providers to the shared plugin cache: %s", err)
+		return
+	}
+	err = runnerutils.TouchUsedPluginCache(r.workingDir, sharedDir)
+	if err != nil {
+		log.Errorf("error marking shared plugin cache providers as used: %s", err)
+		return
+	}
+	err = runnerutils.GarbageCollectPluginCache(sharedDir, r.config.Runner.PluginCache.MaxAge)
+	if err != nil {
+		log.Errorf("error cleaning up shared plugin cache: %s", err)
+	}
+}
+
 // Retrieve linked resources (layer, run, repository) from the Kubernetes API.
 func (r *Runner) GetResources() error {
 	layer := &configv1alpha1.TerraformLayer{}
@@ -196,3 +304,31 @@ func (r *Runner) cloneGitBundle() error {
 
 	return nil
 }
+
+// Path of the termination message of the runner container, reported in the status of the run
+const TerminationMessagePath = "/dev/termination-log"
+
+// Kubernetes truncates termination messages to 4096 bytes
+const maxTerminationMessageLength = 4096
+
+// Write the error which made the runner fail as its termination message
+func WriteTerminationMessage(err error) {
+	message := err.Error()
+	if len(message) > maxTerminationMessageLength {
+		message = message[:maxTerminationMessageLength]
+	}
+	if writeErr := os.WriteFile(TerminationMessagePath, []byte(message), 0644); writeErr != nil {
+		log.Debugf("could not write termination message: %s", writeErr)
+	}
+}
+
+// Update the status of the run with the results reported by the runner. The controller
+// keeps them when it updates the status, failing to report them is not an error.
+func (r *Runner) patchRunStatus(description string, mutate func(status *configv1alpha1.TerraformRunStatus)) {
+	patch := client.MergeFrom(r.Run.DeepCopy())
+	mutate(&r.Run.Status)
+	err := r.Client.Status().Patch(context.TODO(), r.Run, patch)
+	if err != nil {
+		log.Errorf("could not update the %s of the TerraformRun: %s", description, err)
+	}
+}
@@ -0,0 +1,378 @@
+package runner
+
+import (
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/datastore/storage"
+	tg "github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+	"github.com/padok-team/burrito/internal/utils/policy"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the plan file written by terragrunt in each unit of a stack. It is relative to the
+// directory terraform runs in, which is in the terragrunt cache when the unit has a source.
+const StackPlanFile string = "burrito.tfplan"
+
+// Directory where the plan artifacts of the units are written before an apply
+const StackPlanArtifactsDir string = "/tmp/stack"
+
+const (
+	UnitStatePlanned string = "Planned"
+	UnitStateApplied string = "Applied"
+	UnitStateFailed  string = "Failed"
+	UnitStateSkipped string = "Skipped"
+)
+
+// Return true if the layer is the root of a terragrunt stack
+func (r *Runner) isStack() bool {
+	return configv1alpha1.GetTerragruntStackEnabled(r.Repository, r.Layer)
+}
+
+// Return the terragrunt executor of the stack, running at the root of the layer
+func (r *Runner) getStack() (*tg.Terragrunt, error) {
+	stack, ok := r.exec.(*tg.Terragrunt)
+	if !ok {
+		return nil, errors.New("terragrunt must be enabled to run a terragrunt stack")
+	}
+	stack.WorkingDir = r.workingDir
+	return stack, nil
+}
+
+// Run a run-all `init` command on the stack
+func (r *Runner) execStackInit(args ...string) error {
+	stack, err := r.getStack()
+	if err != nil {
+		return err
+	}
+	log.Infof("launching terragrunt run-all init in %s", r.workingDir)
+	err = stack.RunAll("init", args...)
+	if err != nil {
+		log.Errorf("error executing terragrunt run-all init: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Run a run-all `plan` command on the stack and save the plan artifacts of each unit in the
+// datastore, as well as an aggregated summary of the plans of the stack
+func (r *Runner) execStackPlan(extraArgs ...string) (*planResult, error) {
+	stack, err := r.getStack()
+	if err != nil {
+		return nil, err
+	}
+	units, err := tg.DiscoverUnits(r.workingDir)
+	if err != nil {
+		log.Errorf("error discovering terragrunt units: %s", err)
+		return nil, err
+	}
+	log.Infof("found %d terragrunt units in the stack", len(units))
+	args, err := r.getVariablesArgs()
+	if err != nil {
+		log.Errorf("error computing terragrunt variables: %s", err)
+		return nil, err
+	}
+	policies, err := r.loadPolicies()
+	if err != nil {
+		log.Errorf("error loading policies: %s", err)
+		return nil, err
+	}
+	log.Infof("running terragrunt run-all plan")
+	planErr := stack.RunAll("plan", append([]string{fmt.Sprintf("-out=%s", StackPlanFile)}, append(extraArgs, args...)...)...)
+	if planErr != nil {
+		log.Errorf("error executing terragrunt run-all plan: %s", planErr)
+	}
+
+	results := []configv1alpha1.UnitResult{}
+	summaries := map[string]runnerutils.PlanSummary{}
+	reports := map[string]*policy.Report{}
+	prettyPlans := []string{}
+	bins := map[string][]byte{}
+	redactions := 0
+	for _, unit := range units {
+		result := configv1alpha1.UnitResult{Path: unit.Path, State: UnitStateFailed}
+		plan, err := r.execUnitPlan(stack.ForUnit(unit.Path), unit.Path, policies)
+		if err != nil {
+			// Units without a plan file were not planned because of the failure of the run-all plan
+			if planErr == nil {
+				log.Errorf("error getting plan of unit %s: %s", unit.Path, err)
+				r.setUnitResults(append(results, result))
+				return nil, err
+			}
+			results = append(results, result)
+			continue
+		}
+		result.State = UnitStatePlanned
+		result.Diff = plan.summary.ShortDiff()
+		results = append(results, result)
+		summaries[unit.Path] = plan.summary
+		reports[unit.Path] = plan.policy
+		prettyPlans = append(prettyPlans, fmt.Sprintf("# %s\n\n%s", unit.Path, plan.pretty))
+		bins[unit.Path] = plan.bin
+		redactions += plan.redactions
+	}
+	r.setUnitResults(results)
+	if planErr != nil {
+		return nil, planErr
+	}
+
+	summary := runnerutils.MergePlanSummaries(summaries)
+	shortDiff := summary.ShortDiff()
+	if r.Run.Spec.PlanOptions.IsPartial() {
+		shortDiff = fmt.Sprintf("Partial %s", shortDiff)
+	}
+	r.putStackPlan("pretty", []byte(strings.Join(prettyPlans, "\n")))
+	r.putStackPlan("short", []byte(shortDiff))
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		log.Errorf("error computing plan summary: %s", err)
+		return nil, err
+	}
+	r.putStackPlan("summary", summaryBytes)
+	report, err := r.putStackPolicyReport(units, reports)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("terragrunt run-all plan ran successfully, %d values redacted", redactions)
+	return &planResult{
+		sum:        getStackSum(units, bins),
+		summary:    summary,
+		policy:     report,
+		redactions: redactions,
+	}, nil
+}
+
+type unitPlan struct {
+	pretty     []byte
+	bin        []byte
+	summary    runnerutils.PlanSummary
+	policy     *policy.Report
+	redactions int
+}
+
+// Read the plan written by the run-all plan in a unit and save its artifacts in the datastore
+func (r *Runner) execUnitPlan(unitExec *tg.Terragrunt, unit string, policies []policy.Policy) (*unitPlan, error) {
+	planFile, err := unitExec.FindPlanFile(StackPlanFile)
+	if err != nil {
+		return nil, err
+	}
+	planJsonBytes, err := unitExec.Show(planFile, "json")
+	if err != nil {
+		return nil, err
+	}
+	prettyPlan, err := unitExec.Show(planFile, "pretty")
+	if err != nil {
+		return nil, err
+	}
+	plan := &tfjson.Plan{}
+	err = json.Unmarshal(planJsonBytes, plan)
+	if err != nil {
+		return nil, err
+	}
+	if r.config.Runner.StoreUnredacted {
+		r.putUnredactedPlan(storage.UnitPlanFormat(unit, "pretty"), prettyPlan)
+		r.putUnredactedPlan(storage.UnitPlanFormat(unit, "json"), planJsonBytes)
+	}
+	r.getRedactor().AddSensitiveValues(plan)
+	prettyPlan, prettyRedactions := r.getRedactor().Redact(prettyPlan)
+	planJsonBytes, jsonRedactions := r.getRedactor().Redact(planJsonBytes)
+	summary := runnerutils.GetPlanSummary(plan)
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	planBin, err := os.ReadFile(planFile)
+	if err != nil {
+		return nil, err
+	}
+	r.putStackPlan(storage.UnitPlanFormat(unit, "pretty"), prettyPlan)
+	r.putStackPlan(storage.UnitPlanFormat(unit, "json"), planJsonBytes)
+	r.putStackPlan(storage.UnitPlanFormat(unit, "short"), []byte(summary.ShortDiff()))
+	r.putStackPlan(storage.UnitPlanFormat(unit, "summary"), summaryBytes)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), storage.UnitPlanFormat(unit, "bin"), planBin)
+	if err != nil {
+		log.Errorf("could not put plan binary of unit %s in datastore: %s", unit, err)
+		return nil, err
+	}
+	var report *policy.Report
+	if policies != nil {
+		evaluated, err := policy.Evaluate(policies, plan)
+		if err != nil {
+			return nil, err
+		}
+		report = &evaluated
+	}
+	log.Infof("plan of unit %s: %s", unit, summary.ShortDiff())
+	return &unitPlan{
+		pretty:     prettyPlan,
+		bin:        planBin,
+		summary:    summary,
+		policy:     report,
+		redactions: prettyRedactions + jsonRedactions,
+	}, nil
+}
+
+// Save an artifact of the plan of the stack in the datastore, failing to do so is not an error
+func (r *Runner) putStackPlan(format string, content []byte) {
+	err := r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), format, content)
+	if err != nil {
+		log.Errorf("could not put %s plan in datastore: %s", format, err)
+	}
+}
+
+// Merge the policy reports of the units and save the result in the datastore
+func (r *Runner) putStackPolicyReport(units []tg.Unit, reports map[string]*policy.Report) (*policy.Report, error) {
+	if len(configv1alpha1.GetPolicies(r.Repository, r.Layer).ConfigMaps) == 0 {
+		return nil, nil
+	}
+	merged := policy.Report{Passed: true, Policies: []string{}, Violations: []policy.Violation{}}
+	for _, unit := range units {
+		report := reports[unit.Path]
+		merged.Passed = merged.Passed && report.Passed
+		merged.Policies = report.Policies
+		for _, violation := range report.Violations {
+			violation.Address = strings.TrimSuffix(fmt.Sprintf("%s/%s", unit.Path, violation.Address), "/")
+			log.Warnf("policy %s violated by %s: %s", violation.Policy, violation.Address, violation.Message)
+			merged.Violations = append(merged.Violations, violation)
+		}
+	}
+	reportBytes, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "policy", reportBytes)
+	if err != nil {
+		log.Errorf("could not put policy report in datastore: %s", err)
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// Apply the plans of the units of the stack one by one, following the dependency order.
+// The units following a unit which failed to apply are skipped.
+// Returns the sum of the plan artifacts used
+func (r *Runner) execStackApply() (string, error) {
+	stack, err := r.getStack()
+	if err != nil {
+		return "", err
+	}
+	units, err := tg.DiscoverUnits(r.workingDir)
+	if err != nil {
+		log.Errorf("error discovering terragrunt units: %s", err)
+		return "", err
+	}
+	withoutArtifact := configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer)
+	var args []string
+	if withoutArtifact {
+		log.Infof("applying without reusing plan artifacts from previous plan run")
+		args, err = r.getApplyWithoutArtifactArgs()
+		if err != nil {
+			log.Errorf("error computing terragrunt apply arguments: %s", err)
+			return "", err
+		}
+	}
+	bins, err := r.getStackPlans(units)
+	if err != nil {
+		return "", err
+	}
+	jsonArgs := r.startJSONUI()
+	results := []configv1alpha1.UnitResult{}
+	var applyErr error
+	for _, unit := range units {
+		result := configv1alpha1.UnitResult{Path: unit.Path, State: UnitStateSkipped}
+		diff, err := r.Datastore.GetPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, storage.UnitPlanFormat(unit.Path, "short"))
+		if err == nil {
+			result.Diff = string(diff)
+		}
+		if applyErr != nil {
+			results = append(results, result)
+			continue
+		}
+		artifact := filepath.Join(StackPlanArtifactsDir, fmt.Sprintf("%x.out", sha256.Sum256([]byte(unit.Path))))
+		err = os.MkdirAll(StackPlanArtifactsDir, 0755)
+		if err == nil {
+			err = os.WriteFile(artifact, bins[unit.Path], 0644)
+		}
+		if err == nil {
+			log.Infof("launching terragrunt apply in unit %s", unit.Path)
+			if withoutArtifact {
+				err = stack.ForUnit(unit.Path).Apply("", append(args, jsonArgs...)...)
+			} else {
+				err = stack.ForUnit(unit.Path).Apply(artifact, jsonArgs...)
+			}
+		}
+		if err != nil {
+			log.Errorf("error applying unit %s, skipping the remaining units: %s", unit.Path, err)
+			result.State = UnitStateFailed
+			applyErr = fmt.Errorf("could not apply unit %s: %w", unit.Path, err)
+		} else {
+			result.State = UnitStateApplied
+		}
+		results = append(results, result)
+	}
+	events := r.endJSONUI(true)
+	r.setUnitResults(results)
+	if applyErr != nil {
+		return "", applyErr
+	}
+	changes := runnerutils.GetAppliedChanges(events)
+	if changes == nil && !withoutArtifact {
+		changes = r.getArtifactChanges()
+	}
+	r.putStackPlan("short", []byte(r.setApplyChanges(changes)))
+	log.Infof("terragrunt apply of %d units ran successfully", len(units))
+	return getStackSum(units, bins), nil
+}
+
+// Fetch the plan artifacts of all the units before any of them is applied. The stack apply is
+// refused as a whole if an artifact is missing or if the artifacts are not the ones of the last plan,
+// so that a stack is never partially applied from a stale or incomplete plan.
+func (r *Runner) getStackPlans(units []tg.Unit) (map[string][]byte, error) {
+	bins := map[string][]byte{}
+	missing := []string{}
+	for _, unit := range units {
+		log.Infof("getting plan binary of unit %s in datastore", unit.Path)
+		plan, err := r.Datastore.GetPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, storage.UnitPlanFormat(unit.Path, "bin"))
+		if err != nil {
+			log.Errorf("could not get plan artifact of unit %s: %s", unit.Path, err)
+			missing = append(missing, unit.Path)
+			continue
+		}
+		bins[unit.Path] = plan
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: the plan artifacts of units %s could not be fetched", errStalePlan, strings.Join(missing, ", "))
+	}
+	if err := r.checkPlanSum(getStackSum(units, bins)); err != nil {
+		return nil, err
+	}
+	return bins, nil
+}
+
+// Report the results of the units on the status of the run
+func (r *Runner) setUnitResults(results []configv1alpha1.UnitResult) {
+	r.patchRunStatus("unit results", func(status *configv1alpha1.TerraformRunStatus) {
+		status.Units = results
+	})
+}
+
+// The sum of a stack is computed from the sums of the plan artifacts of its units
+func getStackSum(units []tg.Unit, bins map[string][]byte) string {
+	hash := sha256.New()
+	for _, unit := range units {
+		sum := sha256.Sum256(bins[unit.Path])
+		hash.Write([]byte(unit.Path))
+		hash.Write(sum[:])
+	}
+	return b64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
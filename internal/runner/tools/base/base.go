@@ -2,6 +2,7 @@ package base
 
 import (
 	"errors"
+	"io"
 	"os/exec"
 
 	c "github.com/padok-team/burrito/internal/utils/cmd"
@@ -12,15 +13,18 @@ type BaseTool struct {
 	ExecPath   string
 	WorkingDir string
 	ToolName   string // "terraform" or "tofu"
+	Version    string
+	// Standard output of plan and apply, the verbose output by default
+	Stdout io.Writer
 }
 
 func (t *BaseTool) TenvName() string {
 	return t.ToolName
 }
 
-func (t *BaseTool) Init(workingDir string) error {
+func (t *BaseTool) Init(workingDir string, args ...string) error {
 	t.WorkingDir = workingDir
-	cmd := exec.Command(t.ExecPath, "init", "-upgrade")
+	cmd := exec.Command(t.ExecPath, append([]string{"init", "-upgrade"}, args...)...)
 	c.Verbose(cmd)
 	cmd.Dir = workingDir
 	if err := cmd.Run(); err != nil {
@@ -29,8 +33,15 @@ func (t *BaseTool) Init(workingDir string) error {
 	return nil
 }
 
-func (t *BaseTool) Plan(planArtifactPath string) error {
-	cmd := exec.Command(t.ExecPath, "plan", "-out", planArtifactPath)
+// SelectWorkspace selects the given workspace, creating it if it does not exist yet
+func (t *BaseTool) SelectWorkspace(workspace string) error {
+	cmd := exec.Command(t.ExecPath, "workspace", "select", workspace)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := cmd.Run(); err == nil {
+		return nil
+	}
+	cmd = exec.Command(t.ExecPath, "workspace", "new", workspace)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
 	if err := cmd.Run(); err != nil {
@@ -39,14 +50,24 @@ func (t *BaseTool) Plan(planArtifactPath string) error {
 	return nil
 }
 
-func (t *BaseTool) Apply(planArtifactPath string) error {
-	var cmd *exec.Cmd
+func (t *BaseTool) Plan(planArtifactPath string, args ...string) error {
+	options := append([]string{"plan", "-out", planArtifactPath}, args...)
+	cmd := exec.Command(t.ExecPath, options...)
+	c.VerboseTo(cmd, t.Stdout)
+	cmd.Dir = t.WorkingDir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BaseTool) Apply(planArtifactPath string, args ...string) error {
+	options := append([]string{"apply", "-auto-approve"}, args...)
 	if planArtifactPath != "" {
-		cmd = exec.Command(t.ExecPath, "apply", "-auto-approve", planArtifactPath)
-	} else {
-		cmd = exec.Command(t.ExecPath, "apply", "-auto-approve")
+		options = append(options, planArtifactPath)
 	}
-	c.Verbose(cmd)
+	cmd := exec.Command(t.ExecPath, options...)
+	c.VerboseTo(cmd, t.Stdout)
 	cmd.Dir = t.WorkingDir
 	if err := cmd.Run(); err != nil {
 		return err
@@ -73,6 +94,21 @@ func (t *BaseTool) Show(planArtifactPath, mode string) ([]byte, error) {
 	return out, nil
 }
 
+// Output returns the outputs of the layer in JSON, sensitive values included
+func (t *BaseTool) Output() ([]byte, error) {
+	cmd := exec.Command(t.ExecPath, "output", "-no-color", "-json")
+	cmd.Dir = t.WorkingDir
+	return cmd.Output()
+}
+
+func (t *BaseTool) SetStdout(w io.Writer) {
+	t.Stdout = w
+}
+
+func (t *BaseTool) GetVersion() string {
+	return t.Version
+}
+
 func (t *BaseTool) GetExecPath() string {
 	return t.ExecPath
 }
@@ -1,10 +1,16 @@
 package tools
 
+import "io"
+
 type BaseExec interface {
-	Init(string) error
-	Plan(string) error
-	Apply(string) error
+	Init(string, ...string) error
+	SelectWorkspace(string) error
+	Plan(string, ...string) error
+	Apply(string, ...string) error
 	Show(string, string) ([]byte, error)
+	Output() ([]byte, error)
+	SetStdout(io.Writer)
 	TenvName() string
+	GetVersion() string
 	GetExecPath() string
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2/hclparse"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/runner/tools/integrity"
 	ot "github.com/padok-team/burrito/internal/runner/tools/opentofu"
 	tf "github.com/padok-team/burrito/internal/runner/tools/terraform"
 	tg "github.com/padok-team/burrito/internal/runner/tools/terragrunt"
@@ -19,8 +20,9 @@ import (
 
 type tenvWrapper = versionmanager.VersionManager
 
-// Creates a `tenv` wrapper for the given tool (Terraform/Terragrunt/OpenTofu)
-func newTenvWrapper(binaryPath string, toolName string) (*tenvWrapper, error) {
+// Creates a `tenv` wrapper for the given tool (Terraform/Terragrunt/OpenTofu),
+// listing its versions from the tools mirror if one is set
+func newTenvWrapper(binaryPath string, toolName string, mirror string) (*tenvWrapper, error) {
 	conf, err := tenvconfig.InitConfigFromEnv()
 	if err != nil {
 		return nil, err
@@ -30,14 +32,17 @@ func newTenvWrapper(binaryPath string, toolName string) (*tenvWrapper, error) {
 	conf.InitDisplayer(true)
 	hclParser := hclparse.NewParser()
 	versionManager := builder.Builders[toolName](&conf, hclParser)
+	if mirror != "" {
+		versionManager = withMirror(versionManager, hclParser, toolName, mirror, binaryPath)
+	}
 
 	return &versionManager, nil
 }
 
 // detect if the tool is already installed and compatible with the version constraint
 // Return the version of the tool found locally, or the version to install
-func detect(binaryPath, toolName, versionConstraint string) (string, error) {
-	tenvWrapper, err := newTenvWrapper(binaryPath, toolName)
+func detect(binaryPath, toolName, versionConstraint, mirror string) (string, error) {
+	tenvWrapper, err := newTenvWrapper(binaryPath, toolName, mirror)
 	if err != nil {
 		return "", err
 	}
@@ -57,23 +62,41 @@ func detect(binaryPath, toolName, versionConstraint string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	// TODO: check integrity of local version!
+	// The integrity of the local version is checked when installing it
 	log.Infof("found compatible %s version %s already installed", toolName, version)
 
 	return version, nil
 }
 
-// install the tool with the given version, do noting if already installed
-func install(binaryPath, toolName, version string) error {
-	tenvWrapper, err := newTenvWrapper(binaryPath, toolName)
+// Directories and binary names of the tools, following the layout of tenv
+var toolPaths = map[string][2]string{
+	"terraform":  {"Terraform", "terraform"},
+	"tofu":       {"OpenTofu", "tofu"},
+	"terragrunt": {"Terragrunt", "terragrunt"},
+}
+
+// Directory of binaryPath caching the artifacts used to verify the binaries
+const integrityCacheDir = ".integrity"
+
+// install the tool with the given version from its upstream release, or from the tools mirror if one is set,
+// after checking the release checksums and signature. An installed binary which does not match the release is reinstalled.
+func install(binaryPath, toolName, version, mirror string) error {
+	getRelease := integrity.GetRelease
+	if mirror != "" {
+		getRelease = integrity.Mirror(mirror).GetRelease
+	}
+	release, err := getRelease(toolName, version)
 	if err != nil {
 		return err
 	}
-	return tenvWrapper.Install(context.TODO(), version)
+	paths := toolPaths[toolName]
+	verifier := integrity.New(filepath.Join(binaryPath, integrityCacheDir))
+	return verifier.Install(release, filepath.Join(binaryPath, paths[0], version, paths[1]))
 }
 
-// If not already on the system, install Terraform and, if needed, Terragrunt binaries
-func InstallBinaries(layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository, binaryPath, workingDir string) (BaseExec, error) {
+// If not already on the system, install Terraform and, if needed, Terragrunt binaries.
+// If mirror is set, versions are resolved and installed from it instead of the upstream releases.
+func InstallBinaries(layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository, binaryPath, workingDir, mirror string) (BaseExec, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		log.Errorf("error getting current working directory: %s", err)
@@ -94,32 +117,32 @@ func InstallBinaries(layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.
 	var baseExec BaseExec
 	var baseExecVersion string
 	if configv1alpha1.GetTerraformEnabled(repo, layer) {
-		baseExecVersion, err = detect(binaryPath, "terraform", configv1alpha1.GetTerraformVersion(repo, layer))
+		baseExecVersion, err = detect(binaryPath, "terraform", configv1alpha1.GetTerraformVersion(repo, layer), mirror)
 		if err != nil {
 			return nil, err
 		}
-		baseExec = tf.NewTerraform(filepath.Join(binaryPath, "Terraform", baseExecVersion, "terraform"))
+		baseExec = tf.NewTerraform(filepath.Join(binaryPath, "Terraform", baseExecVersion, "terraform"), baseExecVersion)
 	} else if configv1alpha1.GetOpenTofuEnabled(repo, layer) {
-		baseExecVersion, err = detect(binaryPath, "tofu", configv1alpha1.GetOpenTofuVersion(repo, layer))
+		baseExecVersion, err = detect(binaryPath, "tofu", configv1alpha1.GetOpenTofuVersion(repo, layer), mirror)
 		if err != nil {
 			return nil, err
 		}
-		baseExec = ot.NewOpenTofu(filepath.Join(binaryPath, "OpenTofu", baseExecVersion, "tofu"))
+		baseExec = ot.NewOpenTofu(filepath.Join(binaryPath, "OpenTofu", baseExecVersion, "tofu"), baseExecVersion)
 	} else {
 		return nil, errors.New("Please enable either Terraform or OpenTofu in the repository or layer configuration")
 	}
 	log.Infof("using %s version %s", baseExec.TenvName(), baseExecVersion)
 
-	if err := install(binaryPath, baseExec.TenvName(), baseExecVersion); err != nil {
+	if err := install(binaryPath, baseExec.TenvName(), baseExecVersion, mirror); err != nil {
 		return nil, err
 	}
 	if configv1alpha1.GetTerragruntEnabled(repo, layer) {
 		terragruntVersion := configv1alpha1.GetTerragruntVersion(repo, layer)
-		terragruntVersion, err := detect(binaryPath, "terragrunt", terragruntVersion)
+		terragruntVersion, err := detect(binaryPath, "terragrunt", terragruntVersion, mirror)
 		if err != nil {
 			return nil, err
 		}
-		if err := install(binaryPath, "terragrunt", terragruntVersion); err != nil {
+		if err := install(binaryPath, "terragrunt", terragruntVersion, mirror); err != nil {
 			return nil, err
 		}
 		log.Infof("using Terragrunt version %s as wrapper for %s", terragruntVersion, baseExec.TenvName())
@@ -127,6 +150,8 @@ func InstallBinaries(layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.
 			ExecPath:      filepath.Join(binaryPath, "Terragrunt", terragruntVersion, "terragrunt"),
 			ChildExecPath: baseExec.GetExecPath(),
 			Version:       terragruntVersion,
+			ChildName:     baseExec.TenvName(),
+			ChildVersion:  baseExecVersion,
 		}, nil
 	}
 	return baseExec, nil
@@ -0,0 +1,347 @@
+package integrity
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// HashiCorp signing key, see https://www.hashicorp.com/security
+//
+//go:embed keys/hashicorp.asc
+var hashicorpPublicKey []byte
+
+// OpenTofu signing key, see https://opentofu.org/docs/intro/install/
+//
+//go:embed keys/opentofu.asc
+var openTofuPublicKey []byte
+
+const (
+	terraformReleasesURL  = "https://releases.hashicorp.com/terraform"
+	openTofuReleasesURL   = "https://github.com/opentofu/opentofu/releases/download"
+	terragruntReleasesURL = "https://github.com/gruntwork-io/terragrunt/releases/download"
+
+	// Environment variables used by tenv to override the signing keys, with a local path
+	terraformPublicKeyEnv = "TFENV_HASHICORP_PGP_KEY"
+	openTofuPublicKeyEnv  = "TOFUENV_OPENTOFU_PGP_KEY"
+
+	sumsFile      = "SHA256SUMS"
+	signatureFile = "SHA256SUMS.sig"
+)
+
+// Error is returned when a release does not match its checksums or signature
+type Error struct {
+	Tool    string
+	Version string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("integrity verification of %s %s failed: %s", e.Tool, e.Version, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// A Release describes where to find the upstream artifacts of a tool version
+type Release struct {
+	Tool    string
+	Version string
+	// Name of the archive in the checksums file
+	ArchiveName string
+	ArchiveURL  string
+	SumsURL     string
+	// Empty if the checksums file of the tool is not signed
+	SignatureURL string
+	// Path of the binary in the zip archive, empty if the archive is the binary itself
+	BinaryName string
+	// Returns the armored key which signed the checksums file
+	PublicKey func() ([]byte, error)
+}
+
+// GetRelease returns the release of a tool (terraform, tofu or terragrunt) for the current platform
+func GetRelease(tool string, version string) (*Release, error) {
+	version = strings.TrimPrefix(version, "v")
+	platform := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
+	switch tool {
+	case "terraform":
+		base := fmt.Sprintf("%s/%s", terraformReleasesURL, version)
+		archive := fmt.Sprintf("terraform_%s_%s.zip", version, platform)
+		return &Release{
+			Tool:         tool,
+			Version:      version,
+			ArchiveName:  archive,
+			ArchiveURL:   fmt.Sprintf("%s/%s", base, archive),
+			SumsURL:      fmt.Sprintf("%s/terraform_%s_SHA256SUMS", base, version),
+			SignatureURL: fmt.Sprintf("%s/terraform_%s_SHA256SUMS.sig", base, version),
+			BinaryName:   "terraform",
+			PublicKey: func() ([]byte, error) {
+				return publicKey(tool, terraformPublicKeyEnv, hashicorpPublicKey)
+			},
+		}, nil
+	case "tofu":
+		base := fmt.Sprintf("%s/v%s", openTofuReleasesURL, version)
+		archive := fmt.Sprintf("tofu_%s_%s.zip", version, platform)
+		return &Release{
+			Tool:         tool,
+			Version:      version,
+			ArchiveName:  archive,
+			ArchiveURL:   fmt.Sprintf("%s/%s", base, archive),
+			SumsURL:      fmt.Sprintf("%s/tofu_%s_SHA256SUMS", base, version),
+			SignatureURL: fmt.Sprintf("%s/tofu_%s_SHA256SUMS.gpgsig", base, version),
+			BinaryName:   "tofu",
+			PublicKey: func() ([]byte, error) {
+				return publicKey(tool, openTofuPublicKeyEnv, openTofuPublicKey)
+			},
+		}, nil
+	case "terragrunt":
+		base := fmt.Sprintf("%s/v%s", terragruntReleasesURL, version)
+		archive := fmt.Sprintf("terragrunt_%s", platform)
+		return &Release{
+			Tool:        tool,
+			Version:     version,
+			ArchiveName: archive,
+			ArchiveURL:  fmt.Sprintf("%s/%s", base, archive),
+			SumsURL:     fmt.Sprintf("%s/SHA256SUMS", base),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tool %s", tool)
+	}
+}
+
+// A Verifier installs binaries from upstream or mirrored releases after checking them against the
+// release checksums and signature. Checksums, signatures, keys and archives are cached so
+// that binaries can be verified, and reinstalled, without network access.
+type Verifier struct {
+	CacheDir string
+	Download func(url string) ([]byte, error)
+}
+
+func New(cacheDir string) *Verifier {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	return &Verifier{
+		CacheDir: cacheDir,
+		Download: func(url string) ([]byte, error) {
+			if content, ok, err := readFileURL(url); ok {
+				return content, err
+			}
+			resp, err := client.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("could not download %s: %s", url, resp.Status)
+			}
+			return io.ReadAll(resp.Body)
+		},
+	}
+}
+
+// Install makes sure the binary at path is the one of the release, (re)installing it otherwise
+func (v *Verifier) Install(release *Release, path string) error {
+	sums, err := v.getSums(release)
+	if err != nil {
+		return err
+	}
+	archive, err := v.getArchive(release, sums)
+	if err != nil {
+		return err
+	}
+	binary, err := extract(release, archive)
+	if err != nil {
+		return &Error{Tool: release.Tool, Version: release.Version, Err: err}
+	}
+	expected := sha256.Sum256(binary)
+	current, err := os.ReadFile(path)
+	switch {
+	case err == nil && sha256.Sum256(current) == expected:
+		log.Infof("%s %s binary matches the upstream release", release.Tool, release.Version)
+		return nil
+	case err == nil:
+		log.Warnf("%s %s binary at %s does not match the upstream release, reinstalling it", release.Tool, release.Version, path)
+	case !os.IsNotExist(err):
+		return err
+	}
+	return writeFile(path, binary, 0755)
+}
+
+// Return the checksums of the release, from the cache or upstream, after checking their signature
+func (v *Verifier) getSums(release *Release) ([]byte, error) {
+	dir := filepath.Join(v.CacheDir, release.Tool, release.Version)
+	sums, sumsErr := os.ReadFile(filepath.Join(dir, sumsFile))
+	signature, signatureErr := os.ReadFile(filepath.Join(dir, signatureFile))
+	var cacheErr error
+	if sumsErr == nil && (release.SignatureURL == "" || signatureErr == nil) {
+		cacheErr = v.checkSignature(release, sums, signature)
+		if cacheErr == nil {
+			return sums, nil
+		}
+		log.Warnf("cached checksums of %s %s are invalid, downloading them again: %s", release.Tool, release.Version, cacheErr)
+	}
+	sums, err := v.Download(release.SumsURL)
+	if err == nil && release.SignatureURL != "" {
+		signature, err = v.Download(release.SignatureURL)
+	}
+	if err != nil {
+		if cacheErr != nil {
+			return nil, &Error{Tool: release.Tool, Version: release.Version, Err: cacheErr}
+		}
+		return nil, err
+	}
+	err = v.checkSignature(release, sums, signature)
+	if err != nil {
+		return nil, &Error{Tool: release.Tool, Version: release.Version, Err: err}
+	}
+	err = writeFile(filepath.Join(dir, sumsFile), sums, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if release.SignatureURL != "" {
+		err = writeFile(filepath.Join(dir, signatureFile), signature, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+func (v *Verifier) checkSignature(release *Release, sums []byte, signature []byte) error {
+	if release.SignatureURL == "" {
+		log.Warnf("checksums of %s are not signed, skipping signature verification", release.Tool)
+		return nil
+	}
+	key, err := release.PublicKey()
+	if err != nil {
+		return err
+	}
+	publicKey, err := crypto.NewKeyFromArmored(string(key))
+	if err != nil {
+		return fmt.Errorf("invalid signing key: %w", err)
+	}
+	keyRing, err := crypto.NewKeyRing(publicKey)
+	if err != nil {
+		return err
+	}
+	err = keyRing.VerifyDetached(crypto.NewPlainMessage(sums), crypto.NewPGPSignature(signature), crypto.GetUnixTime())
+	if err != nil {
+		return fmt.Errorf("invalid checksums signature: %w", err)
+	}
+	return nil
+}
+
+// Return the archive of the release, from the cache or upstream, after checking its checksum
+func (v *Verifier) getArchive(release *Release, sums []byte) ([]byte, error) {
+	expected, err := getChecksum(sums, release.ArchiveName)
+	if err != nil {
+		return nil, &Error{Tool: release.Tool, Version: release.Version, Err: err}
+	}
+	path := filepath.Join(v.CacheDir, release.Tool, release.Version, release.ArchiveName)
+	archive, err := os.ReadFile(path)
+	if err == nil {
+		if sum := sha256.Sum256(archive); bytes.Equal(sum[:], expected) {
+			return archive, nil
+		}
+		log.Warnf("cached archive of %s %s does not match its checksum, downloading it again", release.Tool, release.Version)
+	}
+	cached := err == nil
+	archive, err = v.Download(release.ArchiveURL)
+	if err != nil {
+		if cached {
+			return nil, &Error{Tool: release.Tool, Version: release.Version, Err: fmt.Errorf("cached %s does not match its checksum and could not be downloaded again: %w", release.ArchiveName, err)}
+		}
+		return nil, err
+	}
+	if sum := sha256.Sum256(archive); !bytes.Equal(sum[:], expected) {
+		return nil, &Error{Tool: release.Tool, Version: release.Version, Err: fmt.Errorf("checksum of %s does not match", release.ArchiveName)}
+	}
+	err = writeFile(path, archive, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Return the signing key from the path set in env, or the embedded key. Keys are never downloaded,
+// so that the checksums cannot be signed by a key served along with them.
+func publicKey(tool string, env string, embedded []byte) ([]byte, error) {
+	if path := os.Getenv(env); path != "" {
+		return os.ReadFile(path)
+	}
+	if len(embedded) == 0 {
+		return nil, fmt.Errorf("no signing key embedded for %s, set %s to the path of its key", tool, env)
+	}
+	return embedded, nil
+}
+
+func getChecksum(sums []byte, name string) ([]byte, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == name {
+			return hex.DecodeString(fields[0])
+		}
+	}
+	return nil, fmt.Errorf("no checksum found for %s", name)
+}
+
+func extract(release *Release, archive []byte) ([]byte, error) {
+	if release.BinaryName == "" {
+		return archive, nil
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range reader.File {
+		if file.Name != release.BinaryName {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+		return io.ReadAll(content)
+	}
+	return nil, errors.New("binary not found in release archive")
+}
+
+// Write a file atomically, so that concurrent readers never see a partial file
+func writeFile(path string, content []byte, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), mode)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,213 @@
+package integrity
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+)
+
+type fakeUpstream map[string][]byte
+
+func (f fakeUpstream) download(url string) ([]byte, error) {
+	content, ok := f[url]
+	if !ok {
+		return nil, fmt.Errorf("could not download %s", url)
+	}
+	return content, nil
+}
+
+func buildRelease(t *testing.T, binary []byte) (*Release, fakeUpstream) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	f, err := w.Create("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	release := &Release{
+		Tool:        "tool",
+		Version:     "1.0.0",
+		ArchiveName: "tool_1.0.0_linux_amd64.zip",
+		ArchiveURL:  "https://example.com/tool_1.0.0_linux_amd64.zip",
+		SumsURL:     "https://example.com/SHA256SUMS",
+		BinaryName:  "tool",
+	}
+	return release, fakeUpstream{
+		release.ArchiveURL: buf.Bytes(),
+		release.SumsURL:    []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), release.ArchiveName)),
+	}
+}
+
+func TestInstallReinstallsTamperedBinary(t *testing.T) {
+	release, upstream := buildRelease(t, []byte("genuine"))
+	v := &Verifier{CacheDir: t.TempDir(), Download: upstream.download}
+	path := filepath.Join(t.TempDir(), "Tool", "1.0.0", "tool")
+
+	if err := v.Install(release, path); err != nil {
+		t.Fatalf("could not install release: %s", err)
+	}
+	if err := os.WriteFile(path, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Offline, the release is reinstalled from the cache
+	v.Download = fakeUpstream{}.download
+	if err := v.Install(release, path); err != nil {
+		t.Fatalf("could not reinstall release: %s", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "genuine" {
+		t.Errorf("expected binary to be reinstalled, got %q", content)
+	}
+}
+
+func TestInstallRejectsArchiveNotMatchingChecksums(t *testing.T) {
+	release, upstream := buildRelease(t, []byte("genuine"))
+	upstream[release.ArchiveURL] = []byte("tampered archive")
+	v := &Verifier{CacheDir: t.TempDir(), Download: upstream.download}
+
+	err := v.Install(release, filepath.Join(t.TempDir(), "tool"))
+	var integrityErr *Error
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("expected an integrity error, got %v", err)
+	}
+}
+
+func TestSignedChecksums(t *testing.T) {
+	sums, err := os.ReadFile("testdata/terraform_1.6.6_SHA256SUMS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := os.ReadFile("testdata/terraform_1.6.6_SHA256SUMS.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := GetRelease("terraform", "1.6.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := fakeUpstream{release.SumsURL: sums, release.SignatureURL: signature}
+	v := &Verifier{CacheDir: t.TempDir(), Download: upstream.download}
+
+	if _, err := v.getSums(release); err != nil {
+		t.Fatalf("expected checksums signed by HashiCorp to be valid: %s", err)
+	}
+	// Checksums are verified again from the cache when offline
+	v.Download = fakeUpstream{}.download
+	if _, err := v.getSums(release); err != nil {
+		t.Fatalf("expected cached checksums to be valid: %s", err)
+	}
+
+	v = &Verifier{CacheDir: t.TempDir(), Download: fakeUpstream{
+		release.SumsURL:      append(sums, []byte("0000  terraform_1.6.6_linux_amd64.zip\n")...),
+		release.SignatureURL: signature,
+	}.download}
+	_, err = v.getSums(release)
+	var integrityErr *Error
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("expected an integrity error for tampered checksums, got %v", err)
+	}
+}
+
+func TestMirrorSignedChecksums(t *testing.T) {
+	mirror := t.TempDir()
+	dir := filepath.Join(mirror, "terraform", "1.6.6")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"terraform_1.6.6_SHA256SUMS", "terraform_1.6.6_SHA256SUMS.sig"} {
+		content, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release, err := Mirror(mirror).GetRelease("terraform", "v1.6.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.SumsURL != "file://"+filepath.Join(dir, "terraform_1.6.6_SHA256SUMS") {
+		t.Errorf("expected checksums to be served by the mirror, got %s", release.SumsURL)
+	}
+	if _, err := New(t.TempDir()).getSums(release); err != nil {
+		t.Fatalf("expected mirrored checksums signed by HashiCorp to be valid: %s", err)
+	}
+}
+
+func TestMirrorListVersions(t *testing.T) {
+	mirror := Mirror("https://mirror.example.com/tools/")
+	v := &Verifier{CacheDir: t.TempDir(), Download: fakeUpstream{
+		"https://mirror.example.com/tools/terragrunt/versions": []byte("# internal releases\n0.55.0\nv0.56.1\n\n"),
+	}.download}
+
+	versions, err := mirror.ListVersions(v, "terragrunt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] != "0.55.0" || versions[1] != "0.56.1" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+	if _, err := mirror.ListVersions(v, "tofu"); err == nil {
+		t.Error("expected an error for a tool missing from the mirror")
+	}
+}
+
+func TestMirrorDoesNotServeSigningKeys(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "opentofu.asc")
+	if err := os.WriteFile(key, []byte("operator key"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(openTofuPublicKeyEnv, key)
+	release, err := Mirror("https://mirror.example.com/tools").GetRelease("tofu", "1.7.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := release.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "operator key" {
+		t.Errorf("expected the OpenTofu signing key to be the one set by %s, got %q", openTofuPublicKeyEnv, content)
+	}
+}
+
+func TestEmbeddedSigningKeys(t *testing.T) {
+	keys := map[string][]byte{
+		"keys/hashicorp.asc": hashicorpPublicKey,
+		"keys/opentofu.asc":  openTofuPublicKey,
+	}
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			if len(key) == 0 {
+				t.Skipf("%s is empty, the armored release key must be added to embed it", name)
+			}
+			if _, err := crypto.NewKeyFromArmored(string(key)); err != nil {
+				t.Errorf("expected %s to be a valid armored key: %s", name, err)
+			}
+		})
+	}
+}
+
+func TestSigningKeys(t *testing.T) {
+	if _, err := publicKey("tofu", openTofuPublicKeyEnv, nil); err == nil {
+		t.Error("expected an error when no key is embedded nor set in the environment")
+	}
+}
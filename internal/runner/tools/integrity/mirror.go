@@ -0,0 +1,69 @@
+package integrity
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// File of a tool directory in the mirror listing the available versions, one per line
+const mirrorIndexFile = "versions"
+
+// A Mirror serves the releases of the tools from an internal source, in place of their upstream
+// endpoints. It is either an HTTP(S) URL or a local directory, with the following layout:
+//
+//	<mirror>/<tool>/versions                   available versions, one per line
+//	<mirror>/<tool>/<version>/<upstream file>  archive, checksums and signature of the release
+//
+// The files of a release keep their upstream names, so that they are verified exactly as upstream ones.
+// Signing keys are never read from the mirror, otherwise a compromised mirror could sign its own releases.
+type Mirror string
+
+func (m Mirror) url(elems ...string) string {
+	base := strings.TrimSuffix(string(m), "/")
+	if !strings.Contains(base, "://") {
+		base = "file://" + base
+	}
+	return strings.Join(append([]string{base}, elems...), "/")
+}
+
+// GetRelease returns the release of a tool for the current platform, served by the mirror
+func (m Mirror) GetRelease(tool string, version string) (*Release, error) {
+	release, err := GetRelease(tool, version)
+	if err != nil {
+		return nil, err
+	}
+	release.ArchiveURL = m.url(tool, release.Version, release.ArchiveName)
+	release.SumsURL = m.url(tool, release.Version, path.Base(release.SumsURL))
+	if release.SignatureURL != "" {
+		release.SignatureURL = m.url(tool, release.Version, path.Base(release.SignatureURL))
+	}
+	return release, nil
+}
+
+// ListVersions returns the versions of a tool available in the mirror, from its index
+func (m Mirror) ListVersions(v *Verifier, tool string) ([]string, error) {
+	index, err := v.Download(m.url(tool, mirrorIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the versions of %s in the tools mirror: %w", tool, err)
+	}
+	versions := []string{}
+	for _, line := range strings.Split(string(index), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		versions = append(versions, strings.TrimPrefix(line, "v"))
+	}
+	return versions, nil
+}
+
+func readFileURL(url string) ([]byte, bool, error) {
+	path, ok := strings.CutPrefix(url, "file://")
+	if !ok {
+		return nil, false, nil
+	}
+	content, err := os.ReadFile(path)
+	return content, true, err
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/padok-team/burrito/internal/runner/tools/integrity"
+	"github.com/tofuutils/tenv/v4/versionmanager"
+	iacparser "github.com/tofuutils/tenv/v4/versionmanager/semantic/parser/iac"
+)
+
+// mirrorRetriever lists and installs the versions of a tool from the tools mirror, so that
+// tenv resolves version constraints without reaching the upstream release endpoints
+type mirrorRetriever struct {
+	mirror   integrity.Mirror
+	verifier *integrity.Verifier
+	tool     string
+}
+
+func (r mirrorRetriever) ListVersions(ctx context.Context) ([]string, error) {
+	return r.mirror.ListVersions(r.verifier, r.tool)
+}
+
+func (r mirrorRetriever) Install(ctx context.Context, version string, targetPath string) error {
+	release, err := r.mirror.GetRelease(r.tool, version)
+	if err != nil {
+		return err
+	}
+	return r.verifier.Install(release, filepath.Join(targetPath, toolPaths[r.tool][1]))
+}
+
+// Extensions of the files in which tenv looks for the required_version of a tool, as in its builders
+func iacExtensions(toolName string, hclParser *hclparse.Parser) []iacparser.ExtDescription {
+	exts := []iacparser.ExtDescription{}
+	if toolName == "tofu" {
+		exts = append(exts,
+			iacparser.ExtDescription{Value: ".tofu", Parser: hclParser.ParseHCLFile},
+			iacparser.ExtDescription{Value: ".tofu.json", Parser: hclParser.ParseJSONFile},
+		)
+	}
+	if toolName == "terraform" || toolName == "tofu" {
+		exts = append(exts,
+			iacparser.ExtDescription{Value: ".tf", Parser: hclParser.ParseHCLFile},
+			iacparser.ExtDescription{Value: ".tf.json", Parser: hclParser.ParseJSONFile},
+		)
+	}
+	return exts
+}
+
+// Rebuilds the version manager of tenv with the tools mirror as release retriever,
+// keeping the settings and version files of the upstream one
+func withMirror(versionManager versionmanager.VersionManager, hclParser *hclparse.Parser, toolName string, mirror string, binaryPath string) versionmanager.VersionManager {
+	retriever := mirrorRetriever{
+		mirror:   integrity.Mirror(mirror),
+		verifier: integrity.New(filepath.Join(binaryPath, integrityCacheDir)),
+		tool:     toolName,
+	}
+	return versionmanager.Make(versionManager.Conf, string(versionManager.EnvNames), versionManager.FolderName, iacExtensions(toolName, hclParser), retriever, versionManager.VersionFiles)
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFromMirror(t *testing.T) {
+	mirror, err := filepath.Abs("testdata/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TENV_AUTO_INSTALL", "false")
+	t.Chdir("testdata/layer")
+
+	// The constraint of the layer is resolved against the versions of the mirror
+	version, err := detect(t.TempDir(), "terraform", "", mirror)
+	if err != nil {
+		t.Fatalf("could not detect version from the mirror: %s", err)
+	}
+	if version != "1.5.7" {
+		t.Errorf("expected version 1.5.7, got %s", version)
+	}
+
+	version, err = detect(t.TempDir(), "terraform", "< 1.0.0", mirror)
+	if err != nil {
+		t.Fatalf("could not detect version from the mirror: %s", err)
+	}
+	if version != "0.15.5" {
+		t.Errorf("expected version 0.15.5, got %s", version)
+	}
+}
@@ -8,10 +8,11 @@ type OpenTofu struct {
 	base.BaseTool
 }
 
-func NewOpenTofu(execPath string) *OpenTofu {
+func NewOpenTofu(execPath string, version string) *OpenTofu {
 	return &OpenTofu{
 		BaseTool: base.BaseTool{
 			ExecPath: execPath,
+			Version:  version,
 			ToolName: "tofu",
 		},
 	}
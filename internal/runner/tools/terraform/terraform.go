@@ -8,10 +8,11 @@ type Terraform struct {
 	base.BaseTool
 }
 
-func NewTerraform(execPath string) *Terraform {
+func NewTerraform(execPath string, version string) *Terraform {
 	return &Terraform{
 		BaseTool: base.BaseTool{
 			ExecPath: execPath,
+			Version:  version,
 			ToolName: "terraform",
 		},
 	}
@@ -0,0 +1,234 @@
+package terragrunt
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const configFile = "terragrunt.hcl"
+
+// A Unit is a directory of a terragrunt stack with its own terragrunt.hcl
+type Unit struct {
+	// Path of the unit, relative to the root of the stack
+	Path string
+	// Paths of the units of the stack this unit depends on
+	Dependencies []string
+}
+
+var unitSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "dependency", LabelNames: []string{"name"}},
+		{Type: "dependencies"},
+	},
+}
+
+var dependencySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "config_path", Required: true}},
+}
+
+var dependenciesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "paths", Required: true}},
+}
+
+// DiscoverUnits returns the units found under root, sorted in dependency order
+func DiscoverUnits(root string) ([]Unit, error) {
+	units := map[string]*Unit{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || d.Name() != configFile || filepath.Dir(path) == root {
+			return nil
+		}
+		unit, err := parseUnit(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		units[unit.Path] = unit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(units) == 0 {
+		return nil, fmt.Errorf("no terragrunt unit found under %s", root)
+	}
+	return SortUnits(units)
+}
+
+// Read the dependencies of a unit from the dependency and dependencies blocks of its configuration
+func parseUnit(root string, dir string) (*Unit, error) {
+	relative, err := filepath.Rel(root, dir)
+	if err != nil {
+		return nil, err
+	}
+	unit := &Unit{Path: filepath.ToSlash(relative), Dependencies: []string{}}
+	file, diags := hclparse.NewParser().ParseHCLFile(filepath.Join(dir, configFile))
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("could not parse %s: %s", unit.Path, diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(unitSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("could not parse %s: %s", unit.Path, diags.Error())
+	}
+	paths := []string{}
+	for _, block := range content.Blocks {
+		schema, attribute := dependencySchema, "config_path"
+		if block.Type == "dependencies" {
+			schema, attribute = dependenciesSchema, "paths"
+		}
+		blockContent, _, diags := block.Body.PartialContent(schema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("could not parse %s: %s", unit.Path, diags.Error())
+		}
+		value, diags := blockContent.Attributes[attribute].Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("could not evaluate the dependencies of %s, only literal paths are supported: %s", unit.Path, diags.Error())
+		}
+		if value.Type() == cty.String {
+			paths = append(paths, value.AsString())
+			continue
+		}
+		if !value.CanIterateElements() {
+			return nil, fmt.Errorf("invalid %s in %s", attribute, unit.Path)
+		}
+		for _, element := range value.AsValueSlice() {
+			if element.Type() != cty.String {
+				return nil, fmt.Errorf("invalid %s in %s", attribute, unit.Path)
+			}
+			paths = append(paths, element.AsString())
+		}
+	}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		dependency, err := filepath.Rel(root, path)
+		if err != nil || dependency == ".." || strings.HasPrefix(dependency, "../") {
+			return nil, fmt.Errorf("dependency %s of %s is outside of the stack", path, unit.Path)
+		}
+		unit.Dependencies = append(unit.Dependencies, filepath.ToSlash(dependency))
+	}
+	sort.Strings(unit.Dependencies)
+	return unit, nil
+}
+
+// SortUnits sorts units so that each unit comes after its dependencies, alphabetically otherwise
+func SortUnits(units map[string]*Unit) ([]Unit, error) {
+	sorted := []Unit{}
+	done := map[string]bool{}
+	for len(sorted) < len(units) {
+		ready := []string{}
+		for path, unit := range units {
+			if done[path] {
+				continue
+			}
+			isReady := true
+			for _, dependency := range unit.Dependencies {
+				if _, ok := units[dependency]; !ok {
+					return nil, fmt.Errorf("dependency %s of %s is not a terragrunt unit", dependency, path)
+				}
+				if !done[dependency] {
+					isReady = false
+					break
+				}
+			}
+			if isReady {
+				ready = append(ready, path)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("dependency cycle between terragrunt units")
+		}
+		sort.Strings(ready)
+		for _, path := range ready {
+			done[path] = true
+			sorted = append(sorted, *units[path])
+		}
+	}
+	return sorted, nil
+}
+
+// The run command with the --all flag replaces run-all since terragrunt 0.77.0
+func (t *Terragrunt) getRunAllOptions(command string) []string {
+	version, err := semver.Parse(t.Version)
+	if err == nil && version.GTE(semver.MustParse("0.77.0")) {
+		return []string{"run", "--all", "--non-interactive", "--tf-path", t.ChildExecPath, "--working-dir", t.WorkingDir, "--", command}
+	}
+	if err == nil && version.GTE(semver.MustParse("0.73.0")) {
+		return []string{"run-all", command, "--non-interactive", "--tf-path", t.ChildExecPath, "--working-dir", t.WorkingDir}
+	}
+	return []string{"run-all", command, "--terragrunt-non-interactive", "--terragrunt-tfpath", t.ChildExecPath, "--terragrunt-working-dir", t.WorkingDir}
+}
+
+// RunAll runs a command on all the units of the stack rooted at the working directory
+func (t *Terragrunt) RunAll(command string, args ...string) error {
+	cmd := exec.Command(t.ExecPath, append(t.getRunAllOptions(command), args...)...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	return cmd.Run()
+}
+
+// ForUnit returns a Terragrunt executor running in the given unit of the stack
+func (t *Terragrunt) ForUnit(unit string) *Terragrunt {
+	return &Terragrunt{
+		ExecPath:      t.ExecPath,
+		WorkingDir:    filepath.Join(t.WorkingDir, unit),
+		ChildExecPath: t.ChildExecPath,
+		Version:       t.Version,
+		ChildName:     t.ChildName,
+		ChildVersion:  t.ChildVersion,
+		Stdout:        t.Stdout,
+	}
+}
+
+// FindPlanFile returns the path of the plan file written by a run-all plan in the unit. Terragrunt
+// runs the plan in its cache when the unit has a source, so the most recent one is returned.
+func (t *Terragrunt) FindPlanFile(name string) (string, error) {
+	found := ""
+	var modTime time.Time
+	err := filepath.WalkDir(t.WorkingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != t.WorkingDir && !strings.Contains(path, ".terragrunt-cache") {
+			// Nested units have their own plan file
+			if _, err := os.Stat(filepath.Join(path, configFile)); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		if d.IsDir() || d.Name() != name {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if found == "" || info.ModTime().After(modTime) {
+			found, modTime = path, info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("no plan file found in %s", t.WorkingDir)
+	}
+	return found, nil
+}
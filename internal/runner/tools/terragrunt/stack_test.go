@@ -0,0 +1,126 @@
+package terragrunt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeUnit(t *testing.T, root string, path string, config string) {
+	dir := filepath.Join(root, path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverUnits(t *testing.T) {
+	root := t.TempDir()
+	writeUnit(t, root, ".", `remote_state {}`)
+	writeUnit(t, root, "network/vpc", `include "root" {
+  path = find_in_parent_folders()
+}`)
+	writeUnit(t, root, "app", `dependency "vpc" {
+  config_path = "../network/vpc"
+  mock_outputs = { id = "vpc-123" }
+}
+dependency "db" {
+  config_path = "../db"
+}`)
+	writeUnit(t, root, "db", `dependencies {
+  paths = ["../network/vpc"]
+}`)
+	writeUnit(t, root, "dns", ``)
+	writeUnit(t, root, "app/.terragrunt-cache/abc/def", `dependency "ignored" {
+  config_path = "../../../../unknown"
+}`)
+
+	units, err := DiscoverUnits(root)
+	if err != nil {
+		t.Fatalf("could not discover units: %s", err)
+	}
+	expected := []Unit{
+		{Path: "dns", Dependencies: []string{}},
+		{Path: "network/vpc", Dependencies: []string{}},
+		{Path: "db", Dependencies: []string{"network/vpc"}},
+		{Path: "app", Dependencies: []string{"db", "network/vpc"}},
+	}
+	if !reflect.DeepEqual(units, expected) {
+		t.Errorf("expected units %v, got %v", expected, units)
+	}
+}
+
+func TestDiscoverUnitsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		units map[string]string
+	}{
+		{
+			name: "Dependency cycle",
+			units: map[string]string{
+				"a": `dependency "b" { config_path = "../b" }`,
+				"b": `dependency "a" { config_path = "../a" }`,
+			},
+		},
+		{
+			name: "Dependency outside of the stack",
+			units: map[string]string{
+				"a": `dependency "b" { config_path = "../../b" }`,
+			},
+		},
+		{
+			name: "Dependency which is not a unit",
+			units: map[string]string{
+				"a": `dependency "b" { config_path = "../b" }`,
+			},
+		},
+		{
+			name: "Dependency path which is not a literal",
+			units: map[string]string{
+				"a": `dependency "b" { config_path = find_in_parent_folders("b") }`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, config := range tt.units {
+				writeUnit(t, root, path, config)
+			}
+			if _, err := DiscoverUnits(root); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestTerragrunt_getRunAllOptions(t *testing.T) {
+	tests := []struct {
+		version       string
+		expectedFlags []string
+	}{
+		{
+			version:       "0.66.9",
+			expectedFlags: []string{"run-all", "plan", "--terragrunt-non-interactive", "--terragrunt-tfpath", "/bin/terraform", "--terragrunt-working-dir", "/stack"},
+		},
+		{
+			version:       "0.73.0",
+			expectedFlags: []string{"run-all", "plan", "--non-interactive", "--tf-path", "/bin/terraform", "--working-dir", "/stack"},
+		},
+		{
+			version:       "0.77.0",
+			expectedFlags: []string{"run", "--all", "--non-interactive", "--tf-path", "/bin/terraform", "--working-dir", "/stack", "--", "plan"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			tg := &Terragrunt{Version: tt.version, ChildExecPath: "/bin/terraform", WorkingDir: "/stack"}
+			if flags := tg.getRunAllOptions("plan"); !reflect.DeepEqual(flags, tt.expectedFlags) {
+				t.Errorf("expected flags %v, got %v", tt.expectedFlags, flags)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package terragrunt
 
 import (
 	"errors"
+	"io"
 	"os/exec"
 
 	"github.com/blang/semver/v4"
@@ -13,6 +14,11 @@ type Terragrunt struct {
 	WorkingDir    string
 	ChildExecPath string
 	Version       string
+	// Name and version of the tool wrapped by terragrunt
+	ChildName    string
+	ChildVersion string
+	// Standard output of plan and apply, the verbose output by default
+	Stdout io.Writer
 }
 
 func (t *Terragrunt) TenvName() string {
@@ -59,13 +65,13 @@ func (t *Terragrunt) getDefaultOptions(command string) ([]string, error) {
 	}
 }
 
-func (t *Terragrunt) Init(workingDir string) error {
+func (t *Terragrunt) Init(workingDir string, args ...string) error {
 	t.WorkingDir = workingDir
 	options, err := t.getDefaultOptions("init")
 	if err != nil {
 		return err
 	}
-	cmd := exec.Command(t.ExecPath, options...)
+	cmd := exec.Command(t.ExecPath, append(options, args...)...)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
 	if err := cmd.Run(); err != nil {
@@ -74,14 +80,36 @@ func (t *Terragrunt) Init(workingDir string) error {
 	return nil
 }
 
-func (t *Terragrunt) Plan(planArtifactPath string) error {
+// SelectWorkspace selects the given workspace, creating it if it does not exist yet
+func (t *Terragrunt) SelectWorkspace(workspace string) error {
+	options, err := t.getDefaultOptions("workspace")
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(t.ExecPath, append(options, "select", workspace)...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := cmd.Run(); err == nil {
+		return nil
+	}
+	cmd = exec.Command(t.ExecPath, append(options, "new", workspace)...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Terragrunt) Plan(planArtifactPath string, args ...string) error {
 	options, err := t.getDefaultOptions("plan")
 	if err != nil {
 		return err
 	}
 	options = append(options, "-out", planArtifactPath)
+	options = append(options, args...)
 	cmd := exec.Command(t.ExecPath, options...)
-	c.Verbose(cmd)
+	c.VerboseTo(cmd, t.Stdout)
 	cmd.Dir = t.WorkingDir
 	if err := cmd.Run(); err != nil {
 		return err
@@ -89,18 +117,19 @@ func (t *Terragrunt) Plan(planArtifactPath string) error {
 	return nil
 }
 
-func (t *Terragrunt) Apply(planArtifactPath string) error {
+func (t *Terragrunt) Apply(planArtifactPath string, args ...string) error {
 	options, err := t.getDefaultOptions("apply")
 	if err != nil {
 		return err
 	}
 	options = append(options, "-auto-approve")
+	options = append(options, args...)
 	if planArtifactPath != "" {
 		options = append(options, planArtifactPath)
 	}
 
 	cmd := exec.Command(t.ExecPath, options...)
-	c.Verbose(cmd)
+	c.VerboseTo(cmd, t.Stdout)
 	cmd.Dir = t.WorkingDir
 	if err := cmd.Run(); err != nil {
 		return err
@@ -131,6 +160,25 @@ func (t *Terragrunt) Show(planArtifactPath, mode string) ([]byte, error) {
 	return output, nil
 }
 
+// Output returns the outputs of the layer in JSON, sensitive values included
+func (t *Terragrunt) Output() ([]byte, error) {
+	options, err := t.getDefaultOptions("output")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(t.ExecPath, append(options, "-no-color", "-json")...)
+	cmd.Dir = t.WorkingDir
+	return cmd.Output()
+}
+
+func (t *Terragrunt) SetStdout(w io.Writer) {
+	t.Stdout = w
+}
+
+func (t *Terragrunt) GetVersion() string {
+	return t.Version
+}
+
 func (t *Terragrunt) GetExecPath() string {
 	return t.ExecPath
 }
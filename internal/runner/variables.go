@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Names of terraform variables, as accepted by terraform identifiers
+var variableNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// Build the -var and -var-file arguments from the variables of the layer and its repository.
+// Values taken from ConfigMaps and Secrets are read from the files projected in the runner pod.
+// Values taken from Secrets and layer outputs are returned as TF_VAR_ environment variables
+// instead, so that they never show up in the arguments of the commands.
+func getVariablesArgs(variables configv1alpha1.Variables, variablesPath, workingDir string) (args []string, env []string, err error) {
+	args = []string{}
+	for _, file := range variables.VarFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workingDir, file)
+		}
+		args = append(args, fmt.Sprintf("-var-file=%s", file))
+	}
+	for _, v := range variables.Vars {
+		if !variableNameRegexp.MatchString(v.Name) {
+			return nil, nil, fmt.Errorf("invalid variable name %q", v.Name)
+		}
+		value, ok, err := readValue(v, variablesPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read value of variable %s: %w", v.Name, err)
+		}
+		if !ok {
+			log.Infof("optional variable %s is not set, skipping", v.Name)
+			continue
+		}
+		if isSensitive(v.ValueFrom) {
+			env = append(env, fmt.Sprintf("TF_VAR_%s=%s", v.Name, value))
+			continue
+		}
+		args = append(args, "-var", fmt.Sprintf("%s=%s", v.Name, value))
+	}
+	return args, env, nil
+}
+
+// Build the variables arguments of the layer, and export the sensitive ones in the environment
+// of the runner, which is inherited by terraform, tofu and terragrunt
+func (r *Runner) getVariablesArgs() ([]string, error) {
+	args, env, err := getVariablesArgs(configv1alpha1.GetVariables(r.Repository, r.Layer), r.config.Runner.VariablesPath, r.workingDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		if err := os.Setenv(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// Build the -backend-config argument of init from the backend configuration of the layer and its
+// repository. The values are written to a backend configuration file only readable by the runner
+// in dir, so that credentials never show up in the arguments of the commands.
+func getBackendConfigArgs(backendConfig []configv1alpha1.Variable, backendConfigPath string, dir string) ([]string, error) {
+	file := hclwrite.NewEmptyFile()
+	empty := true
+	for _, v := range backendConfig {
+		if !variableNameRegexp.MatchString(v.Name) {
+			return nil, fmt.Errorf("invalid backend configuration name %q", v.Name)
+		}
+		value, ok, err := readValue(v, backendConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read value of backend configuration %s: %w", v.Name, err)
+		}
+		if !ok {
+			log.Infof("optional backend configuration %s is not set, skipping", v.Name)
+			continue
+		}
+		file.Body().SetAttributeValue(v.Name, cty.StringVal(value))
+		empty = false
+	}
+	if empty {
+		return []string{}, nil
+	}
+	f, err := os.CreateTemp(dir, "backend-*.tfbackend")
+	if err != nil {
+		return nil, fmt.Errorf("could not create backend configuration file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(file.Bytes()); err != nil {
+		return nil, fmt.Errorf("could not write backend configuration file: %w", err)
+	}
+	return []string{fmt.Sprintf("-backend-config=%s", f.Name())}, nil
+}
+
+// Return the value of a variable, read from the files projected in path when it is taken from
+// a ConfigMap or a Secret. ok is false when an optional source is not set.
+func readValue(v configv1alpha1.Variable, path string) (value string, ok bool, err error) {
+	if v.ValueFrom == nil {
+		return v.Value, true, nil
+	}
+	content, err := os.ReadFile(filepath.Join(path, v.Name))
+	if errors.Is(err, os.ErrNotExist) && isOptional(v.ValueFrom) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSuffix(string(content), "\n"), true, nil
+}
+
+// Return true for the sources of variables that may hold secrets
+func isSensitive(source *configv1alpha1.VariableSource) bool {
+	return source != nil && (source.SecretKeyRef != nil || source.LayerOutputRef != nil)
+}
+
+func isOptional(source *configv1alpha1.VariableSource) bool {
+	switch {
+	case source.SecretKeyRef != nil:
+		return source.SecretKeyRef.Optional != nil && *source.SecretKeyRef.Optional
+	case source.ConfigMapKeyRef != nil:
+		return source.ConfigMapKeyRef.Optional != nil && *source.ConfigMapKeyRef.Optional
+	case source.LayerOutputRef != nil:
+		return source.LayerOutputRef.Optional != nil && *source.LayerOutputRef.Optional
+	}
+	return false
+}
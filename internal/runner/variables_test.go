@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetVariablesArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api_key"), []byte("my-secret-api-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	optional := true
+	variables := configv1alpha1.Variables{
+		VarFiles: []string{"common.tfvars", "/etc/burrito/global.tfvars"},
+		Vars: []configv1alpha1.Variable{
+			{Name: "environment", Value: "production"},
+			{Name: "api_key", ValueFrom: &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "api_key"}}},
+			{Name: "region", ValueFrom: &configv1alpha1.VariableSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "region", Optional: &optional}}},
+		},
+	}
+	args, env, err := getVariablesArgs(variables, dir, "/repository/layer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"-var-file=/repository/layer/common.tfvars",
+		"-var-file=/etc/burrito/global.tfvars",
+		"-var", "environment=production",
+	}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("unexpected variables arguments %v", args)
+	}
+	if len(env) != 1 || env[0] != "TF_VAR_api_key=my-secret-api-key" {
+		t.Errorf("expected the value from the secret to be in the environment, got %v", env)
+	}
+
+	variables.Vars = append(variables.Vars, configv1alpha1.Variable{
+		Name:      "token",
+		ValueFrom: &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}},
+	})
+	if _, _, err := getVariablesArgs(variables, dir, "/repository/layer"); err == nil {
+		t.Errorf("expected a missing required value to be an error")
+	}
+
+	variables.Vars = []configv1alpha1.Variable{{Name: "region=eu -var other", Value: "west"}}
+	if _, _, err := getVariablesArgs(variables, dir, "/repository/layer"); err == nil {
+		t.Errorf("expected an invalid variable name to be an error")
+	}
+}
+
+func TestGetBackendConfigArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "access_key"), []byte("my-access-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	backendConfig := []configv1alpha1.Variable{
+		{Name: "key", Value: "layers/${name}/terraform.tfstate"},
+		{Name: "access_key", ValueFrom: &configv1alpha1.VariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "access_key"}}},
+	}
+	args, err := getBackendConfigArgs(backendConfig, dir, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || !strings.HasPrefix(args[0], "-backend-config=") {
+		t.Fatalf("expected a single backend configuration file argument, got %v", args)
+	}
+	file := strings.TrimPrefix(args[0], "-backend-config=")
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the backend configuration file to only be readable by the runner, got %s", info.Mode())
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "key        = \"layers/$${name}/terraform.tfstate\"\naccess_key = \"my-access-key\"\n"
+	if string(content) != expected {
+		t.Errorf("unexpected backend configuration file %q", content)
+	}
+
+	args, err = getBackendConfigArgs(nil, dir, t.TempDir())
+	if err != nil || len(args) != 0 {
+		t.Errorf("expected no argument without backend configuration, got %v, %v", args, err)
+	}
+	if _, err := getBackendConfigArgs([]configv1alpha1.Variable{{Name: "key = \"other\"\nbucket", Value: "x"}}, dir, t.TempDir()); err == nil {
+		t.Errorf("expected an invalid backend configuration name to be an error")
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/utils/approval"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type approvalsResponse struct {
+	Run       string                          `json:"run"`
+	PlanSum   string                          `json:"planSum"`
+	Required  int                             `json:"required"`
+	Pending   int                             `json:"pending"`
+	Approvals []configv1alpha1.ApprovalRecord `json:"approvals"`
+	Rejection *configv1alpha1.ApprovalRecord  `json:"rejection,omitempty"`
+}
+
+func newApprovalsResponse(policy configv1alpha1.ApprovalPolicy, approvals configv1alpha1.PlanApprovals) approvalsResponse {
+	response := approvalsResponse{
+		Run:       approvals.Run,
+		PlanSum:   approvals.PlanSum,
+		Required:  approval.Required(policy),
+		Pending:   approval.Pending(policy, approvals),
+		Approvals: approvals.Approvals,
+		Rejection: approvals.Rejection,
+	}
+	if response.Approvals == nil {
+		response.Approvals = []configv1alpha1.ApprovalRecord{}
+	}
+	return response
+}
+
+// The identity of the user is set in the context by the authentication middleware
+func getIdentity(c echo.Context) approval.Identity {
+	identity := approval.Identity{}
+	if id, ok := c.Get("user_id").(string); ok {
+		identity.ID = id
+	}
+	if email, ok := c.Get("user_email").(string); ok {
+		identity.Email = email
+	}
+	if groups, ok := c.Get("user_groups").([]string); ok {
+		identity.Groups = groups
+	}
+	return identity
+}
+
+// Returns the layer and its approval policy, or the status and the message of the error response
+func (a *API) getApprovalPolicy(namespace, name string) (*configv1alpha1.TerraformLayer, configv1alpha1.ApprovalPolicy, int, error) {
+	layer := &configv1alpha1.TerraformLayer{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, layer)
+	if errors.IsNotFound(err) {
+		return nil, configv1alpha1.ApprovalPolicy{}, http.StatusNotFound, fmt.Errorf("Layer not found")
+	}
+	if err != nil {
+		log.Errorf("could not get terraform layer: %s", err)
+		return nil, configv1alpha1.ApprovalPolicy{}, http.StatusInternalServerError, fmt.Errorf("An error occurred while getting the layer")
+	}
+	repository := &configv1alpha1.TerraformRepository{}
+	err = a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: layer.Spec.Repository.Namespace,
+		Name:      layer.Spec.Repository.Name,
+	}, repository)
+	if err != nil {
+		log.Errorf("could not get terraform repository: %s", err)
+		return nil, configv1alpha1.ApprovalPolicy{}, http.StatusInternalServerError, fmt.Errorf("An error occurred while getting the repository of the layer")
+	}
+	return layer, configv1alpha1.GetApprovalPolicy(repository, layer), http.StatusOK, nil
+}
+
+// layers/${namespace}/${layer}/approvals
+// Returns the approvals of the last plan of the layer
+func (a *API) GetApprovalsHandler(c echo.Context) error {
+	layer, policy, status, err := a.getApprovalPolicy(c.Param("namespace"), c.Param("layer"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, newApprovalsResponse(policy, approval.Current(layer)))
+}
+
+// layers/${namespace}/${layer}/approve
+// Approves the last plan of the layer, it is applied once it has received the required approvals
+func (a *API) ApprovePlanHandler(c echo.Context) error {
+	return a.recordApproval(c, false)
+}
+
+// layers/${namespace}/${layer}/reject
+// Rejects the last plan of the layer, it is never applied
+func (a *API) RejectPlanHandler(c echo.Context) error {
+	return a.recordApproval(c, true)
+}
+
+func (a *API) recordApproval(c echo.Context, reject bool) error {
+	layer, policy, status, err := a.getApprovalPolicy(c.Param("namespace"), c.Param("layer"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	if approval.Required(policy) == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Plans of this layer do not require approvals"})
+	}
+	identity := getIdentity(c)
+	if identity.ID == "" {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Approvals require an authenticated user"})
+	}
+	if !approval.IsAllowed(policy, identity) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not allowed to approve plans of this layer"})
+	}
+	approvals := approval.Current(layer)
+	if approvals.Run == "" || approvals.PlanSum == "" {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer has no plan to approve"})
+	}
+	if approvals.Rejection != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Last plan has already been rejected"})
+	}
+	record := configv1alpha1.ApprovalRecord{User: identity.Name(), Time: metav1.Now()}
+	if reject {
+		approvals.Rejection = &record
+	} else {
+		if approval.HasApproved(approvals, identity) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "You have already approved the last plan"})
+		}
+		approvals.Approvals = append(approvals.Approvals, record)
+	}
+	value, err := json.Marshal(approvals)
+	if err != nil {
+		log.Errorf("could not marshal approvals: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while recording the approval"})
+	}
+	// Concurrent approvals must not overwrite each other
+	patch := client.MergeFromWithOptions(layer.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	layer.Annotations[annotations.Approvals] = string(value)
+	err = a.Client.Patch(context.Background(), layer, patch)
+	if errors.IsConflict(err) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer has been updated in the meantime, please retry"})
+	}
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	return c.JSON(http.StatusOK, newApprovalsResponse(policy, approvals))
+}
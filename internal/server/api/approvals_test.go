@@ -0,0 +1,175 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/server/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type approvalsBody struct {
+	Run       string                          `json:"run"`
+	PlanSum   string                          `json:"planSum"`
+	Required  int                             `json:"required"`
+	Pending   int                             `json:"pending"`
+	Approvals []configv1alpha1.ApprovalRecord `json:"approvals"`
+	Rejection *configv1alpha1.ApprovalRecord  `json:"rejection"`
+}
+
+var _ = Describe("Approvals API", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		e = echo.New()
+	})
+
+	intPtr := func(i int) *int { return &i }
+
+	newObjects := func(policy configv1alpha1.ApprovalPolicy, layerAnnotations map[string]string) []client.Object {
+		repository := &configv1alpha1.TerraformRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-repo", Namespace: "default"},
+			Spec: configv1alpha1.TerraformRepositorySpec{
+				RemediationStrategy: configv1alpha1.RemediationStrategy{Approval: policy},
+			},
+		}
+		layer := &configv1alpha1.TerraformLayer{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "my-layer",
+				Namespace:   "default",
+				Annotations: layerAnnotations,
+			},
+			Spec: configv1alpha1.TerraformLayerSpec{
+				Path:       "modules/test",
+				Branch:     "main",
+				Repository: configv1alpha1.TerraformLayerRepository{Name: "my-repo", Namespace: "default"},
+			},
+		}
+		return []client.Object{repository, layer}
+	}
+	planned := func() map[string]string {
+		return map[string]string{
+			annotations.LastPlanRun: "my-layer-plan-abcde",
+			annotations.LastPlanSum: "AuP6pMNxWsbSZKnxZvxD842wy0qaF9JCX8HW1nFeL1I",
+		}
+	}
+	call := func(a *api.API, handler func(*api.API, echo.Context) error, user string, groups []string) (*httptest.ResponseRecorder, approvalsBody) {
+		req := httptest.NewRequest(http.MethodPost, "/api/layers/default/my-layer/approve", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "my-layer"})
+		if user != "" {
+			c.Set("user_id", user)
+			c.Set("user_email", user+"@example.com")
+			c.Set("user_groups", groups)
+		}
+		Expect(handler(a, c)).To(Succeed())
+		body := approvalsBody{}
+		if rec.Code == http.StatusOK {
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		}
+		return rec, body
+	}
+	approve := (*api.API).ApprovePlanHandler
+	reject := (*api.API).RejectPlanHandler
+	get := (*api.API).GetApprovalsHandler
+
+	It("should record approvals until the required number is reached", func() {
+		policy := configv1alpha1.ApprovalPolicy{Required: intPtr(2)}
+		a := &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(policy, planned())...).Build()}
+
+		rec, body := call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body.Run).To(Equal("my-layer-plan-abcde"))
+		Expect(body.Required).To(Equal(2))
+		Expect(body.Pending).To(Equal(1))
+		Expect(body.Approvals).To(HaveLen(1))
+		Expect(body.Approvals[0].User).To(Equal("alice@example.com"))
+
+		rec, _ = call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+
+		rec, body = call(a, approve, "bob", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body.Pending).To(Equal(0))
+
+		rec, body = call(a, get, "", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body.Approvals).To(HaveLen(2))
+	})
+
+	It("should only accept approvals from allowed users and groups", func() {
+		policy := configv1alpha1.ApprovalPolicy{Required: intPtr(1), Users: []string{"alice@example.com"}, Groups: []string{"platform"}}
+		a := &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(policy, planned())...).Build()}
+
+		rec, _ := call(a, approve, "mallory", []string{"developers"})
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		rec, _ = call(a, approve, "", nil)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		rec, _ = call(a, approve, "bob", []string{"platform"})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		rec, _ = call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject the last plan and refuse further approvals", func() {
+		policy := configv1alpha1.ApprovalPolicy{Required: intPtr(1)}
+		fakeClient := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(policy, planned())...).Build()
+		a := &api.API{Client: fakeClient}
+
+		rec, body := call(a, reject, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body.Rejection).NotTo(BeNil())
+		Expect(body.Rejection.User).To(Equal("alice@example.com"))
+
+		rec, _ = call(a, approve, "bob", nil)
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+
+		layer := &configv1alpha1.TerraformLayer{}
+		Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "my-layer"}, layer)).To(Succeed())
+		Expect(layer.Annotations).To(HaveKey(annotations.Approvals))
+	})
+
+	It("should discard approvals of a previous plan", func() {
+		policy := configv1alpha1.ApprovalPolicy{Required: intPtr(1)}
+		layerAnnotations := planned()
+		layerAnnotations[annotations.Approvals] = `{"run":"my-layer-plan-old","planSum":"old","approvals":[{"user":"alice@example.com","time":"2024-05-08T12:00:00Z"}]}`
+		a := &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(policy, layerAnnotations)...).Build()}
+
+		rec, body := call(a, get, "", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body.Run).To(Equal("my-layer-plan-abcde"))
+		Expect(body.Approvals).To(BeEmpty())
+		Expect(body.Pending).To(Equal(1))
+
+		rec, _ = call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should refuse approvals when none are required or there is no plan", func() {
+		a := &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(configv1alpha1.ApprovalPolicy{}, planned())...).Build()}
+		rec, _ := call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+
+		policy := configv1alpha1.ApprovalPolicy{Required: intPtr(1)}
+		a = &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newObjects(policy, map[string]string{})...).Build()}
+		rec, _ = call(a, approve, "alice", nil)
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("should return not found for an unknown layer", func() {
+		a := &api.API{Client: fake.NewClientBuilder().WithScheme(newScheme()).Build()}
+		rec, _ := call(a, get, "", nil)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/padok-team/burrito/internal/utils/layergraph"
+)
+
+type layerGraphNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	State     string `json:"state"`
+	// False when the layer is referenced by dependsOn but does not exist
+	Exists bool `json:"exists"`
+}
+
+// An edge goes from a layer to a layer depending on it, in the order of the applies
+type layerGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type layerGraphResponse struct {
+	Nodes  []layerGraphNode `json:"nodes"`
+	Edges  []layerGraphEdge `json:"edges"`
+	Cycles []string         `json:"cycles"`
+}
+
+// LayersGraphHandler returns the graph of the dependencies between layers, pull request layers excluded
+func (a *API) LayersGraphHandler(c echo.Context) error {
+	layers, _, err := a.getLayersAndRuns()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("could not list terraform layers: %s", err))
+	}
+	response := layerGraphResponse{Nodes: []layerGraphNode{}, Edges: []layerGraphEdge{}}
+	nodes := map[string]bool{}
+	for _, l := range layers {
+		if a.isLayerPR(l) {
+			continue
+		}
+		key := layergraph.Key(l.Namespace, l.Name)
+		nodes[key] = true
+		response.Nodes = append(response.Nodes, layerGraphNode{
+			ID:        key,
+			Name:      l.Name,
+			Namespace: l.Namespace,
+			State:     a.getLayerState(l),
+			Exists:    true,
+		})
+	}
+	for _, l := range layers {
+		if a.isLayerPR(l) {
+			continue
+		}
+		for _, dependency := range layergraph.Dependencies(&l) {
+			response.Edges = append(response.Edges, layerGraphEdge{From: dependency, To: layergraph.Key(l.Namespace, l.Name)})
+			if !nodes[dependency] {
+				nodes[dependency] = true
+				namespace, name, _ := strings.Cut(dependency, "/")
+				response.Nodes = append(response.Nodes, layerGraphNode{ID: dependency, Name: name, Namespace: namespace})
+			}
+		}
+	}
+	slices.SortFunc(response.Nodes, func(a, b layerGraphNode) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	response.Cycles = layergraph.New(layers).Cycles()
+	return c.JSON(http.StatusOK, &response)
+}
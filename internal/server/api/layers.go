@@ -30,6 +30,7 @@ type layer struct {
 	LatestRuns       []Run                  `json:"latestRuns"`
 	ManualSyncStatus utils.ManualSyncStatus `json:"manualSyncStatus"`
 	HasValidPlan     bool                   `json:"hasValidPlan"`
+	PartialPlan      bool                   `json:"partialPlan"`
 	AutoApply        bool                   `json:"autoApply"`
 }
 
@@ -127,6 +128,7 @@ func (a *API) LayersHandler(c echo.Context) error {
 			LatestRuns:       transformLatestRuns(l.Status.LatestRuns),
 			ManualSyncStatus: getManualOperationStatus(l),
 			HasValidPlan:     hasValidPlan(l),
+			PartialPlan:      l.Annotations[annotations.LastPlanPartial] == "true",
 			AutoApply:        autoApply,
 		})
 	}
@@ -137,7 +139,7 @@ func (a *API) LayersHandler(c echo.Context) error {
 }
 
 func runStillRunning(run configv1alpha1.TerraformRun) bool {
-	if run.Status.State != "Failed" && run.Status.State != "Succeeded" {
+	if run.Status.State != "Failed" && run.Status.State != "Succeeded" && run.Status.State != "Cancelled" {
 		return true
 	}
 	return false
@@ -162,15 +164,19 @@ func (a *API) getLayerState(layer configv1alpha1.TerraformLayer) string {
 	case len(layer.Status.Conditions) == 0:
 		state = "disabled"
 	case layer.Status.State == "ApplyNeeded":
-		if layer.Status.LastResult == "Plan: 0 to create, 0 to update, 0 to delete" {
-			state = "success"
-		} else {
+		if lastPlanHasChanges(layer) {
 			state = "warning"
+		} else {
+			state = "success"
 		}
 	case layer.Status.State == "PlanNeeded":
 		state = "warning"
+	case layer.Status.State == "ApprovalPending":
+		state = "warning"
+	case isLayerDrifted(layer):
+		state = "warning"
 	}
-	if layer.Annotations[annotations.LastPlanSum] == "" {
+	if layer.Annotations[annotations.LastPlanSum] == "" || layer.Annotations[annotations.LastPlanPolicyPassed] == "false" {
 		state = "error"
 	}
 	if layer.Annotations[annotations.LastApplySum] != "" && layer.Annotations[annotations.LastApplySum] == "" {
@@ -179,6 +185,23 @@ func (a *API) getLayerState(layer configv1alpha1.TerraformLayer) string {
 	return state
 }
 
+func lastPlanHasChanges(layer configv1alpha1.TerraformLayer) bool {
+	if hasChanges, ok := layer.Annotations[annotations.LastPlanHasChanges]; ok {
+		return hasChanges == "true"
+	}
+	// Layers planned before plan summaries were introduced only have the short diff
+	return layer.Status.LastResult != "Plan: 0 to create, 0 to update, 0 to delete"
+}
+
+func isLayerDrifted(layer configv1alpha1.TerraformLayer) bool {
+	for _, c := range layer.Status.Conditions {
+		if c.Type == "Drifted" && c.Status == "True" {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *API) isLayerPR(layer configv1alpha1.TerraformLayer) bool {
 	if len(layer.OwnerReferences) == 0 {
 		return false
@@ -187,7 +210,11 @@ func (a *API) isLayerPR(layer configv1alpha1.TerraformLayer) bool {
 }
 
 func getManualOperationStatus(layer configv1alpha1.TerraformLayer) utils.ManualSyncStatus {
-	// Check apply status first, then sync status
+	// Check destroy and apply status first, then sync status
+	destroyStatus := utils.GetManualDestroyStatus(layer)
+	if destroyStatus != utils.ManualSyncNone {
+		return destroyStatus
+	}
 	applyStatus := utils.GetManualApplyStatus(layer)
 	if applyStatus != utils.ManualSyncNone {
 		return applyStatus
@@ -131,5 +131,258 @@ var _ = Describe("Layers API", func() {
 			Expect(byName["layer-no-plan"].ManualSyncStatus).To(Equal(utils.ManualSyncAnnotated))
 			Expect(byName["layer-no-plan"].AutoApply).To(BeFalse())
 		})
+
+		It("should report drifted layers with a warning state", func() {
+			drifted := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "drifted-layer",
+					Namespace: "default",
+					Annotations: map[string]string{
+						annotations.LastPlanSum: "AuP6pMNxWsbSZKnxZvxD842wy0qaF9JCX8HW1nFeL1I=",
+					},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/vpc",
+					Branch: "main",
+					Repository: configv1alpha1.TerraformLayerRepository{
+						Name:      "my-repo",
+						Namespace: "default",
+					},
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					State: "Idle",
+					Conditions: []metav1.Condition{
+						{Type: "Drifted", Status: metav1.ConditionTrue, Reason: "ResourcesDrifted"},
+					},
+				},
+			}
+
+			repo := &configv1alpha1.TerraformRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-repo",
+					Namespace: "default",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(drifted, repo).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/layers", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := a.LayersHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var resp struct {
+				Results []struct {
+					Name  string `json:"name"`
+					State string `json:"state"`
+				} `json:"results"`
+			}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).NotTo(HaveOccurred())
+			Expect(resp.Results).To(HaveLen(1))
+			Expect(resp.Results[0].State).To(Equal("warning"))
+		})
+
+		It("should use the plan summary to report layers without changes as successful", func() {
+			noChanges := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "no-changes-layer",
+					Namespace: "default",
+					Annotations: map[string]string{
+						annotations.LastPlanSum:        "AuP6pMNxWsbSZKnxZvxD842wy0qaF9JCX8HW1nFeL1I=",
+						annotations.LastPlanHasChanges: "false",
+					},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/vpc",
+					Branch: "main",
+					Repository: configv1alpha1.TerraformLayerRepository{
+						Name:      "my-repo",
+						Namespace: "default",
+					},
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					State:      "ApplyNeeded",
+					LastResult: "Partial Plan: 0 to create, 0 to update, 0 to delete",
+					Conditions: []metav1.Condition{
+						{Type: "IsApplyUpToDate", Status: metav1.ConditionFalse, Reason: "NewPlanNotApplied"},
+					},
+				},
+			}
+
+			repo := &configv1alpha1.TerraformRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-repo",
+					Namespace: "default",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(noChanges, repo).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/layers", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := a.LayersHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var resp struct {
+				Results []struct {
+					Name  string `json:"name"`
+					State string `json:"state"`
+				} `json:"results"`
+			}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).NotTo(HaveOccurred())
+			Expect(resp.Results).To(HaveLen(1))
+			Expect(resp.Results[0].State).To(Equal("success"))
+		})
+
+		It("should report layers violating their policies with an error state", func() {
+			violating := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "violating-layer",
+					Namespace: "default",
+					Annotations: map[string]string{
+						annotations.LastPlanSum:          "AuP6pMNxWsbSZKnxZvxD842wy0qaF9JCX8HW1nFeL1I=",
+						annotations.LastPlanPolicyPassed: "false",
+					},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/vpc",
+					Branch: "main",
+					Repository: configv1alpha1.TerraformLayerRepository{
+						Name:      "my-repo",
+						Namespace: "default",
+					},
+				},
+				Status: configv1alpha1.TerraformLayerStatus{
+					State: "ApplyNeeded",
+					Conditions: []metav1.Condition{
+						{Type: "PolicyPassed", Status: metav1.ConditionFalse, Reason: "PolicyViolated"},
+					},
+				},
+			}
+
+			repo := &configv1alpha1.TerraformRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-repo",
+					Namespace: "default",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(violating, repo).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/layers", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := a.LayersHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var resp struct {
+				Results []struct {
+					Name  string `json:"name"`
+					State string `json:"state"`
+				} `json:"results"`
+			}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).NotTo(HaveOccurred())
+			Expect(resp.Results).To(HaveLen(1))
+			Expect(resp.Results[0].State).To(Equal("error"))
+		})
+	})
+
+	Describe("LayersGraphHandler", func() {
+		It("should return the dependencies between layers and their cycles", func() {
+			type edge struct {
+				From string `json:"from"`
+				To   string `json:"to"`
+			}
+			network := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "network", Namespace: "default"},
+			}
+			cluster := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DependsOn: []configv1alpha1.TerraformLayerReference{{Name: "network"}, {Name: "dns", Namespace: "shared"}},
+				},
+			}
+			loopA := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "loop-a", Namespace: "default"},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DependsOn: []configv1alpha1.TerraformLayerReference{{Name: "loop-b"}},
+				},
+			}
+			loopB := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Name: "loop-b", Namespace: "default"},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DependsOn: []configv1alpha1.TerraformLayerReference{{Name: "loop-a"}},
+				},
+			}
+			prLayer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cluster-pr-1-abcde",
+					Namespace:       "default",
+					OwnerReferences: []metav1.OwnerReference{{Kind: "TerraformPullRequest", Name: "pr-1"}},
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(network, cluster, loopA, loopB, prLayer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/layers/graph", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := a.LayersGraphHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var resp struct {
+				Nodes []struct {
+					ID     string `json:"id"`
+					Exists bool   `json:"exists"`
+				} `json:"nodes"`
+				Edges  []edge   `json:"edges"`
+				Cycles []string `json:"cycles"`
+			}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).NotTo(HaveOccurred())
+
+			ids := []string{}
+			for _, node := range resp.Nodes {
+				ids = append(ids, node.ID)
+				Expect(node.Exists).To(Equal(node.ID != "shared/dns"))
+			}
+			Expect(ids).To(Equal([]string{"default/cluster", "default/loop-a", "default/loop-b", "default/network", "shared/dns"}))
+			Expect(resp.Edges).To(ConsistOf(
+				edge{"default/network", "default/cluster"},
+				edge{"shared/dns", "default/cluster"},
+				edge{"default/loop-b", "default/loop-a"},
+				edge{"default/loop-a", "default/loop-b"},
+			))
+			Expect(resp.Cycles).To(Equal([]string{"default/loop-a", "default/loop-b"}))
+		})
 	})
 })
@@ -1,13 +1,23 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/datastore/storage"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Period between two reads of the logs in the datastore when streaming them
+var LogsStreamInterval = 2 * time.Second
+
 type GetLogsResponse struct {
 	Results []string `json:"results"`
 }
@@ -52,3 +62,117 @@ func (a *API) DownloadLogsHandler(c echo.Context) error {
 	c.Response().Header().Set("Content-Type", "application/octet-stream")
 	return c.Blob(http.StatusOK, "application/octet-stream", []byte(file))
 }
+
+// logs/${namespace}/${layer}/${runId}/${attemptId}/stream
+// Streams the logs of an attempt as server-sent events, until the attempt is finished. The logs are
+// streamed from the parts uploaded by the runner, the id of the events is the number of the part
+// they belong to, so that a client reconnecting with the Last-Event-ID header resumes after it.
+func (a *API) StreamLogsHandler(c echo.Context) error {
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	next := 0
+	if id := c.Request().Header.Get("Last-Event-ID"); id != "" {
+		last, err := strconv.Atoi(id)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid Last-Event-ID header")
+		}
+		next = last + 1
+	}
+	ctx := c.Request().Context()
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(LogsStreamInterval)
+	defer ticker.Stop()
+	for {
+		// Check the attempt before reading the logs, the final logs are uploaded before the run ends
+		finished, err := a.isAttemptFinished(ctx, namespace, run, attempt)
+		if err != nil {
+			writeEvent(res, "error", "could not get run, it may have been deleted")
+			return nil
+		}
+		parts, err := a.Datastore.GetLogsParts(namespace, layer, run, attempt, next)
+		if err != nil {
+			writeEvent(res, "error", "could not get logs, there's an issue with the storage backend")
+			return nil
+		}
+		for _, part := range parts {
+			// A part being uploaded again after a failure is waited for
+			if part.Number != next {
+				break
+			}
+			writePart(res, part)
+			next++
+		}
+		if finished {
+			// The runner could not stream its logs, they have been uploaded whole by the controller
+			if next == 0 {
+				lines, err := a.Datastore.GetLogs(namespace, layer, run, attempt)
+				if err != nil && !storageerrors.NotFound(err) {
+					writeEvent(res, "error", "could not get logs, there's an issue with the storage backend")
+					return nil
+				}
+				for _, line := range lines {
+					writeEvent(res, "", line)
+				}
+			}
+			writeEvent(res, "end", "")
+			return nil
+		}
+		res.Flush()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *API) isAttemptFinished(ctx context.Context, namespace, name, attempt string) (bool, error) {
+	run := &configv1alpha1.TerraformRun{}
+	err := a.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, run)
+	if err != nil {
+		return false, err
+	}
+	if !runStillRunning(*run) {
+		return true, nil
+	}
+	number, err := strconv.Atoi(attempt)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range run.Status.Attempts {
+		if a.Number == number && a.LogsUploaded {
+			return true, nil
+		}
+	}
+	// A newer attempt has been started
+	return run.Status.Retries > number, nil
+}
+
+// Write the lines of a part of the logs as events, the last one carrying the number of the part
+func writePart(res *echo.Response, part storage.LogsPart) {
+	content := strings.TrimSuffix(string(part.Content), "\n")
+	if content == "" {
+		return
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			fmt.Fprintf(res, "id: %d\n", part.Number)
+		}
+		writeEvent(res, "", line)
+	}
+}
+
+func writeEvent(res *echo.Response, event, data string) {
+	if event != "" {
+		fmt.Fprintf(res, "event: %s\n", event)
+	}
+	fmt.Fprintf(res, "data: %s\n\n", data)
+}
@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/datastore/storage"
+	"github.com/padok-team/burrito/internal/server/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type logsDatastore struct {
+	datastore.MockClient
+	logs  []string
+	parts []storage.LogsPart
+}
+
+func (d *logsDatastore) GetLogs(namespace string, layer string, run string, attempt string) ([]string, error) {
+	return d.logs, nil
+}
+
+func (d *logsDatastore) GetLogsParts(namespace string, layer string, run string, attempt string, from int) ([]storage.LogsPart, error) {
+	parts := []storage.LogsPart{}
+	for _, part := range d.parts {
+		if part.Number >= from {
+			parts = append(parts, part)
+		}
+	}
+	return parts, nil
+}
+
+var _ = Describe("Logs API", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		e = echo.New()
+		api.LogsStreamInterval = 10 * time.Millisecond
+	})
+
+	Describe("StreamLogsHandler", func() {
+		It("should stream the logs uploaded by the controller when the runner did not stream them", func() {
+			run := &configv1alpha1.TerraformRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-run",
+					Namespace: "default",
+				},
+				Status: configv1alpha1.TerraformRunStatus{
+					State: "Succeeded",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(run).
+				WithStatusSubresource(run).
+				Build()
+
+			a := &api.API{Client: fakeClient, Datastore: &logsDatastore{logs: []string{"Initializing...", "Apply complete!"}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/logs/default/my-layer/my-run/0/stream", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run", "attempt"}, []string{"default", "my-layer", "my-run", "0"})
+
+			err := a.StreamLogsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal("text/event-stream"))
+			Expect(rec.Body.String()).To(Equal("data: Initializing...\n\ndata: Apply complete!\n\nevent: end\ndata: \n\n"))
+		})
+
+		It("should stream the parts of the logs of a finished attempt", func() {
+			run := &configv1alpha1.TerraformRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-run",
+					Namespace: "default",
+				},
+				Status: configv1alpha1.TerraformRunStatus{
+					State: "Succeeded",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(run).
+				WithStatusSubresource(run).
+				Build()
+
+			a := &api.API{Client: fakeClient, Datastore: &logsDatastore{
+				logs: []string{"Initializing...", "Apply complete!"},
+				parts: []storage.LogsPart{
+					{Number: 0, Content: []byte("[1 bytes of earlier logs truncated]\nInitializing...\n")},
+					{Number: 1, Final: true, Content: []byte("Apply complete!")},
+				},
+			}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/logs/default/my-layer/my-run/0/stream", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run", "attempt"}, []string{"default", "my-layer", "my-run", "0"})
+
+			err := a.StreamLogsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Body.String()).To(Equal("data: [1 bytes of earlier logs truncated]\n\nid: 0\ndata: Initializing...\n\nid: 1\ndata: Apply complete!\n\nevent: end\ndata: \n\n"))
+		})
+
+		It("should resume after the part of the last event received", func() {
+			run := &configv1alpha1.TerraformRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "running-run",
+					Namespace: "default",
+				},
+				Status: configv1alpha1.TerraformRunStatus{
+					State:   "Running",
+					Retries: 0,
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(run).
+				WithStatusSubresource(run).
+				Build()
+
+			a := &api.API{Client: fakeClient, Datastore: &logsDatastore{parts: []storage.LogsPart{
+				{Number: 0, Content: []byte("Initializing...\n")},
+				{Number: 1, Content: []byte("Plan: 1 to add\n")},
+				{Number: 3, Content: []byte("Apply complete!\n")},
+			}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/logs/default/my-layer/running-run/0/stream", nil)
+			req.Header.Set("Last-Event-ID", "0")
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run", "attempt"}, []string{"default", "my-layer", "running-run", "0"})
+
+			err := a.StreamLogsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Body.String()).To(Equal("id: 1\ndata: Plan: 1 to add\n\n"))
+		})
+
+		It("should return an error event when the run does not exist", func() {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				Build()
+
+			a := &api.API{Client: fakeClient, Datastore: &logsDatastore{}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/logs/default/my-layer/nonexistent/0/stream", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run", "attempt"}, []string{"default", "my-layer", "nonexistent", "0"})
+
+			err := a.StreamLogsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Body.String()).To(HavePrefix("event: error\n"))
+		})
+	})
+})
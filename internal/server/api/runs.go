@@ -2,11 +2,17 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -36,3 +42,120 @@ func (a *API) GetAttemptsHandler(c echo.Context) error {
 	response := GetAttemptsResponse{Count: len(runObject.Status.Attempts)}
 	return c.JSON(http.StatusOK, &response)
 }
+
+// run/${namespace}/${layer}/${runId}/summary?attempt=${attemptId}
+// Returns the structured summary of the plan of a run, for its latest attempt by default
+func (a *API) GetPlanSummaryHandler(c echo.Context) error {
+	namespace := c.Param("namespace")
+	layer := c.Param("layer")
+	run := c.Param("run")
+	if namespace == "" || layer == "" || run == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	content, err := a.Datastore.GetPlan(namespace, layer, run, c.QueryParam("attempt"), "summary")
+	if storageerrors.NotFound(err) {
+		return c.String(http.StatusNotFound, "no plan summary for this run")
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not get plan summary, there's an issue with the storage backend")
+	}
+	summary := runnerutils.PlanSummary{}
+	if err := json.Unmarshal(content, &summary); err != nil {
+		return c.String(http.StatusInternalServerError, "could not parse plan summary")
+	}
+	return c.JSON(http.StatusOK, &summary)
+}
+
+type GetTimelineResponse struct {
+	Resources   []configv1alpha1.ResourceResult `json:"resources"`
+	Diagnostics []runnerutils.JSONDiagnostic    `json:"diagnostics"`
+}
+
+// run/${namespace}/${layer}/${runId}/timeline?attempt=${attemptId}
+// Returns the timeline of the resources of an apply, and its diagnostics, for its latest attempt by default.
+// It is only available when the runner uses the machine-readable output of terraform.
+func (a *API) GetTimelineHandler(c echo.Context) error {
+	namespace := c.Param("namespace")
+	layer := c.Param("layer")
+	run := c.Param("run")
+	if namespace == "" || layer == "" || run == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	content, err := a.Datastore.GetPlan(namespace, layer, run, c.QueryParam("attempt"), "events")
+	if storageerrors.NotFound(err) {
+		return c.String(http.StatusNotFound, "no events for this run")
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not get events, there's an issue with the storage backend")
+	}
+	events := []runnerutils.JSONEvent{}
+	if err := json.Unmarshal(content, &events); err != nil {
+		return c.String(http.StatusInternalServerError, "could not parse events")
+	}
+	response := GetTimelineResponse{
+		Resources:   runnerutils.GetResourceTimeline(events),
+		Diagnostics: []runnerutils.JSONDiagnostic{},
+	}
+	for _, event := range events {
+		if event.Diagnostic != nil {
+			response.Diagnostics = append(response.Diagnostics, *event.Diagnostic)
+		}
+	}
+	return c.JSON(http.StatusOK, &response)
+}
+
+// run/${namespace}/${layer}/${runId}/outputs?attempt=${attemptId}
+// Returns the outputs captured after an apply, for its latest attempt by default. The values of
+// sensitive outputs are never returned.
+func (a *API) GetOutputsHandler(c echo.Context) error {
+	namespace := c.Param("namespace")
+	layer := c.Param("layer")
+	run := c.Param("run")
+	if namespace == "" || layer == "" || run == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	content, err := a.Datastore.GetOutputs(namespace, layer, run, c.QueryParam("attempt"))
+	if storageerrors.NotFound(err) {
+		return c.String(http.StatusNotFound, "no outputs for this run")
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not get outputs, there's an issue with the storage backend")
+	}
+	outputs, err := runnerutils.ParseOutputs(content)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not parse outputs")
+	}
+	return c.JSON(http.StatusOK, outputs.Redact())
+}
+
+// run/${namespace}/${layer}/${runId}/cancel
+// Cancels a run, its runner interrupts terraform and the run ends in the Cancelled state
+func (a *API) CancelRunHandler(c echo.Context) error {
+	namespace, run, err := getRunAttemptArgs(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	runObject := &configv1alpha1.TerraformRun{}
+	err = a.Client.Get(context.Background(), types.NamespacedName{Name: run, Namespace: namespace}, runObject)
+	if errors.IsNotFound(err) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Run not found"})
+	}
+	if err != nil {
+		log.Errorf("could not get terraform run: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the run"})
+	}
+	if !runStillRunning(*runObject) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Run has already finished"})
+	}
+	if _, ok := runObject.Annotations[annotations.CancelRun]; ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Run cancellation already triggered"})
+	}
+	err = annotations.Add(context.Background(), a.Client, runObject, map[string]string{
+		annotations.CancelRun: "true",
+	})
+	if err != nil {
+		log.Errorf("could not update terraform run annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the run annotations"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "Run cancellation triggered"})
+}
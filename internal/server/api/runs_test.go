@@ -0,0 +1,211 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/server/api"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type plansDatastore struct {
+	datastore.MockClient
+	plans map[string]string
+}
+
+func (d *plansDatastore) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
+	plan, ok := d.plans[format]
+	if !ok {
+		return nil, &storageerrors.StorageError{Nil: true}
+	}
+	return []byte(plan), nil
+}
+
+func (d *plansDatastore) GetOutputs(namespace string, layer string, run string, attempt string) ([]byte, error) {
+	return d.GetPlan(namespace, layer, run, attempt, "outputs")
+}
+
+var _ = Describe("Runs API", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		e = echo.New()
+	})
+
+	Describe("GetPlanSummaryHandler", func() {
+		It("should return the plan summary of a run", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{
+				"summary": `{"create":0,"update":0,"delete":0,"replace":1,"resources":[{"address":"aws_instance.web","actions":["delete","create"],"replace":true}],"outputs":[]}`,
+			}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/summary", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetPlanSummaryHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			summary := runnerutils.PlanSummary{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &summary)).To(Succeed())
+			Expect(summary.Replace).To(Equal(1))
+			Expect(summary.Resources).To(Equal([]runnerutils.ResourceSummary{
+				{Address: "aws_instance.web", Actions: []string{"delete", "create"}, Replace: true},
+			}))
+		})
+
+		It("should return not found when the run has no plan summary", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/summary", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetPlanSummaryHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("GetOutputsHandler", func() {
+		It("should return the outputs of a run without the values of sensitive ones", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{
+				"outputs": `{"vpc_id":{"sensitive":false,"type":"string","value":"vpc-0123"},"db_password":{"sensitive":true,"type":"string","value":"hunter2"}}`,
+			}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/outputs", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetOutputsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			outputs := runnerutils.Outputs{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &outputs)).To(Succeed())
+			Expect(outputs["vpc_id"].String()).To(Equal("vpc-0123"))
+			Expect(outputs["db_password"].Sensitive).To(BeTrue())
+			Expect(outputs["db_password"].Value).To(BeNil())
+		})
+
+		It("should return not found when the run has no outputs", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/outputs", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetOutputsHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("GetTimelineHandler", func() {
+		It("should return the timeline of the resources of a run", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{
+				"events": `[
+					{"@level":"info","@message":"random_pet.this: Creating...","@timestamp":"2024-05-08T12:00:01Z","type":"apply_start","hook":{"resource":{"addr":"random_pet.this"},"action":"create"}},
+					{"@level":"info","@message":"random_pet.this: Creation complete after 2s","@timestamp":"2024-05-08T12:00:03Z","type":"apply_complete","hook":{"resource":{"addr":"random_pet.this"},"action":"create","elapsed_seconds":2}},
+					{"@level":"warn","@message":"Warning: deprecated argument","@timestamp":"2024-05-08T12:00:04Z","type":"diagnostic","diagnostic":{"severity":"warning","summary":"deprecated argument"}}
+				]`,
+			}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/timeline", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetTimelineHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			timeline := api.GetTimelineResponse{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &timeline)).To(Succeed())
+			Expect(timeline.Resources).To(HaveLen(1))
+			Expect(timeline.Resources[0].Address).To(Equal("random_pet.this"))
+			Expect(timeline.Resources[0].State).To(Equal(runnerutils.ResourceStateApplied))
+			Expect(timeline.Resources[0].Duration.Duration).To(Equal(2 * time.Second))
+			Expect(timeline.Diagnostics).To(Equal([]runnerutils.JSONDiagnostic{
+				{Severity: "warning", Summary: "deprecated argument"},
+			}))
+		})
+
+		It("should return not found when the run has no events", func() {
+			a := &api.API{Datastore: &plansDatastore{plans: map[string]string{}}}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/run/default/my-layer/my-run/timeline", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+
+			err := a.GetTimelineHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("CancelRunHandler", func() {
+		newRun := func(state string) *configv1alpha1.TerraformRun {
+			return &configv1alpha1.TerraformRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-run",
+					Namespace:   "default",
+					Annotations: map[string]string{},
+				},
+				Status: configv1alpha1.TerraformRunStatus{State: state},
+			}
+		}
+		cancel := func(a *api.API) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/run/default/my-layer/my-run/cancel", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer", "run"}, []string{"default", "my-layer", "my-run"})
+			Expect(a.CancelRunHandler(c)).To(Succeed())
+			return rec
+		}
+
+		It("should annotate a running run", func() {
+			fakeClient := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newRun("Running")).Build()
+
+			rec := cancel(&api.API{Client: fakeClient})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			run := &configv1alpha1.TerraformRun{}
+			Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "my-run"}, run)).To(Succeed())
+			Expect(run.Annotations).To(HaveKeyWithValue(annotations.CancelRun, "true"))
+		})
+
+		It("should refuse to cancel a finished run", func() {
+			fakeClient := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(newRun("Succeeded")).Build()
+
+			rec := cancel(&api.API{Client: fakeClient})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should return not found for an unknown run", func() {
+			fakeClient := fake.NewClientBuilder().WithScheme(newScheme()).Build()
+
+			rec := cancel(&api.API{Client: fakeClient})
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
@@ -26,10 +28,64 @@ func (a *API) SyncLayerHandler(c echo.Context) error {
 	if syncStatus == utils.ManualSyncAnnotated || syncStatus == utils.ManualSyncPending {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer sync already triggered"})
 	}
+	// The body is optional, it restricts the plan with targets or replaces
+	options := configv1alpha1.PlanOptions{}
+	if err := c.Bind(&options); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	return a.triggerSync(c, layer, options)
+}
 
-	err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
-		annotations.SyncNow: "true",
+type replaceRequest struct {
+	Addresses []string `json:"addresses"`
+	AutoApply bool     `json:"autoApply"`
+}
+
+func (a *API) ReplaceLayerHandler(c echo.Context) error {
+	layer := &configv1alpha1.TerraformLayer{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("layer"),
+	}, layer)
+	if err != nil {
+		log.Errorf("could not get terraform layer: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the layer"})
+	}
+	syncStatus := utils.GetManualSyncStatus(*layer)
+	if syncStatus == utils.ManualSyncAnnotated || syncStatus == utils.ManualSyncPending {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer sync already triggered"})
+	}
+	request := replaceRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if len(request.Addresses) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one resource address to replace is required"})
+	}
+	return a.triggerSync(c, layer, configv1alpha1.PlanOptions{
+		Replaces:  request.Addresses,
+		AutoApply: request.AutoApply,
 	})
+}
+
+func (a *API) triggerSync(c echo.Context, layer *configv1alpha1.TerraformLayer, options configv1alpha1.PlanOptions) error {
+	for _, address := range append(append([]string{}, options.Targets...), options.Replaces...) {
+		if strings.TrimSpace(address) == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Resource addresses can not be empty"})
+		}
+	}
+	ann := map[string]string{
+		annotations.SyncNow: "true",
+	}
+	if options.IsPartial() {
+		value, err := json.Marshal(options)
+		if err != nil {
+			log.Errorf("could not marshal sync options: %s", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while computing the sync options"})
+		}
+		ann[annotations.SyncOptions] = string(value)
+	}
+	err := annotations.Add(context.Background(), a.Client, layer, ann)
 	if err != nil {
 		log.Errorf("could not update terraform layer annotations: %s", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
@@ -61,3 +117,32 @@ func (a *API) ApplyLayerHandler(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, map[string]string{"status": "Layer apply triggered"})
 }
+
+func (a *API) DestroyLayerHandler(c echo.Context) error {
+	layer := &configv1alpha1.TerraformLayer{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("layer"),
+	}, layer)
+	if err != nil {
+		log.Errorf("could not get terraform layer: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the layer"})
+	}
+	// Check if layer is managed by TerraformPullRequest controller
+	if managedBy, exists := layer.Labels["burrito/managed-by"]; exists && managedBy != "" {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Manual destroy is not allowed on layers managed by TerraformPullRequest controller"})
+	}
+	destroyStatus := utils.GetManualDestroyStatus(*layer)
+	if destroyStatus == utils.ManualSyncAnnotated || destroyStatus == utils.ManualSyncPending {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer destroy already triggered"})
+	}
+	// Add destroy annotation to trigger a destroy plan
+	err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
+		annotations.DestroyNow: "true",
+	})
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "Layer destroy triggered"})
+}
@@ -1,9 +1,11 @@
 package api_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	. "github.com/onsi/ginkgo/v2"
@@ -13,6 +15,7 @@ import (
 	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/server/api"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -229,6 +232,221 @@ var _ = Describe("Sync API", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
 		})
+
+		It("should store the plan options when targets are given", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "targeted-layer",
+					Namespace:   "default",
+					Annotations: map[string]string{},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/targeted-layer/sync", strings.NewReader(`{"targets":["module.app"]}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "targeted-layer"})
+
+			err := a.SyncLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			updated := &configv1alpha1.TerraformLayer{}
+			Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "targeted-layer"}, updated)).To(Succeed())
+			Expect(updated.Annotations[annotations.SyncNow]).To(Equal("true"))
+			options := configv1alpha1.PlanOptions{}
+			Expect(json.Unmarshal([]byte(updated.Annotations[annotations.SyncOptions]), &options)).To(Succeed())
+			Expect(options.Targets).To(Equal([]string{"module.app"}))
+			Expect(options.AutoApply).To(BeFalse())
+		})
+	})
+
+	Describe("ReplaceLayerHandler", func() {
+		It("should trigger a sync replacing the given addresses", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "replace-layer",
+					Namespace:   "default",
+					Annotations: map[string]string{},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/replace-layer/replace", strings.NewReader(`{"addresses":["aws_instance.web"],"autoApply":true}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "replace-layer"})
+
+			err := a.ReplaceLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			updated := &configv1alpha1.TerraformLayer{}
+			Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "replace-layer"}, updated)).To(Succeed())
+			options := configv1alpha1.PlanOptions{}
+			Expect(json.Unmarshal([]byte(updated.Annotations[annotations.SyncOptions]), &options)).To(Succeed())
+			Expect(options.Replaces).To(Equal([]string{"aws_instance.web"}))
+			Expect(options.AutoApply).To(BeTrue())
+		})
+
+		It("should reject a request without addresses", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "replace-empty",
+					Namespace:   "default",
+					Annotations: map[string]string{},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/replace-empty/replace", strings.NewReader(`{"addresses":[]}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "replace-empty"})
+
+			err := a.ReplaceLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("DestroyLayerHandler", func() {
+		It("should trigger destroy on an existing layer", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "destroy-layer",
+					Namespace:   "default",
+					Annotations: map[string]string{},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/destroy-layer/destroy", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "destroy-layer"})
+
+			err := a.DestroyLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var body map[string]string
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).NotTo(HaveOccurred())
+			Expect(body["status"]).To(Equal("Layer destroy triggered"))
+
+			updated := &configv1alpha1.TerraformLayer{}
+			Expect(fakeClient.Get(context.Background(), client.ObjectKeyFromObject(layer), updated)).To(Succeed())
+			Expect(updated.Annotations).To(HaveKeyWithValue(annotations.DestroyNow, "true"))
+		})
+
+		It("should return conflict when destroy is already pending", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "already-destroying",
+					Namespace: "default",
+					Annotations: map[string]string{
+						annotations.DestroyNow: "true",
+					},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/already-destroying/destroy", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "already-destroying"})
+
+			err := a.DestroyLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should return conflict when layer is managed by TerraformPullRequest", func() {
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pr-layer",
+					Namespace: "default",
+					Labels: map[string]string{
+						"burrito/managed-by": "terraform-pullrequest",
+					},
+					Annotations: map[string]string{},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "modules/test",
+					Branch: "main",
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithObjects(layer).
+				Build()
+
+			a := &api.API{Client: fakeClient}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/layers/default/pr-layer/destroy", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			setRouteParams(c, []string{"namespace", "layer"}, []string{"default", "pr-layer"})
+
+			err := a.DestroyLayerHandler(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
 	})
 
 })
@@ -110,10 +110,11 @@ func (o *OAuthAuthHandlers) HandleCallback(c echo.Context) error {
 
 	// Extract claims
 	var claims struct {
-		Sub     string `json:"sub"`
-		Email   string `json:"email"`
-		Name    string `json:"name"`
-		Picture string `json:"picture"`
+		Sub     string   `json:"sub"`
+		Email   string   `json:"email"`
+		Name    string   `json:"name"`
+		Picture string   `json:"picture"`
+		Groups  []string `json:"groups"`
 	}
 	if err := idToken.Claims(&claims); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to extract claims")
@@ -127,6 +128,7 @@ func (o *OAuthAuthHandlers) HandleCallback(c echo.Context) error {
 	sess.Values["email"] = claims.Email
 	sess.Values["name"] = claims.Name
 	sess.Values["picture"] = claims.Picture
+	sess.Values["groups"] = claims.Groups
 	delete(sess.Values, "oauth_state") // Clean up state
 	if err := sess.Save(c.Request(), c.Response()); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save session")
@@ -167,11 +167,22 @@ func (s *Server) Exec() {
 	// Logger middleware should be applied after auth middleware to be able log user info
 	api.Use(middleware.RequestLoggerWithConfig(utils.LoggerMiddlewareConfig))
 	api.GET("/layers", s.API.LayersHandler)
+	api.GET("/layers/graph", s.API.LayersGraphHandler)
 	api.POST("/layers/:namespace/:layer/sync", s.API.SyncLayerHandler)
+	api.POST("/layers/:namespace/:layer/replace", s.API.ReplaceLayerHandler)
 	api.POST("/layers/:namespace/:layer/apply", s.API.ApplyLayerHandler)
+	api.POST("/layers/:namespace/:layer/destroy", s.API.DestroyLayerHandler)
+	api.GET("/layers/:namespace/:layer/approvals", s.API.GetApprovalsHandler)
+	api.POST("/layers/:namespace/:layer/approve", s.API.ApprovePlanHandler)
+	api.POST("/layers/:namespace/:layer/reject", s.API.RejectPlanHandler)
 	api.GET("/repositories", s.API.RepositoriesHandler)
 	api.GET("/logs/:namespace/:layer/:run/:attempt", s.API.GetLogsHandler)
+	api.GET("/logs/:namespace/:layer/:run/:attempt/stream", s.API.StreamLogsHandler)
 	api.GET("/run/:namespace/:layer/:run/attempts", s.API.GetAttemptsHandler)
+	api.GET("/run/:namespace/:layer/:run/summary", s.API.GetPlanSummaryHandler)
+	api.GET("/run/:namespace/:layer/:run/timeline", s.API.GetTimelineHandler)
+	api.GET("/run/:namespace/:layer/:run/outputs", s.API.GetOutputsHandler)
+	api.POST("/run/:namespace/:layer/:run/cancel", s.API.CancelRunHandler)
 
 	// Redirect root to layers if authenticated, otherwise to login
 	e.GET("/", func(c echo.Context) error {
@@ -217,6 +228,9 @@ func (s *Server) authMiddleware() echo.MiddlewareFunc {
 			if picture, ok := sess.Values["picture"].(string); ok {
 				c.Set("user_picture", picture)
 			}
+			if groups, ok := sess.Values["groups"].([]string); ok {
+				c.Set("user_groups", groups)
+			}
 			return next(c)
 		}
 	}
@@ -38,3 +38,16 @@ func GetManualApplyStatus(layer configv1alpha1.TerraformLayer) ManualSyncStatus
 	}
 	return ManualSyncNone
 }
+
+func GetManualDestroyStatus(layer configv1alpha1.TerraformLayer) ManualSyncStatus {
+	if layer.Annotations[annotations.DestroyNow] == "true" {
+		return ManualSyncAnnotated
+	}
+	// check the IsDestroyScheduled condition on layer
+	for _, c := range layer.Status.Conditions {
+		if c.Type == "IsDestroyScheduled" && c.Status == "True" {
+			return ManualSyncPending
+		}
+	}
+	return ManualSyncNone
+}
@@ -0,0 +1,81 @@
+package approval
+
+import (
+	"encoding/json"
+	"slices"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+)
+
+// An Identity is a user of the server, as authenticated by its session
+type Identity struct {
+	ID     string
+	Email  string
+	Groups []string
+}
+
+// Name identifies the user in the approvals, by its email when it is known
+func (i Identity) Name() string {
+	if i.Email != "" {
+		return i.Email
+	}
+	return i.ID
+}
+
+// Required returns the number of approvals required by the policy
+func Required(policy configv1alpha1.ApprovalPolicy) int {
+	if policy.Required == nil {
+		return 0
+	}
+	return *policy.Required
+}
+
+// IsAllowed returns true when the user can approve or reject plans under the policy
+func IsAllowed(policy configv1alpha1.ApprovalPolicy, identity Identity) bool {
+	if len(policy.Users) == 0 && len(policy.Groups) == 0 {
+		return true
+	}
+	if slices.Contains(policy.Users, identity.ID) || (identity.Email != "" && slices.Contains(policy.Users, identity.Email)) {
+		return true
+	}
+	for _, group := range identity.Groups {
+		if slices.Contains(policy.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Current returns the approvals of the last plan of the layer. Approvals recorded for another
+// plan, or which can not be read, are discarded.
+func Current(layer *configv1alpha1.TerraformLayer) configv1alpha1.PlanApprovals {
+	current := configv1alpha1.PlanApprovals{
+		Run:     layer.Annotations[annotations.LastPlanRun],
+		PlanSum: layer.Annotations[annotations.LastPlanSum],
+	}
+	value, ok := layer.Annotations[annotations.Approvals]
+	if !ok {
+		return current
+	}
+	recorded := configv1alpha1.PlanApprovals{}
+	if err := json.Unmarshal([]byte(value), &recorded); err != nil {
+		return current
+	}
+	if recorded.Run != current.Run || recorded.PlanSum != current.PlanSum {
+		return current
+	}
+	return recorded
+}
+
+// Pending returns the number of approvals still missing for the plan
+func Pending(policy configv1alpha1.ApprovalPolicy, approvals configv1alpha1.PlanApprovals) int {
+	return max(Required(policy)-len(approvals.Approvals), 0)
+}
+
+// HasApproved returns true when the user has already approved the plan
+func HasApproved(approvals configv1alpha1.PlanApprovals, identity Identity) bool {
+	return slices.ContainsFunc(approvals.Approvals, func(record configv1alpha1.ApprovalRecord) bool {
+		return record.User == identity.Name()
+	})
+}
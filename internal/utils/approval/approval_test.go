@@ -0,0 +1,69 @@
+package approval_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/utils/approval"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApproval(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Approval Suite")
+}
+
+var _ = Describe("Approval", func() {
+	two := 2
+	policy := configv1alpha1.ApprovalPolicy{
+		Required: &two,
+		Users:    []string{"alice@example.com"},
+		Groups:   []string{"platform"},
+	}
+
+	Describe("IsAllowed", func() {
+		It("should allow the listed users by email or ID", func() {
+			Expect(approval.IsAllowed(policy, approval.Identity{ID: "123", Email: "alice@example.com"})).To(BeTrue())
+			Expect(approval.IsAllowed(configv1alpha1.ApprovalPolicy{Users: []string{"123"}}, approval.Identity{ID: "123"})).To(BeTrue())
+		})
+		It("should allow the members of the listed groups", func() {
+			Expect(approval.IsAllowed(policy, approval.Identity{ID: "456", Groups: []string{"dev", "platform"}})).To(BeTrue())
+		})
+		It("should refuse the other users", func() {
+			Expect(approval.IsAllowed(policy, approval.Identity{ID: "456", Email: "bob@example.com", Groups: []string{"dev"}})).To(BeFalse())
+		})
+		It("should allow any user when no users nor groups are listed", func() {
+			Expect(approval.IsAllowed(configv1alpha1.ApprovalPolicy{Required: &two}, approval.Identity{ID: "456"})).To(BeTrue())
+		})
+	})
+
+	Describe("Current", func() {
+		layer := func(approvals string) *configv1alpha1.TerraformLayer {
+			return &configv1alpha1.TerraformLayer{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				annotations.LastPlanRun: "run-2/0",
+				annotations.LastPlanSum: "sum-2",
+				annotations.Approvals:   approvals,
+			}}}
+		}
+		It("should return the approvals of the last plan", func() {
+			current := approval.Current(layer(`{"run":"run-2/0","planSum":"sum-2","approvals":[{"user":"alice@example.com","time":"2024-05-08T12:00:00Z"}]}`))
+			Expect(current.Approvals).To(HaveLen(1))
+			Expect(approval.Pending(policy, current)).To(Equal(1))
+			Expect(approval.HasApproved(current, approval.Identity{Email: "alice@example.com"})).To(BeTrue())
+		})
+		It("should discard the approvals of an older plan", func() {
+			current := approval.Current(layer(`{"run":"run-1/0","planSum":"sum-1","approvals":[{"user":"alice@example.com","time":"2024-05-08T12:00:00Z"}]}`))
+			Expect(current.Run).To(Equal("run-2/0"))
+			Expect(current.PlanSum).To(Equal("sum-2"))
+			Expect(current.Approvals).To(BeEmpty())
+			Expect(approval.Pending(policy, current)).To(Equal(2))
+		})
+		It("should discard approvals which can not be read", func() {
+			current := approval.Current(layer("not json"))
+			Expect(current.Approvals).To(BeEmpty())
+		})
+	})
+})
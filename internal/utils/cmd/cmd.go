@@ -1,16 +1,89 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
 func Verbose(cmd *exec.Cmd) {
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+}
+
+// VerboseTo is like Verbose, with the standard output of the command written to w instead when it is not nil
+func VerboseTo(cmd *exec.Cmd, w io.Writer) {
+	Verbose(cmd)
+	if w != nil {
+		cmd.Stdout = w
+	}
+}
+
+// Stdout returns the writer used as standard output of the commands made verbose
+func Stdout() io.Writer {
+	return stdout
+}
+
+// Tee copies the output of the commands made verbose to w, in addition to stdout and stderr
+func Tee(w io.Writer) {
+	stdout = io.MultiWriter(os.Stdout, w)
+	stderr = io.MultiWriter(os.Stderr, w)
+}
+
+// InterruptChildren sends SIGINT to the direct children of the current process and returns their pids.
+// Grandchildren are left alone: terragrunt forwards the signal to terraform, which aborts on a second interrupt.
+func InterruptChildren() ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	interrupted := []int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			// The process has exited in the meantime
+			continue
+		}
+		if getParentPid(stat) != os.Getpid() {
+			continue
+		}
+		if err := syscall.Kill(pid, syscall.SIGINT); err != nil {
+			return interrupted, err
+		}
+		interrupted = append(interrupted, pid)
+	}
+	return interrupted, nil
+}
+
+// The name of the command in /proc/<pid>/stat is between parentheses and can contain spaces,
+// it is followed by the state and the parent pid
+func getParentPid(stat []byte) int {
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 2 {
+		return -1
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return -1
+	}
+	return ppid
 }
 
 func UnsupportedCommand(cmd *cobra.Command, args []string) {
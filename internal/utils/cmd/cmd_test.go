@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestGetParentPid(t *testing.T) {
+	tt := []struct {
+		name string
+		stat string
+		want int
+	}{
+		{"simple", "1234 (terraform) S 42 1234 1 0", 42},
+		{"name with spaces and parentheses", "1234 (my (odd) cmd) R 7 1234 1 0", 7},
+		{"truncated", "1234 (terraform)", -1},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := getParentPid([]byte(tc.stat)); got != tc.want {
+				t.Errorf("getParentPid(%q) = %d, want %d", tc.stat, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInterruptChildren(t *testing.T) {
+	child := exec.Command("sleep", "30")
+	if err := child.Start(); err != nil {
+		t.Skipf("could not start child process: %s", err)
+	}
+	pids, err := InterruptChildren()
+	if err != nil {
+		t.Fatalf("InterruptChildren returned an error: %s", err)
+	}
+	found := false
+	for _, pid := range pids {
+		found = found || pid == child.Process.Pid
+	}
+	if !found {
+		t.Errorf("child %d was not interrupted, got %v", child.Process.Pid, pids)
+	}
+	err = child.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected the child to be interrupted, got %v", err)
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || status.Signal() != syscall.SIGINT {
+		t.Errorf("expected the child to be stopped by SIGINT, got %v", exitErr)
+	}
+}
@@ -0,0 +1,78 @@
+package driftschedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Layers are checked with a delay derived from their name, so that layers sharing a schedule are not
+// all planned at the same time. The delay is at most a tenth of the interval between two detections,
+// and at most MaxJitter.
+const MaxJitter = time.Hour
+
+type Schedule struct {
+	key        string
+	expression string
+	cron       cron.Schedule
+	period     time.Duration
+	jitter     bool
+}
+
+// New returns the drift detection schedule of the layer identified by key. A cron expression takes
+// precedence over the period, and drift is detected every defaultPeriod when none of them are set.
+func New(key string, expression string, period *metav1.Duration, defaultPeriod time.Duration) (*Schedule, error) {
+	if expression != "" {
+		schedule, err := cron.ParseStandard(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid drift detection schedule %q: %w", expression, err)
+		}
+		// The cron library returns the zero time for schedules which never fire, like February 30th
+		if schedule.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("invalid drift detection schedule %q: it never fires", expression)
+		}
+		return &Schedule{key: key, expression: expression, cron: schedule, jitter: true}, nil
+	}
+	if period != nil && period.Duration > 0 {
+		return Every(key, period.Duration), nil
+	}
+	return Every(key, defaultPeriod), nil
+}
+
+// Every returns the schedule of the layer identified by key detecting drift on a fixed period
+func Every(key string, period time.Duration) *Schedule {
+	return &Schedule{key: key, period: period, jitter: true}
+}
+
+// Next returns the date of the drift detection following the one made at last
+func (s *Schedule) Next(last time.Time) time.Time {
+	if s.cron == nil {
+		return last.Add(s.period + s.jitterOf(s.period))
+	}
+	next := s.cron.Next(last)
+	// The jitter is bounded by the interval to the following detection, so that it never skips it
+	return next.Add(s.jitterOf(s.cron.Next(next).Sub(next)))
+}
+
+func (s *Schedule) String() string {
+	if s.cron != nil {
+		return fmt.Sprintf("schedule %q", s.expression)
+	}
+	return fmt.Sprintf("every %s", s.period)
+}
+
+func (s *Schedule) jitterOf(interval time.Duration) time.Duration {
+	if !s.jitter {
+		return 0
+	}
+	limit := min(interval/10, MaxJitter)
+	if limit <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(s.key))
+	return time.Duration(h.Sum64() % uint64(limit))
+}
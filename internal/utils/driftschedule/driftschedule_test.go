@@ -0,0 +1,105 @@
+package driftschedule_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/padok-team/burrito/internal/utils/driftschedule"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testTime = "Mon May  8 11:21:53 UTC 2023"
+
+func TestDriftSchedule(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DriftSchedule Suite")
+}
+
+var _ = Describe("DriftSchedule", func() {
+	var last time.Time
+
+	BeforeEach(func() {
+		last, _ = time.Parse(time.UnixDate, testTime)
+	})
+
+	Context("Without schedule nor period", func() {
+		It("Should detect drift on the default period with a jitter of at most a tenth of it", func() {
+			schedule, err := driftschedule.New("default/my-layer", "", nil, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			next := schedule.Next(last)
+			Expect(next).To(BeTemporally(">=", last.Add(4*time.Hour)))
+			Expect(next).To(BeTemporally("<", last.Add(4*time.Hour+24*time.Minute)))
+			Expect(schedule.String()).To(Equal("every 4h0m0s"))
+		})
+
+		It("Should delay layers by the same jitter as with the same period", func() {
+			schedule, _ := driftschedule.New("default/my-layer", "", nil, 4*time.Hour)
+			Expect(schedule.Next(last)).To(Equal(driftschedule.Every("default/my-layer", 4*time.Hour).Next(last)))
+		})
+	})
+
+	Context("With a period", func() {
+		It("Should detect drift on the period with a jitter of at most a tenth of it", func() {
+			schedule, err := driftschedule.New("default/my-layer", "", &metav1.Duration{Duration: time.Hour}, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			next := schedule.Next(last)
+			Expect(next).To(BeTemporally(">=", last.Add(time.Hour)))
+			Expect(next).To(BeTemporally("<", last.Add(time.Hour+6*time.Minute)))
+		})
+
+		It("Should bound the jitter of long periods", func() {
+			schedule, err := driftschedule.New("default/my-layer", "", &metav1.Duration{Duration: 7 * 24 * time.Hour}, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.Next(last)).To(BeTemporally("<", last.Add(7*24*time.Hour+driftschedule.MaxJitter)))
+		})
+
+		It("Should use the default period when the period is zero", func() {
+			schedule, err := driftschedule.New("default/my-layer", "", &metav1.Duration{}, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.String()).To(Equal("every 4h0m0s"))
+		})
+	})
+
+	Context("With a cron schedule", func() {
+		It("Should detect drift on the next tick of the schedule", func() {
+			schedule, err := driftschedule.New("default/my-layer", "0 3 * * *", &metav1.Duration{Duration: time.Hour}, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			tick := time.Date(2023, time.May, 9, 3, 0, 0, 0, time.UTC)
+			next := schedule.Next(last)
+			Expect(next).To(BeTemporally(">=", tick))
+			Expect(next).To(BeTemporally("<", tick.Add(driftschedule.MaxJitter)))
+			Expect(schedule.String()).To(Equal(`schedule "0 3 * * *"`))
+		})
+
+		It("Should not skip a tick when the last detection was delayed by the jitter", func() {
+			schedule, err := driftschedule.New("default/my-layer", "0 * * * *", nil, 4*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			first := schedule.Next(last)
+			second := schedule.Next(first)
+			Expect(second.Sub(first)).To(Equal(time.Hour))
+		})
+
+		It("Should return an error for an invalid expression", func() {
+			_, err := driftschedule.New("default/my-layer", "every monday", nil, 4*time.Hour)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should return an error for an expression which never fires", func() {
+			_, err := driftschedule.New("default/my-layer", "0 0 30 2 *", nil, 4*time.Hour)
+			Expect(err).To(MatchError(ContainSubstring("never fires")))
+		})
+	})
+
+	Context("With several layers", func() {
+		It("Should delay them deterministically by different jitters", func() {
+			period := &metav1.Duration{Duration: time.Hour}
+			first, _ := driftschedule.New("default/layer-1", "", period, 4*time.Hour)
+			again, _ := driftschedule.New("default/layer-1", "", period, 4*time.Hour)
+			second, _ := driftschedule.New("default/layer-2", "", period, 4*time.Hour)
+			Expect(first.Next(last)).To(Equal(again.Next(last)))
+			Expect(first.Next(last)).NotTo(Equal(second.Next(last)))
+		})
+	})
+})
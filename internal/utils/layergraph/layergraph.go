@@ -0,0 +1,79 @@
+package layergraph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+// Key identifies a layer in the graph
+func Key(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// Dependencies returns the keys of the layers the given layer depends on
+func Dependencies(layer *configv1alpha1.TerraformLayer) []string {
+	keys := []string{}
+	for _, dependency := range layer.Spec.DependsOn {
+		namespace := dependency.Namespace
+		if namespace == "" {
+			namespace = layer.Namespace
+		}
+		keys = append(keys, Key(namespace, dependency.Name))
+	}
+	return keys
+}
+
+// A Graph maps each layer to the layers it depends on
+type Graph map[string][]string
+
+func New(layers []configv1alpha1.TerraformLayer) Graph {
+	graph := Graph{}
+	for _, layer := range layers {
+		graph[Key(layer.Namespace, layer.Name)] = Dependencies(&layer)
+	}
+	return graph
+}
+
+// FindCycle returns a cycle of dependencies going through the given layer, starting and
+// ending with it, or nil if there is none
+func (g Graph) FindCycle(key string) []string {
+	visited := map[string]bool{}
+	var walk func(path []string) []string
+	walk = func(path []string) []string {
+		current := path[len(path)-1]
+		for _, dependency := range g[current] {
+			if dependency == key {
+				return append(slices.Clone(path), key)
+			}
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+			if cycle := walk(append(path, dependency)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk([]string{key})
+}
+
+// Cycles returns the layers which are part of a cycle of dependencies, sorted
+func (g Graph) Cycles() []string {
+	layers := []string{}
+	for key := range g {
+		if g.FindCycle(key) != nil {
+			layers = append(layers, key)
+		}
+	}
+	slices.Sort(layers)
+	return layers
+}
+
+// FormatCycle returns a human-readable representation of a cycle
+func FormatCycle(cycle []string) string {
+	return strings.Join(cycle, " -> ")
+}
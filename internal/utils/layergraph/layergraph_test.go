@@ -0,0 +1,62 @@
+package layergraph_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/utils/layergraph"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLayerGraph(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LayerGraph Suite")
+}
+
+func layer(namespace, name string, dependsOn ...configv1alpha1.TerraformLayerReference) configv1alpha1.TerraformLayer {
+	return configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       configv1alpha1.TerraformLayerSpec{DependsOn: dependsOn},
+	}
+}
+
+var _ = Describe("LayerGraph", func() {
+	It("should default the namespace of dependencies to the one of the layer", func() {
+		l := layer("dev", "cluster",
+			configv1alpha1.TerraformLayerReference{Name: "network"},
+			configv1alpha1.TerraformLayerReference{Name: "dns", Namespace: "shared"},
+		)
+		Expect(layergraph.Dependencies(&l)).To(Equal([]string{"dev/network", "shared/dns"}))
+	})
+
+	It("should find no cycle in a directed acyclic graph", func() {
+		graph := layergraph.New([]configv1alpha1.TerraformLayer{
+			layer("dev", "network"),
+			layer("dev", "cluster", configv1alpha1.TerraformLayerReference{Name: "network"}),
+			layer("dev", "app",
+				configv1alpha1.TerraformLayerReference{Name: "network"},
+				configv1alpha1.TerraformLayerReference{Name: "cluster"},
+			),
+		})
+		Expect(graph.FindCycle("dev/app")).To(BeNil())
+		Expect(graph.Cycles()).To(BeEmpty())
+	})
+
+	It("should find the cycles going through a layer", func() {
+		graph := layergraph.New([]configv1alpha1.TerraformLayer{
+			layer("dev", "network", configv1alpha1.TerraformLayerReference{Name: "app"}),
+			layer("dev", "cluster", configv1alpha1.TerraformLayerReference{Name: "network"}),
+			layer("dev", "app", configv1alpha1.TerraformLayerReference{Name: "cluster"}),
+			layer("dev", "monitoring", configv1alpha1.TerraformLayerReference{Name: "app"}),
+			layer("dev", "self", configv1alpha1.TerraformLayerReference{Name: "self"}),
+		})
+		cycle := graph.FindCycle("dev/app")
+		Expect(cycle).To(Equal([]string{"dev/app", "dev/cluster", "dev/network", "dev/app"}))
+		Expect(layergraph.FormatCycle(cycle)).To(Equal("dev/app -> dev/cluster -> dev/network -> dev/app"))
+		// Depending on a cycle does not make a layer part of it
+		Expect(graph.FindCycle("dev/monitoring")).To(BeNil())
+		Expect(graph.Cycles()).To(Equal([]string{"dev/app", "dev/cluster", "dev/network", "dev/self"}))
+	})
+})
@@ -0,0 +1,101 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ComponentLabel = "burrito/component"
+	ManagedByLabel = "burrito/managed-by"
+	ComponentValue = "outputs"
+)
+
+// Publish writes the outputs selected by the layer to its Secret and ConfigMap. Outputs missing
+// from the apply are skipped, and objects which are not labelled as managed by the layer are
+// never updated.
+func Publish(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, outputs runnerutils.Outputs) error {
+	var errs []error
+	publication := layer.Spec.Outputs
+	if target := publication.Secret; target != nil {
+		values, err := outputs.Select(target.Outputs, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("some outputs are not published to Secret %s: %w", target.Name, err))
+		}
+		data := map[string][]byte{}
+		for name, value := range values {
+			data[name] = []byte(value)
+		}
+		secret := &corev1.Secret{}
+		err = publish(ctx, c, layer, "Secret", target.Name, secret, func() {
+			secret.Data = data
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if target := publication.ConfigMap; target != nil {
+		values, err := outputs.Select(target.Outputs, false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("some outputs are not published to ConfigMap %s: %w", target.Name, err))
+		}
+		configMap := &corev1.ConfigMap{}
+		err = publish(ctx, c, layer, "ConfigMap", target.Name, configMap, func() {
+			configMap.Data = values
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsManagedBy returns true when the object holds the outputs of the layer
+func IsManagedBy(obj client.Object, layer *configv1alpha1.TerraformLayer) bool {
+	labels := obj.GetLabels()
+	return labels[ComponentLabel] == ComponentValue && labels[ManagedByLabel] == layer.Name
+}
+
+func publish(ctx context.Context, c client.Client, layer *configv1alpha1.TerraformLayer, kind string, name string, obj client.Object, setData func()) error {
+	err := c.Get(ctx, client.ObjectKey{Namespace: layer.Namespace, Name: name}, obj)
+	exists := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("could not get %s %s: %w", kind, name, err)
+	}
+	if exists && !IsManagedBy(obj, layer) {
+		return fmt.Errorf("%s %s already exists and is not managed by layer %s, refusing to overwrite it", kind, name, layer.Name)
+	}
+	obj.SetName(name)
+	obj.SetNamespace(layer.Namespace)
+	obj.SetLabels(map[string]string{
+		ComponentLabel: ComponentValue,
+		ManagedByLabel: layer.Name,
+	})
+	// The published outputs are deleted with the layer
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: configv1alpha1.GroupVersion.String(),
+			Kind:       "TerraformLayer",
+			Name:       layer.Name,
+			UID:        layer.UID,
+		},
+	})
+	setData()
+	if exists {
+		err = c.Update(ctx, obj)
+	} else {
+		err = c.Create(ctx, obj)
+	}
+	if err != nil {
+		return fmt.Errorf("could not publish outputs to %s %s: %w", kind, name, err)
+	}
+	return nil
+}
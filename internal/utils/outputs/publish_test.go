@@ -0,0 +1,120 @@
+package outputs_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/utils/outputs"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOutputs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Outputs Suite")
+}
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	Expect(configv1alpha1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+var _ = Describe("Publish", func() {
+	var layer *configv1alpha1.TerraformLayer
+	var applied runnerutils.Outputs
+
+	BeforeEach(func() {
+		layer = &configv1alpha1.TerraformLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: "network", Namespace: "default", UID: "1234"},
+			Spec: configv1alpha1.TerraformLayerSpec{
+				Outputs: configv1alpha1.OutputsPublication{
+					Secret:    &configv1alpha1.OutputsTarget{Name: "network-secrets", Outputs: []string{"db_password"}},
+					ConfigMap: &configv1alpha1.OutputsTarget{Name: "network-outputs", Outputs: []string{"vpc_id"}},
+				},
+			},
+		}
+		var err error
+		applied, err = runnerutils.ParseOutputs([]byte(`{
+			"vpc_id": {"sensitive": false, "type": "string", "value": "vpc-0123"},
+			"db_password": {"sensitive": true, "type": "string", "value": "hunter2"}
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should create the Secret and the ConfigMap of the layer", func() {
+		c := fake.NewClientBuilder().WithScheme(newScheme()).Build()
+		Expect(outputs.Publish(context.Background(), c, layer, applied)).To(Succeed())
+
+		secret := &corev1.Secret{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "network-secrets"}, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("db_password", []byte("hunter2")))
+		Expect(outputs.IsManagedBy(secret, layer)).To(BeTrue())
+		Expect(secret.OwnerReferences).To(HaveLen(1))
+		Expect(secret.OwnerReferences[0].Kind).To(Equal("TerraformLayer"))
+
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "network-outputs"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{"vpc_id": "vpc-0123"}))
+	})
+
+	It("should update the objects it manages", func() {
+		existing := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "network-outputs",
+				Namespace: "default",
+				Labels:    map[string]string{outputs.ComponentLabel: outputs.ComponentValue, outputs.ManagedByLabel: "network"},
+			},
+			Data: map[string]string{"vpc_id": "vpc-old"},
+		}
+		c := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(existing).Build()
+		Expect(outputs.Publish(context.Background(), c, layer, applied)).To(Succeed())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "network-outputs"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{"vpc_id": "vpc-0123"}))
+	})
+
+	It("should refuse to overwrite an object which is not managed by the layer", func() {
+		existing := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "network-secrets", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("do-not-touch")},
+		}
+		other := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "network-outputs",
+				Namespace: "default",
+				Labels:    map[string]string{outputs.ComponentLabel: outputs.ComponentValue, outputs.ManagedByLabel: "another-layer"},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(existing, other).Build()
+		err := outputs.Publish(context.Background(), c, layer, applied)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Secret network-secrets already exists and is not managed by layer network"))
+		Expect(err.Error()).To(ContainSubstring("ConfigMap network-outputs already exists"))
+
+		secret := &corev1.Secret{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "network-secrets"}, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"token": []byte("do-not-touch")}))
+		Expect(secret.Labels).To(BeEmpty())
+		Expect(secret.OwnerReferences).To(BeEmpty())
+	})
+
+	It("should publish the available outputs when some are missing", func() {
+		layer.Spec.Outputs.ConfigMap.Outputs = []string{"vpc_id", "subnet_ids"}
+		c := fake.NewClientBuilder().WithScheme(newScheme()).Build()
+		Expect(outputs.Publish(context.Background(), c, layer, applied)).NotTo(Succeed())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "network-outputs"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("vpc_id", "vpc-0123"))
+	})
+})
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const PolicyFileExtension = ".cel"
+
+// A Policy is a CEL expression evaluated for each resource change of a plan.
+// It must return true for the change to be allowed.
+type Policy struct {
+	Name       string
+	Expression string
+}
+
+type Violation struct {
+	Policy  string `json:"policy"`
+	Address string `json:"address,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the result of the evaluation of the policies against a plan
+type Report struct {
+	Passed     bool        `json:"passed"`
+	Policies   []string    `json:"policies"`
+	Violations []Violation `json:"violations"`
+}
+
+// Load reads the policies from dir, where each ConfigMap is mounted in its own directory.
+// Policies are named after their ConfigMap and key, e.g. "org-policies/no-public-bucket.cel"
+func Load(dir string) ([]Policy, error) {
+	policies := []Policy{}
+	configMaps, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return policies, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range configMaps {
+		if !cm.IsDir() || strings.HasPrefix(cm.Name(), ".") {
+			continue
+		}
+		keys, err := os.ReadDir(filepath.Join(dir, cm.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			// ConfigMap volumes contain hidden directories next to the keys
+			if strings.HasPrefix(key.Name(), ".") || !strings.HasSuffix(key.Name(), PolicyFileExtension) {
+				continue
+			}
+			expression, err := os.ReadFile(filepath.Join(dir, cm.Name(), key.Name()))
+			if err != nil {
+				return nil, err
+			}
+			policies = append(policies, Policy{
+				Name:       fmt.Sprintf("%s/%s", cm.Name(), key.Name()),
+				Expression: string(expression),
+			})
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// Evaluate the policies against each resource change of the plan. The expressions have access to:
+//   - resource: the resource change, as found in the resource_changes of the JSON plan
+//   - plan: the whole JSON plan
+//
+// A policy which does not compile, or fails to evaluate, is reported as violated.
+func Evaluate(policies []Policy, plan *tfjson.Plan) (Report, error) {
+	report := Report{Passed: true, Policies: []string{}, Violations: []Violation{}}
+	env, err := cel.NewEnv(
+		cel.Variable("resource", cel.DynType),
+		cel.Variable("plan", cel.DynType),
+	)
+	if err != nil {
+		return report, err
+	}
+	planValue, err := toValue(plan)
+	if err != nil {
+		return report, err
+	}
+	for _, policy := range policies {
+		report.Policies = append(report.Policies, policy.Name)
+		ast, issues := env.Compile(policy.Expression)
+		if issues != nil && issues.Err() != nil {
+			report.add(Violation{Policy: policy.Name, Message: fmt.Sprintf("invalid policy: %s", issues.Err())})
+			continue
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			report.add(Violation{Policy: policy.Name, Message: fmt.Sprintf("invalid policy: %s", err)})
+			continue
+		}
+		for _, change := range plan.ResourceChanges {
+			if change.Change == nil || change.Change.Actions.NoOp() || change.Change.Actions.Read() {
+				continue
+			}
+			resourceValue, err := toValue(change)
+			if err != nil {
+				return report, err
+			}
+			out, _, err := program.Eval(map[string]any{
+				"resource": resourceValue,
+				"plan":     planValue,
+			})
+			if err != nil {
+				report.add(Violation{Policy: policy.Name, Address: change.Address, Message: fmt.Sprintf("could not evaluate policy: %s", err)})
+				continue
+			}
+			allowed, ok := out.Value().(bool)
+			if !ok {
+				report.add(Violation{Policy: policy.Name, Address: change.Address, Message: "policy must return a boolean"})
+				continue
+			}
+			if !allowed {
+				report.add(Violation{Policy: policy.Name, Address: change.Address, Message: "resource change is not allowed"})
+			}
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) add(violation Violation) {
+	r.Passed = false
+	r.Violations = append(r.Violations, violation)
+}
+
+// Convert a value to generic maps and lists, as found in the JSON plan
+func toValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
@@ -0,0 +1,110 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/padok-team/burrito/internal/utils/policy"
+)
+
+func TestPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policy Suite")
+}
+
+func change(address, resourceType string, actions tfjson.Actions, after map[string]any) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Type:    resourceType,
+		Change: &tfjson.Change{
+			Actions: actions,
+			After:   after,
+		},
+	}
+}
+
+var _ = Describe("Policy", func() {
+	noDatabaseDeletion := policy.Policy{
+		Name:       "org/no-database-deletion.cel",
+		Expression: `!(resource.type == "aws_db_instance" && "delete" in resource.change.actions)`,
+	}
+	allowedRegions := policy.Policy{
+		Name:       "org/allowed-regions.cel",
+		Expression: `!has(resource.change.after.region) || resource.change.after.region in ["eu-west-1", "eu-west-3"]`,
+	}
+
+	Describe("Evaluate", func() {
+		It("should pass when no change violates the policies", func() {
+			plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_db_instance.main", "aws_db_instance", tfjson.Actions{tfjson.ActionUpdate}, map[string]any{}),
+				change("aws_s3_bucket.logs", "aws_s3_bucket", tfjson.Actions{tfjson.ActionCreate}, map[string]any{"region": "eu-west-3"}),
+			}}
+			report, err := policy.Evaluate([]policy.Policy{noDatabaseDeletion, allowedRegions}, plan)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Passed).To(BeTrue())
+			Expect(report.Policies).To(Equal([]string{"org/no-database-deletion.cel", "org/allowed-regions.cel"}))
+			Expect(report.Violations).To(BeEmpty())
+		})
+
+		It("should report the changes violating the policies", func() {
+			plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_db_instance.main", "aws_db_instance", tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}, map[string]any{}),
+				change("aws_s3_bucket.logs", "aws_s3_bucket", tfjson.Actions{tfjson.ActionCreate}, map[string]any{"region": "us-east-1"}),
+			}}
+			report, err := policy.Evaluate([]policy.Policy{noDatabaseDeletion, allowedRegions}, plan)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Passed).To(BeFalse())
+			Expect(report.Violations).To(HaveLen(2))
+			Expect(report.Violations[0].Policy).To(Equal("org/no-database-deletion.cel"))
+			Expect(report.Violations[0].Address).To(Equal("aws_db_instance.main"))
+			Expect(report.Violations[1].Policy).To(Equal("org/allowed-regions.cel"))
+			Expect(report.Violations[1].Address).To(Equal("aws_s3_bucket.logs"))
+		})
+
+		It("should ignore resources without changes", func() {
+			plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_s3_bucket.logs", "aws_s3_bucket", tfjson.Actions{tfjson.ActionNoop}, map[string]any{"region": "us-east-1"}),
+			}}
+			report, err := policy.Evaluate([]policy.Policy{allowedRegions}, plan)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Passed).To(BeTrue())
+		})
+
+		It("should report invalid policies as violated", func() {
+			plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_s3_bucket.logs", "aws_s3_bucket", tfjson.Actions{tfjson.ActionCreate}, map[string]any{}),
+			}}
+			report, err := policy.Evaluate([]policy.Policy{
+				{Name: "org/invalid.cel", Expression: `resource.type ==`},
+				{Name: "org/not-a-boolean.cel", Expression: `resource.type`},
+			}, plan)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Passed).To(BeFalse())
+			Expect(report.Violations).To(HaveLen(2))
+			Expect(report.Violations[0].Message).To(HavePrefix("invalid policy"))
+			Expect(report.Violations[1].Message).To(Equal("policy must return a boolean"))
+		})
+	})
+
+	Describe("Load", func() {
+		It("should load the CEL policies of each ConfigMap", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(dir, "org", "..data"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "org", "regions.cel"), []byte(`true`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "org", "README.md"), []byte(`docs`), 0644)).To(Succeed())
+			policies, err := policy.Load(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(Equal([]policy.Policy{{Name: "org/regions.cel", Expression: "true"}}))
+		})
+
+		It("should return no policies when the directory does not exist", func() {
+			policies, err := policy.Load(filepath.Join(GinkgoT().TempDir(), "missing"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,234 @@
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const Mask = "[REDACTED]"
+
+// Sensitive values shorter than this are not redacted, they would mask unrelated text
+const minSensitiveValueLength = 6
+
+// DefaultPatterns match common cloud credentials and tokens, they are always applied
+var DefaultPatterns = []string{
+	// AWS access key IDs
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// GitHub tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`,
+	// GitLab personal access tokens
+	`\bglpat-[A-Za-z0-9_-]{20,}\b`,
+	// Slack tokens
+	`\bxox[baprs]-[A-Za-z0-9-]{10,}\b`,
+	// Google API keys
+	`\bAIza[0-9A-Za-z_-]{35}\b`,
+	// Private keys
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// A Redactor masks sensitive values and text matching patterns. It is safe for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// New returns a Redactor applying the default patterns and the given ones
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{values: map[string]bool{}}
+	for _, p := range append(append([]string{}, DefaultPatterns...), patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// LoadPatterns reads the patterns of each file of dir, one per line. Empty lines and
+// lines starting with # are ignored.
+func LoadPatterns(dir string) ([]string, error) {
+	patterns := []string{}
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		// ConfigMap volumes contain hidden directories next to the keys
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ParsePatterns(content)...)
+	}
+	return patterns, nil
+}
+
+// ParsePatterns returns the patterns of content, one per line. Empty lines and lines
+// starting with # are ignored.
+func ParsePatterns(content []byte) []string {
+	patterns := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// AddSensitiveValues registers the values marked as sensitive in the plan: sensitive
+// attributes of resources and outputs, and sensitive variables
+func (r *Redactor) AddSensitiveValues(plan *tfjson.Plan) {
+	values := []string{}
+	for _, res := range plan.ResourceChanges {
+		if res.Change == nil {
+			continue
+		}
+		values = collectSensitive(res.Change.Before, res.Change.BeforeSensitive, values)
+		values = collectSensitive(res.Change.After, res.Change.AfterSensitive, values)
+	}
+	for _, output := range plan.OutputChanges {
+		if output == nil {
+			continue
+		}
+		values = collectSensitive(output.Before, output.BeforeSensitive, values)
+		values = collectSensitive(output.After, output.AfterSensitive, values)
+	}
+	if plan.Config != nil && plan.Config.RootModule != nil {
+		for name, variable := range plan.Config.RootModule.Variables {
+			if variable == nil || !variable.Sensitive || plan.Variables[name] == nil {
+				continue
+			}
+			values = collectSensitive(plan.Variables[name].Value, true, values)
+		}
+	}
+	r.AddValues(values...)
+}
+
+// AddValues registers sensitive values, e.g. the values of variables taken from Secrets
+func (r *Redactor) AddValues(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSensitiveValueLength {
+			r.values[v] = true
+		}
+	}
+}
+
+// Values returns the sensitive values registered in the redactor
+func (r *Redactor) Values() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// Walk value along its sensitivity mask, which is either a boolean or has the same shape as value
+func collectSensitive(value, sensitive interface{}, values []string) []string {
+	if isSensitive, ok := sensitive.(bool); ok {
+		if isSensitive {
+			return collectStrings(value, values)
+		}
+		return values
+	}
+	switch mask := sensitive.(type) {
+	case map[string]interface{}:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return values
+		}
+		for key, m := range mask {
+			values = collectSensitive(object[key], m, values)
+		}
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok {
+			return values
+		}
+		for i, m := range mask {
+			if i < len(list) {
+				values = collectSensitive(list[i], m, values)
+			}
+		}
+	}
+	return values
+}
+
+func collectStrings(value interface{}, values []string) []string {
+	switch v := value.(type) {
+	case string:
+		values = append(values, v)
+	case map[string]interface{}:
+		for _, elt := range v {
+			values = collectStrings(elt, values)
+		}
+	case []interface{}:
+		for _, elt := range v {
+			values = collectStrings(elt, values)
+		}
+	}
+	return values
+}
+
+// Redact masks the sensitive values and the text matching the patterns in content.
+// It returns the redacted content and the number of redactions made.
+func (r *Redactor) Redact(content []byte) ([]byte, int) {
+	r.mu.RLock()
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	r.mu.RUnlock()
+	// Longest values first, so that values containing other ones are fully masked
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	count := 0
+	for _, v := range values {
+		// Values are also looked for in their JSON encoded form, as found in JSON plans
+		forms := []string{v}
+		if encoded, err := json.Marshal(v); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != v {
+				forms = append(forms, escaped)
+			}
+		}
+		for _, form := range forms {
+			if n := bytes.Count(content, []byte(form)); n > 0 {
+				count += n
+				content = bytes.ReplaceAll(content, []byte(form), []byte(Mask))
+			}
+		}
+	}
+	for _, re := range r.patterns {
+		content = re.ReplaceAllFunc(content, func([]byte) []byte {
+			count++
+			return []byte(Mask)
+		})
+	}
+	return content, count
+}
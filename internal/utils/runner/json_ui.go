@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of the events of the machine-readable UI of terraform used to follow the resources
+const (
+	JSONEventApplyStart    string = "apply_start"
+	JSONEventApplyComplete string = "apply_complete"
+	JSONEventApplyErrored  string = "apply_errored"
+	JSONEventChangeSummary string = "change_summary"
+)
+
+// States of the resources in the timeline of an apply
+const (
+	ResourceStateApplying string = "Applying"
+	ResourceStateApplied  string = "Applied"
+	ResourceStateFailed   string = "Failed"
+)
+
+// JSONEvent is a message of the machine-readable UI of terraform, written by plan and apply with -json.
+// Only the fields used by Burrito are decoded.
+type JSONEvent struct {
+	Level      string          `json:"@level"`
+	Message    string          `json:"@message"`
+	Timestamp  time.Time       `json:"@timestamp"`
+	Type       string          `json:"type"`
+	Hook       *JSONHook       `json:"hook,omitempty"`
+	Diagnostic *JSONDiagnostic `json:"diagnostic,omitempty"`
+	Changes    *JSONChanges    `json:"changes,omitempty"`
+}
+
+type JSONHook struct {
+	Resource       JSONResource `json:"resource"`
+	Action         string       `json:"action,omitempty"`
+	ElapsedSeconds float64      `json:"elapsed_seconds,omitempty"`
+}
+
+type JSONResource struct {
+	Addr string `json:"addr"`
+}
+
+type JSONChanges struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+type JSONDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+// JSONUIRecorder parses the machine-readable UI of terraform written to it. The human-readable
+// message of each event is written to out so that the logs stay readable, and the events are kept.
+// Lines which are not events, such as the logs of terragrunt, are written to out as they are.
+type JSONUIRecorder struct {
+	out     io.Writer
+	mutex   sync.Mutex
+	pending []byte
+	events  []JSONEvent
+}
+
+func NewJSONUIRecorder(out io.Writer) *JSONUIRecorder {
+	return &JSONUIRecorder{out: out, events: []JSONEvent{}}
+}
+
+func (r *JSONUIRecorder) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pending = append(r.pending, p...)
+	for {
+		end := bytes.IndexByte(r.pending, '\n')
+		if end < 0 {
+			break
+		}
+		line := r.pending[:end+1]
+		r.pending = r.pending[end+1:]
+		if err := r.record(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush records the last line written, if it did not end with a newline
+func (r *JSONUIRecorder) Flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	line := append(r.pending, '\n')
+	r.pending = nil
+	return r.record(line)
+}
+
+func (r *JSONUIRecorder) record(line []byte) error {
+	event := JSONEvent{}
+	if err := json.Unmarshal(line, &event); err != nil || event.Type == "" {
+		_, err := r.out.Write(line)
+		return err
+	}
+	r.events = append(r.events, event)
+	message := event.Message
+	if event.Diagnostic != nil && event.Diagnostic.Detail != "" {
+		message = fmt.Sprintf("%s\n\n%s", message, event.Diagnostic.Detail)
+	}
+	_, err := fmt.Fprintln(r.out, message)
+	return err
+}
+
+// Events returns a copy of the events recorded so far
+func (r *JSONUIRecorder) Events() []JSONEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]JSONEvent{}, r.events...)
+}
+
+// Produces the timeline of the resources of an apply from the events of the machine-readable UI,
+// in the order in which they started being applied
+func GetResourceTimeline(events []JSONEvent) []configv1alpha1.ResourceResult {
+	timeline := []configv1alpha1.ResourceResult{}
+	index := map[string]int{}
+	for _, event := range events {
+		if event.Hook == nil || event.Hook.Resource.Addr == "" {
+			continue
+		}
+		address := event.Hook.Resource.Addr
+		switch event.Type {
+		case JSONEventApplyStart:
+			index[address] = len(timeline)
+			timeline = append(timeline, configv1alpha1.ResourceResult{
+				Address:   address,
+				Action:    event.Hook.Action,
+				State:     ResourceStateApplying,
+				StartedAt: &metav1.Time{Time: event.Timestamp},
+			})
+		case JSONEventApplyComplete, JSONEventApplyErrored:
+			i, ok := index[address]
+			if !ok {
+				continue
+			}
+			timeline[i].State = ResourceStateApplied
+			if event.Type == JSONEventApplyErrored {
+				timeline[i].State = ResourceStateFailed
+			}
+			elapsed := time.Duration(event.Hook.ElapsedSeconds * float64(time.Second))
+			if elapsed == 0 {
+				elapsed = event.Timestamp.Sub(timeline[i].StartedAt.Time)
+			}
+			timeline[i].Duration = &metav1.Duration{Duration: elapsed}
+		}
+	}
+	return timeline
+}
+
+// LimitResourceTimeline keeps at most max resources of a timeline, the failed ones first then the
+// last ones to start being applied, in their order in the timeline. Returns the number of resources left out.
+func LimitResourceTimeline(timeline []configv1alpha1.ResourceResult, max int) ([]configv1alpha1.ResourceResult, int) {
+	if len(timeline) <= max {
+		return timeline, 0
+	}
+	kept := make([]bool, len(timeline))
+	count := 0
+	for i, res := range timeline {
+		if count < max && res.State == ResourceStateFailed {
+			kept[i] = true
+			count++
+		}
+	}
+	for i := len(timeline) - 1; i >= 0 && count < max; i-- {
+		if !kept[i] {
+			kept[i] = true
+			count++
+		}
+	}
+	limited := []configv1alpha1.ResourceResult{}
+	for i, res := range timeline {
+		if kept[i] {
+			limited = append(limited, res)
+		}
+	}
+	return limited, len(timeline) - len(limited)
+}
+
+// Returns the changes made by the applies which wrote the given events, nil if they did not report them
+func GetAppliedChanges(events []JSONEvent) *configv1alpha1.ChangeCounts {
+	var counts *configv1alpha1.ChangeCounts
+	for _, event := range events {
+		if event.Type != JSONEventChangeSummary || event.Changes == nil || event.Changes.Operation == "plan" {
+			continue
+		}
+		if counts == nil {
+			counts = &configv1alpha1.ChangeCounts{}
+		}
+		counts.Add += event.Changes.Add
+		counts.Change += event.Changes.Change
+		counts.Destroy += event.Changes.Remove
+	}
+	return counts
+}
@@ -0,0 +1,115 @@
+package runner_test
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+const applyEvents = `{"@level":"info","@message":"Terraform 1.7.5","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:00.000000Z","terraform":"1.7.5","type":"version","ui":"1.2"}
+{"@level":"info","@message":"random_pet.this: Creating...","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:01.000000Z","hook":{"resource":{"addr":"random_pet.this","module":"","resource":"random_pet.this","implied_provider":"random","resource_type":"random_pet","resource_name":"this","resource_key":null},"action":"create"},"type":"apply_start"}
+{"@level":"info","@message":"null_resource.that: Destroying...","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:01.500000Z","hook":{"resource":{"addr":"null_resource.that","module":"","resource":"null_resource.that","implied_provider":"null","resource_type":"null_resource","resource_name":"that","resource_key":null},"action":"delete"},"type":"apply_start"}
+{"@level":"info","@message":"random_pet.this: Creation complete after 2s [id=lucky-cat]","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:03.000000Z","hook":{"resource":{"addr":"random_pet.this","module":"","resource":"random_pet.this","implied_provider":"random","resource_type":"random_pet","resource_name":"this","resource_key":null},"action":"create","id_key":"id","id_value":"lucky-cat","elapsed_seconds":2},"type":"apply_complete"}
+{"@level":"error","@message":"null_resource.that: Destruction errored after 1s","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:02.500000Z","hook":{"resource":{"addr":"null_resource.that","module":"","resource":"null_resource.that","implied_provider":"null","resource_type":"null_resource","resource_name":"that","resource_key":null},"action":"delete","elapsed_seconds":1},"type":"apply_errored"}
+{"@level":"error","@message":"Error: could not destroy","@module":"terraform.ui","@timestamp":"2024-05-08T12:00:02.600000Z","diagnostic":{"severity":"error","summary":"could not destroy","detail":"the resource is protected","address":"null_resource.that"},"type":"diagnostic"}
+`
+
+var _ = Describe("JSON UI", func() {
+	var out *bytes.Buffer
+	var recorder *runnerutils.JSONUIRecorder
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		recorder = runnerutils.NewJSONUIRecorder(out)
+	})
+
+	It("should write the human-readable messages of the events", func() {
+		_, err := fmt.Fprint(recorder, applyEvents)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(HavePrefix("Terraform 1.7.5\nrandom_pet.this: Creating...\n"))
+		Expect(out.String()).To(HaveSuffix("Error: could not destroy\n\nthe resource is protected\n"))
+		Expect(recorder.Events()).To(HaveLen(6))
+	})
+
+	It("should write the lines which are not events as they are", func() {
+		_, err := fmt.Fprint(recorder, "time=12:00:00 level=info msg=Downloading sources\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(Equal("time=12:00:00 level=info msg=Downloading sources\n"))
+		Expect(recorder.Events()).To(BeEmpty())
+	})
+
+	It("should record events split across writes", func() {
+		line := `{"@level":"info","@message":"Apply complete!","@timestamp":"2024-05-08T12:00:04Z","type":"change_summary"}`
+		_, err := fmt.Fprint(recorder, line[:20])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(BeEmpty())
+		_, err = fmt.Fprint(recorder, line[20:])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Flush()).To(Succeed())
+		Expect(out.String()).To(Equal("Apply complete!\n"))
+		Expect(recorder.Events()).To(HaveLen(1))
+	})
+
+	It("should build the timeline of the resources", func() {
+		_, err := fmt.Fprint(recorder, applyEvents)
+		Expect(err).NotTo(HaveOccurred())
+		timeline := runnerutils.GetResourceTimeline(recorder.Events())
+		Expect(timeline).To(HaveLen(2))
+		Expect(timeline[0].Address).To(Equal("random_pet.this"))
+		Expect(timeline[0].Action).To(Equal("create"))
+		Expect(timeline[0].State).To(Equal(runnerutils.ResourceStateApplied))
+		Expect(timeline[0].StartedAt.Time).To(Equal(time.Date(2024, 5, 8, 12, 0, 1, 0, time.UTC)))
+		Expect(timeline[0].Duration.Duration).To(Equal(2 * time.Second))
+		Expect(timeline[1].Address).To(Equal("null_resource.that"))
+		Expect(timeline[1].State).To(Equal(runnerutils.ResourceStateFailed))
+		Expect(timeline[1].Duration.Duration).To(Equal(time.Second))
+	})
+
+	It("should count the changes made by the applies", func() {
+		_, err := fmt.Fprint(recorder, `{"@level":"info","@message":"Apply complete! Resources: 1 added, 0 changed, 1 destroyed.","@timestamp":"2024-05-08T12:00:04Z","changes":{"add":1,"change":0,"import":0,"remove":1,"operation":"apply"},"type":"change_summary"}
+{"@level":"info","@message":"Apply complete! Resources: 0 added, 2 changed, 0 destroyed.","@timestamp":"2024-05-08T12:00:05Z","changes":{"add":0,"change":2,"import":0,"remove":0,"operation":"apply"},"type":"change_summary"}
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runnerutils.GetAppliedChanges(recorder.Events())).To(Equal(&configv1alpha1.ChangeCounts{Add: 1, Change: 2, Destroy: 1}))
+	})
+
+	It("should not count the changes of plans", func() {
+		_, err := fmt.Fprint(recorder, `{"@level":"info","@message":"Plan: 1 to add, 0 to change, 0 to destroy.","@timestamp":"2024-05-08T12:00:04Z","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"plan"},"type":"change_summary"}
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runnerutils.GetAppliedChanges(recorder.Events())).To(BeNil())
+	})
+
+	It("should leave the resources being applied without a duration", func() {
+		lines := bytes.SplitAfter([]byte(applyEvents), []byte("\n"))
+		_, err := recorder.Write(bytes.Join(lines[:2], nil))
+		Expect(err).NotTo(HaveOccurred())
+		timeline := runnerutils.GetResourceTimeline(recorder.Events())
+		Expect(timeline).To(HaveLen(1))
+		Expect(timeline[0].State).To(Equal(runnerutils.ResourceStateApplying))
+		Expect(timeline[0].Duration).To(BeNil())
+	})
+
+	It("should keep the failed resources and the last ones when limiting the timeline", func() {
+		timeline := []configv1alpha1.ResourceResult{
+			{Address: "a", State: runnerutils.ResourceStateApplied},
+			{Address: "b", State: runnerutils.ResourceStateFailed},
+			{Address: "c", State: runnerutils.ResourceStateApplied},
+			{Address: "d", State: runnerutils.ResourceStateApplied},
+			{Address: "e", State: runnerutils.ResourceStateApplying},
+		}
+		limited, omitted := runnerutils.LimitResourceTimeline(timeline, 3)
+		Expect(omitted).To(Equal(2))
+		Expect(limited).To(HaveLen(3))
+		Expect([]string{limited[0].Address, limited[1].Address, limited[2].Address}).To(Equal([]string{"b", "d", "e"}))
+
+		limited, omitted = runnerutils.LimitResourceTimeline(timeline, 10)
+		Expect(omitted).To(BeZero())
+		Expect(limited).To(Equal(timeline))
+	})
+})
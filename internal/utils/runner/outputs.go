@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// An output of a layer, as returned by `output -json`
+type Output struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	// Value is omitted once redacted
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type Outputs map[string]Output
+
+func ParseOutputs(content []byte) (Outputs, error) {
+	outputs := Outputs{}
+	if err := json.Unmarshal(content, &outputs); err != nil {
+		return nil, fmt.Errorf("could not parse outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// Redact returns a copy of the outputs without the values of the sensitive ones
+func (o Outputs) Redact() Outputs {
+	redacted := Outputs{}
+	for name, output := range o {
+		if output.Sensitive {
+			output.Value = nil
+		}
+		redacted[name] = output
+	}
+	return redacted
+}
+
+// String returns the value of the output as it can be passed in a -var argument: strings are
+// returned as is, other values are encoded in JSON
+func (o Output) String() (string, error) {
+	var value string
+	if err := json.Unmarshal(o.Value, &value); err == nil {
+		return value, nil
+	}
+	if len(o.Value) == 0 {
+		return "", fmt.Errorf("the output has no value")
+	}
+	return string(o.Value), nil
+}
+
+// Select returns the values of the given outputs to publish. Sensitive outputs are refused
+// unless allowed. The outputs which cannot be published are reported in the error, the other
+// ones are returned anyway.
+func (o Outputs) Select(names []string, allowSensitive bool) (map[string]string, error) {
+	values := map[string]string{}
+	errs := []error{}
+	for _, name := range names {
+		output, ok := o[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("output %s does not exist", name))
+			continue
+		}
+		if output.Sensitive && !allowSensitive {
+			errs = append(errs, fmt.Errorf("output %s is sensitive, it can only be published to a Secret", name))
+			continue
+		}
+		value, err := output.String()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("output %s: %w", name, err))
+			continue
+		}
+		values[name] = value
+	}
+	return values, errors.Join(errs...)
+}
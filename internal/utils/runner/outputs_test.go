@@ -0,0 +1,61 @@
+package runner_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+var _ = Describe("Outputs", func() {
+	content := []byte(`{
+		"vpc_id": {"sensitive": false, "type": "string", "value": "vpc-0123"},
+		"subnets": {"sensitive": false, "type": ["list", "string"], "value": ["subnet-a", "subnet-b"]},
+		"db_password": {"sensitive": true, "type": "string", "value": "hunter2"}
+	}`)
+
+	It("should parse the outputs", func() {
+		outputs, err := runnerutils.ParseOutputs(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outputs).To(HaveLen(3))
+		Expect(outputs["db_password"].Sensitive).To(BeTrue())
+	})
+
+	It("should return strings as is and other values in JSON", func() {
+		outputs, err := runnerutils.ParseOutputs(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outputs["vpc_id"].String()).To(Equal("vpc-0123"))
+		Expect(outputs["subnets"].String()).To(Equal(`["subnet-a", "subnet-b"]`))
+	})
+
+	It("should only redact the values of sensitive outputs", func() {
+		outputs, err := runnerutils.ParseOutputs(content)
+		Expect(err).NotTo(HaveOccurred())
+		redacted := outputs.Redact()
+		Expect(redacted["db_password"].Value).To(BeNil())
+		Expect(redacted["db_password"].Sensitive).To(BeTrue())
+		Expect(redacted["vpc_id"].Value).To(Equal(outputs["vpc_id"].Value))
+		Expect(outputs["db_password"].Value).NotTo(BeNil())
+	})
+
+	It("should select the outputs to publish", func() {
+		outputs, err := runnerutils.ParseOutputs(content)
+		Expect(err).NotTo(HaveOccurred())
+		values, err := outputs.Select([]string{"vpc_id", "db_password"}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"vpc_id": "vpc-0123", "db_password": "hunter2"}))
+	})
+
+	It("should report the outputs which cannot be published and return the other ones", func() {
+		outputs, err := runnerutils.ParseOutputs(content)
+		Expect(err).NotTo(HaveOccurred())
+		values, err := outputs.Select([]string{"vpc_id", "db_password", "missing"}, false)
+		Expect(err).To(MatchError(ContainSubstring("output db_password is sensitive")))
+		Expect(err).To(MatchError(ContainSubstring("output missing does not exist")))
+		Expect(values).To(Equal(map[string]string{"vpc_id": "vpc-0123"}))
+	})
+
+	It("should refuse invalid outputs", func() {
+		_, err := runnerutils.ParseOutputs([]byte("not json"))
+		Expect(err).To(HaveOccurred())
+	})
+})
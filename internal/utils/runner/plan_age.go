@@ -0,0 +1,22 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// CheckPlanAge returns an error if a plan made at lastPlanDate, in the UnixDate format of the
+// layer annotations, is older than maxAge. A zero maxAge disables the check.
+func CheckPlanAge(lastPlanDate string, maxAge time.Duration, now time.Time) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	date, err := time.Parse(time.UnixDate, lastPlanDate)
+	if err != nil {
+		return fmt.Errorf("could not read the date of the plan: %w", err)
+	}
+	if age := now.Sub(date); age > maxAge {
+		return fmt.Errorf("the plan is %s old, more than the maximum of %s", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
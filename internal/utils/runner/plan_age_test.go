@@ -0,0 +1,31 @@
+package runner_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+var _ = Describe("Plan age", func() {
+	now := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+	lastPlanDate := now.Add(-time.Hour).Format(time.UnixDate)
+
+	It("should accept a plan younger than the maximum age", func() {
+		Expect(runnerutils.CheckPlanAge(lastPlanDate, 24*time.Hour, now)).To(Succeed())
+	})
+
+	It("should refuse a plan older than the maximum age", func() {
+		err := runnerutils.CheckPlanAge(lastPlanDate, 30*time.Minute, now)
+		Expect(err).To(MatchError(ContainSubstring("the plan is 1h0m0s old")))
+	})
+
+	It("should not check the age without a maximum age", func() {
+		Expect(runnerutils.CheckPlanAge("not a date", 0, now)).To(Succeed())
+	})
+
+	It("should refuse a plan without a valid date", func() {
+		Expect(runnerutils.CheckPlanAge("", time.Hour, now)).NotTo(Succeed())
+	})
+})
@@ -2,8 +2,10 @@ package runner
 
 import (
 	"fmt"
+	"sort"
 
 	tfjson "github.com/hashicorp/terraform-json"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 )
 
 // Produces a diff summary from the given plan
@@ -32,3 +34,138 @@ func GetDiff(plan *tfjson.Plan) (bool, string) {
 	}
 	return diff, fmt.Sprintf("Plan: %d to create, %d to update, %d to delete", create, update, delete)
 }
+
+// Returns the sorted addresses of the resources changed outside of Terraform in the given plan
+func GetDriftedResources(plan *tfjson.Plan) []string {
+	drifted := []string{}
+	for _, res := range plan.ResourceDrift {
+		if res.Change == nil || res.Change.Actions.NoOp() {
+			continue
+		}
+		drifted = append(drifted, res.Address)
+	}
+	sort.Strings(drifted)
+	return drifted
+}
+
+// PlanSummary is a structured summary of a plan, stored in the datastore next to the plan
+type PlanSummary struct {
+	Create    int               `json:"create"`
+	Update    int               `json:"update"`
+	Delete    int               `json:"delete"`
+	Replace   int               `json:"replace"`
+	Resources []ResourceSummary `json:"resources"`
+	Outputs   []OutputSummary   `json:"outputs"`
+}
+
+type ResourceSummary struct {
+	Address string   `json:"address"`
+	Actions []string `json:"actions"`
+	Replace bool     `json:"replace"`
+}
+
+type OutputSummary struct {
+	Name      string   `json:"name"`
+	Actions   []string `json:"actions"`
+	Sensitive bool     `json:"sensitive"`
+}
+
+// ChangeCounts returns the numbers of resources to add, change and destroy, as counted in the plans of terraform
+func (s PlanSummary) ChangeCounts() configv1alpha1.ChangeCounts {
+	return configv1alpha1.ChangeCounts{
+		Add:     s.Create + s.Replace,
+		Change:  s.Update,
+		Destroy: s.Delete + s.Replace,
+	}
+}
+
+// HasChanges returns true if the plan changes resources or outputs
+func (s PlanSummary) HasChanges() bool {
+	return len(s.Resources) > 0 || len(s.Outputs) > 0
+}
+
+// Produces a structured summary from the given plan, resources and outputs without changes are left out,
+// as well as data sources read during the apply
+func GetPlanSummary(plan *tfjson.Plan) PlanSummary {
+	summary := PlanSummary{Resources: []ResourceSummary{}, Outputs: []OutputSummary{}}
+	for _, res := range plan.ResourceChanges {
+		if res.Change == nil || res.Change.Actions.NoOp() || res.Change.Actions.Read() {
+			continue
+		}
+		replace := res.Change.Actions.Replace()
+		switch {
+		case replace:
+			summary.Replace++
+		case res.Change.Actions.Create():
+			summary.Create++
+		case res.Change.Actions.Update():
+			summary.Update++
+		case res.Change.Actions.Delete():
+			summary.Delete++
+		}
+		summary.Resources = append(summary.Resources, ResourceSummary{
+			Address: res.Address,
+			Actions: actionsToStrings(res.Change.Actions),
+			Replace: replace,
+		})
+	}
+	for name, output := range plan.OutputChanges {
+		if output == nil || output.Actions.NoOp() {
+			continue
+		}
+		summary.Outputs = append(summary.Outputs, OutputSummary{
+			Name:      name,
+			Actions:   actionsToStrings(output.Actions),
+			Sensitive: isSensitive(output.BeforeSensitive) || isSensitive(output.AfterSensitive),
+		})
+	}
+	sort.Slice(summary.Resources, func(i, j int) bool { return summary.Resources[i].Address < summary.Resources[j].Address })
+	sort.Slice(summary.Outputs, func(i, j int) bool { return summary.Outputs[i].Name < summary.Outputs[j].Name })
+	return summary
+}
+
+func actionsToStrings(actions tfjson.Actions) []string {
+	result := []string{}
+	for _, action := range actions {
+		result = append(result, string(action))
+	}
+	return result
+}
+
+// Sensitive values of outputs are reported as a boolean in the JSON plan
+func isSensitive(value interface{}) bool {
+	sensitive, ok := value.(bool)
+	return ok && sensitive
+}
+
+// ShortDiff returns the short diff of the plan, in the same format as GetDiff
+func (s PlanSummary) ShortDiff() string {
+	return fmt.Sprintf("Plan: %d to create, %d to update, %d to delete", s.Create+s.Replace, s.Update, s.Delete+s.Replace)
+}
+
+// MergePlanSummaries merges the summaries of the plans of the units of a terragrunt stack,
+// the addresses of resources and names of outputs are prefixed with the path of their unit
+func MergePlanSummaries(summaries map[string]PlanSummary) PlanSummary {
+	merged := PlanSummary{Resources: []ResourceSummary{}, Outputs: []OutputSummary{}}
+	units := []string{}
+	for unit := range summaries {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	for _, unit := range units {
+		summary := summaries[unit]
+		merged.Create += summary.Create
+		merged.Update += summary.Update
+		merged.Delete += summary.Delete
+		merged.Replace += summary.Replace
+		for _, res := range summary.Resources {
+			res.Address = fmt.Sprintf("%s/%s", unit, res.Address)
+			merged.Resources = append(merged.Resources, res)
+		}
+		for _, output := range summary.Outputs {
+			output.Name = fmt.Sprintf("%s/%s", unit, output.Name)
+			merged.Outputs = append(merged.Outputs, output)
+		}
+	}
+	return merged
+}
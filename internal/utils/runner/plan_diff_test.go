@@ -0,0 +1,98 @@
+package runner_test
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+func TestRunnerUtils(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runner Utils Suite")
+}
+
+var _ = Describe("Plan summary", func() {
+	It("should list the changed resources and outputs", func() {
+		plan := &tfjson.Plan{
+			ResourceChanges: []*tfjson.ResourceChange{
+				{Address: "random_pet.b", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}}},
+				{Address: "random_pet.a", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}}},
+				{Address: "random_pet.c", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+				{Address: "random_pet.d", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}}},
+			},
+			OutputChanges: map[string]*tfjson.Change{
+				"name":     {Actions: tfjson.Actions{tfjson.ActionUpdate}, AfterSensitive: true},
+				"constant": {Actions: tfjson.Actions{tfjson.ActionNoop}},
+			},
+		}
+		summary := runnerutils.GetPlanSummary(plan)
+		Expect(summary.HasChanges()).To(BeTrue())
+		Expect(summary.Create).To(Equal(1))
+		Expect(summary.Update).To(Equal(0))
+		Expect(summary.Delete).To(Equal(1))
+		Expect(summary.Replace).To(Equal(1))
+		Expect(summary.Resources).To(Equal([]runnerutils.ResourceSummary{
+			{Address: "random_pet.a", Actions: []string{"delete", "create"}, Replace: true},
+			{Address: "random_pet.b", Actions: []string{"create"}, Replace: false},
+			{Address: "random_pet.d", Actions: []string{"delete"}, Replace: false},
+		}))
+		Expect(summary.Outputs).To(Equal([]runnerutils.OutputSummary{
+			{Name: "name", Actions: []string{"update"}, Sensitive: true},
+		}))
+		Expect(summary.ChangeCounts()).To(Equal(configv1alpha1.ChangeCounts{Add: 2, Change: 0, Destroy: 2}))
+	})
+
+	It("should report plans without changes", func() {
+		plan := &tfjson.Plan{
+			ResourceChanges: []*tfjson.ResourceChange{
+				{Address: "random_pet.a", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+				{Address: "data.aws_caller_identity.current", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionRead}}},
+			},
+		}
+		summary := runnerutils.GetPlanSummary(plan)
+		Expect(summary.HasChanges()).To(BeFalse())
+		Expect(summary.Resources).To(BeEmpty())
+	})
+
+	It("should merge the summaries of the units of a stack", func() {
+		summary := runnerutils.MergePlanSummaries(map[string]runnerutils.PlanSummary{
+			"network/vpc": {
+				Create:    1,
+				Resources: []runnerutils.ResourceSummary{{Address: "aws_vpc.main", Actions: []string{"create"}}},
+				Outputs:   []runnerutils.OutputSummary{{Name: "id", Actions: []string{"create"}}},
+			},
+			"app": {
+				Replace:   1,
+				Resources: []runnerutils.ResourceSummary{{Address: "random_pet.a", Actions: []string{"delete", "create"}, Replace: true}},
+				Outputs:   []runnerutils.OutputSummary{},
+			},
+		})
+		Expect(summary.ShortDiff()).To(Equal("Plan: 2 to create, 0 to update, 1 to delete"))
+		Expect(summary.Resources).To(Equal([]runnerutils.ResourceSummary{
+			{Address: "app/random_pet.a", Actions: []string{"delete", "create"}, Replace: true},
+			{Address: "network/vpc/aws_vpc.main", Actions: []string{"create"}},
+		}))
+		Expect(summary.Outputs).To(Equal([]runnerutils.OutputSummary{
+			{Name: "network/vpc/id", Actions: []string{"create"}},
+		}))
+	})
+})
+
+var _ = Describe("Drifted resources", func() {
+	It("should list the resources changed outside of Terraform", func() {
+		plan := &tfjson.Plan{
+			ResourceDrift: []*tfjson.ResourceChange{
+				{Address: "aws_s3_bucket.logs", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}}},
+				{Address: "aws_instance.web", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}}},
+				{Address: "aws_iam_role.ci", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+				{Address: "aws_vpc.main"},
+			},
+		}
+		Expect(runnerutils.GetDriftedResources(plan)).To(Equal([]string{"aws_instance.web", "aws_s3_bucket.logs"}))
+		Expect(runnerutils.GetDriftedResources(&tfjson.Plan{})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,274 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Providers are cached with the unpacked layout of Terraform plugin cache directories:
+// <hostname>/<namespace>/<type>/<version>/<os>_<arch>/
+const pluginCachePackageDepth = 5
+
+// Prefix of the directories being written to or removed from the shared cache
+const pluginCacheTempPrefix = ".burrito-"
+
+// The Terraform plugin cache is not safe for concurrent use, so runners do not use the
+// shared cache directly. Each runner uses a local cache in which the packages of the shared
+// cache are linked, and publishes the packages it downloaded in the shared cache afterwards.
+
+// LinkPluginCache links each provider package of the shared cache in the local cache
+func LinkPluginCache(sharedDir string, localDir string) error {
+	// Terraform ignores the plugin cache directory if it does not exist
+	err := os.MkdirAll(localDir, 0755)
+	if err != nil {
+		return err
+	}
+	packages, err := listPluginCachePackages(sharedDir)
+	if err != nil {
+		return err
+	}
+	linked := 0
+	now := time.Now()
+	for _, pkg := range packages {
+		target := filepath.Join(localDir, pkg)
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		// Marked as used before being linked, so that the garbage collection of another
+		// runner does not remove a package this runner is about to use
+		source := filepath.Join(sharedDir, pkg)
+		err = os.Chtimes(source, now, now)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed by a garbage collection in the meantime, it is downloaded instead
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.Symlink(source, target)
+		if err != nil {
+			return err
+		}
+		linked++
+	}
+	log.Infof("linked %d cached provider packages", linked)
+	return nil
+}
+
+// PublishPluginCache copies the provider packages downloaded in the local cache to the
+// shared cache. Packages are written to a temporary directory then renamed, so that other
+// runners never see a partially written package, and the first runner to publish a package wins.
+func PublishPluginCache(localDir string, sharedDir string) error {
+	packages, err := listPluginCachePackages(localDir)
+	if err != nil {
+		return err
+	}
+	published := 0
+	for _, pkg := range packages {
+		source := filepath.Join(localDir, pkg)
+		info, err := os.Lstat(source)
+		if err != nil {
+			return err
+		}
+		// Linked from the shared cache
+		if info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		target := filepath.Join(sharedDir, pkg)
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		tmp, err := os.MkdirTemp(filepath.Dir(target), pluginCacheTempPrefix)
+		if err != nil {
+			return err
+		}
+		err = os.Chmod(tmp, 0755)
+		if err == nil {
+			err = copyDir(source, tmp)
+		}
+		if err == nil {
+			err = os.Rename(tmp, target)
+		}
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+			if _, statErr := os.Stat(target); statErr == nil {
+				// Published by another runner in the meantime
+				continue
+			}
+			return fmt.Errorf("could not publish provider package %s: %w", pkg, err)
+		}
+		published++
+	}
+	log.Infof("published %d provider packages to the shared cache", published)
+	return nil
+}
+
+// TouchUsedPluginCache marks the packages of the shared cache installed in the .terraform
+// directories found under workingDir as used, so that they are not garbage collected
+func TouchUsedPluginCache(workingDir string, sharedDir string) error {
+	sharedDir, err := filepath.EvalSymlinks(sharedDir)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return filepath.WalkDir(workingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Name() != "providers" || filepath.Base(filepath.Dir(path)) != ".terraform" {
+			return nil
+		}
+		packages, err := listPluginCachePackages(path)
+		if err != nil {
+			return err
+		}
+		for _, pkg := range packages {
+			resolved, err := filepath.EvalSymlinks(filepath.Join(path, pkg))
+			if err != nil || !strings.HasPrefix(resolved, sharedDir+string(filepath.Separator)) {
+				continue
+			}
+			err = os.Chtimes(resolved, now, now)
+			if err != nil {
+				return err
+			}
+		}
+		return filepath.SkipDir
+	})
+}
+
+// GarbageCollectPluginCache removes the packages of the shared cache which have not been
+// used for maxAge, as well as temporary directories left by runners which were interrupted
+func GarbageCollectPluginCache(sharedDir string, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	deadline := time.Now().Add(-maxAge)
+	removed := 0
+	err := filepath.WalkDir(sharedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == sharedDir {
+			return nil
+		}
+		relative, err := filepath.Rel(sharedDir, path)
+		if err != nil {
+			return err
+		}
+		depth := len(strings.Split(relative, string(filepath.Separator)))
+		isTemp := strings.HasPrefix(d.Name(), pluginCacheTempPrefix)
+		if !isTemp && depth < pluginCachePackageDepth {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(deadline) {
+			// Renamed first so that other runners do not link a package being removed
+			trash := filepath.Join(filepath.Dir(path), fmt.Sprintf("%s%s-%d", pluginCacheTempPrefix, d.Name(), time.Now().UnixNano()))
+			if isTemp {
+				trash = path
+			} else if err := os.Rename(path, trash); err != nil {
+				return err
+			}
+			if err := os.RemoveAll(trash); err != nil {
+				return err
+			}
+			if !isTemp {
+				removed++
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("removed %d unused provider packages from the shared cache", removed)
+	return nil
+}
+
+// List the provider packages of a cache directory, relative to it
+func listPluginCachePackages(dir string) ([]string, error) {
+	pattern := filepath.Join(append([]string{dir}, strings.Split(strings.Repeat("*", pluginCachePackageDepth), "")...)...)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	packages := []string{}
+	for _, match := range matches {
+		relative, err := filepath.Rel(dir, match)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(relative, pluginCacheTempPrefix) {
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		packages = append(packages, relative)
+	}
+	return packages, nil
+}
+
+func copyDir(source string, target string) error {
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		destination := filepath.Join(target, relative)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(destination, info.Mode().Perm())
+		}
+		return copyFile(path, destination, info.Mode().Perm())
+	})
+}
+
+func copyFile(source string, destination string, mode os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
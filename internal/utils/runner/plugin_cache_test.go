@@ -0,0 +1,90 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+const randomProvider = "registry.terraform.io/hashicorp/random/3.6.0/linux_amd64"
+const nullProvider = "registry.terraform.io/hashicorp/null/3.2.2/linux_amd64"
+
+func writeProvider(dir string, pkg string) {
+	Expect(os.MkdirAll(filepath.Join(dir, pkg), 0755)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(dir, pkg, "terraform-provider"), []byte(pkg), 0755)).To(Succeed())
+}
+
+var _ = Describe("Plugin cache", func() {
+	var shared, local string
+
+	BeforeEach(func() {
+		shared = GinkgoT().TempDir()
+		local = GinkgoT().TempDir()
+	})
+
+	It("should link the shared packages and publish the downloaded ones", func() {
+		writeProvider(shared, randomProvider)
+		Expect(runnerutils.LinkPluginCache(shared, local)).To(Succeed())
+		info, err := os.Lstat(filepath.Join(local, randomProvider))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & os.ModeSymlink).NotTo(BeZero())
+
+		// Downloaded by terraform init
+		writeProvider(local, nullProvider)
+		Expect(runnerutils.PublishPluginCache(local, shared)).To(Succeed())
+		content, err := os.ReadFile(filepath.Join(shared, nullProvider, "terraform-provider"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal(nullProvider))
+		entries, err := os.ReadDir(filepath.Dir(filepath.Join(shared, nullProvider)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("should keep the package published first", func() {
+		writeProvider(local, nullProvider)
+		Expect(os.MkdirAll(filepath.Join(shared, nullProvider), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(shared, nullProvider, "terraform-provider"), []byte("first"), 0755)).To(Succeed())
+		Expect(runnerutils.PublishPluginCache(local, shared)).To(Succeed())
+		content, err := os.ReadFile(filepath.Join(shared, nullProvider, "terraform-provider"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("first"))
+	})
+
+	It("should remove the packages which have not been used recently", func() {
+		writeProvider(shared, randomProvider)
+		writeProvider(shared, nullProvider)
+		Expect(runnerutils.LinkPluginCache(shared, local)).To(Succeed())
+		// Linked long ago
+		old := time.Now().Add(-48 * time.Hour)
+		Expect(os.Chtimes(filepath.Join(shared, randomProvider), old, old)).To(Succeed())
+		Expect(os.Chtimes(filepath.Join(shared, nullProvider), old, old)).To(Succeed())
+
+		// The null provider is installed in the working directory from the cache
+		workingDir := GinkgoT().TempDir()
+		installed := filepath.Join(workingDir, ".terraform", "providers", nullProvider)
+		Expect(os.MkdirAll(filepath.Dir(installed), 0755)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(local, nullProvider), installed)).To(Succeed())
+		Expect(runnerutils.TouchUsedPluginCache(workingDir, shared)).To(Succeed())
+
+		Expect(runnerutils.GarbageCollectPluginCache(shared, 24*time.Hour)).To(Succeed())
+		Expect(filepath.Join(shared, randomProvider)).NotTo(BeADirectory())
+		Expect(filepath.Join(shared, nullProvider)).To(BeADirectory())
+		entries, err := os.ReadDir(filepath.Dir(filepath.Join(shared, randomProvider)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should not remove the packages linked by another runner", func() {
+		writeProvider(shared, randomProvider)
+		old := time.Now().Add(-48 * time.Hour)
+		Expect(os.Chtimes(filepath.Join(shared, randomProvider), old, old)).To(Succeed())
+
+		Expect(runnerutils.LinkPluginCache(shared, local)).To(Succeed())
+		Expect(runnerutils.GarbageCollectPluginCache(shared, 24*time.Hour)).To(Succeed())
+		Expect(filepath.Join(local, randomProvider)).To(BeADirectory())
+	})
+})
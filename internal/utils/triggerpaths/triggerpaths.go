@@ -0,0 +1,277 @@
+package triggerpaths
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const terragruntFile = "terragrunt.hcl"
+
+var moduleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+var moduleBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}},
+}
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}},
+}
+
+var includeBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "path"}},
+}
+
+// A resolver walks the configuration of a layer in the files of a repository, following
+// local module sources and terragrunt sources and includes
+type resolver struct {
+	files   fs.FS
+	parser  *hclparse.Parser
+	layer   string
+	visited map[string]bool
+	paths   map[string]bool
+}
+
+// Compute returns the paths of the repository, outside of the layer directory, that the
+// configuration of the layer depends on: the directories of the local modules it calls,
+// recursively, and the terragrunt configurations and sources it uses.
+// The paths are relative to the root of the repository and sorted.
+func Compute(files fs.FS, layerPath string) ([]string, error) {
+	r := &resolver{
+		files:   files,
+		parser:  hclparse.NewParser(),
+		layer:   clean(layerPath),
+		visited: map[string]bool{},
+		paths:   map[string]bool{},
+	}
+	if err := r.walkTerragrunt(r.layer, path.Join(r.layer, terragruntFile)); err != nil {
+		return nil, err
+	}
+	if err := r.walkModule(r.layer); err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for p := range r.paths {
+		if p != r.layer && !strings.HasPrefix(p, r.layer+"/") {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// Follows the local module sources of the terraform files of dir
+func (r *resolver) walkModule(dir string) error {
+	if r.visited[dir] {
+		return nil
+	}
+	r.visited[dir] = true
+	entries, err := fs.ReadDir(r.files, clean(dir))
+	if err != nil {
+		log.Warnf("could not read module directory %s: %s", dir, err)
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := path.Join(dir, entry.Name())
+		var file *hcl.File
+		switch {
+		case strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tofu"):
+			file, err = r.parseFile(name, r.parser.ParseHCL)
+		case strings.HasSuffix(name, ".tf.json") || strings.HasSuffix(name, ".tofu.json"):
+			file, err = r.parseFile(name, r.parser.ParseJSON)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			continue
+		}
+		content, _, _ := file.Body.PartialContent(moduleSchema)
+		for _, block := range content.Blocks {
+			attributes, _, _ := block.Body.PartialContent(moduleBlockSchema)
+			source, ok := evaluateString(attributes.Attributes["source"], nil)
+			if !ok || !isLocalModuleSource(source) {
+				continue
+			}
+			module := clean(path.Join(dir, source))
+			r.paths[module] = true
+			if err := r.walkModule(module); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Follows the terraform source and the includes of the terragrunt configuration at file.
+// Included configurations are evaluated in the context of the layer, as terragrunt does.
+func (r *resolver) walkTerragrunt(dir, file string) error {
+	if r.visited[file] {
+		return nil
+	}
+	r.visited[file] = true
+	if _, err := fs.Stat(r.files, clean(file)); err != nil {
+		return nil
+	}
+	r.paths[file] = true
+	config, err := r.parseFile(file, r.parser.ParseHCL)
+	if err != nil || config == nil {
+		return err
+	}
+	body, ok := config.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	ctx := r.terragruntContext(dir, path.Dir(file))
+	// Blocks are read from the syntax tree since include blocks may or may not have a label
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "terraform":
+			attributes, _, _ := block.Body.PartialContent(terraformBlockSchema)
+			source, ok := evaluateString(attributes.Attributes["source"], ctx)
+			if !ok || !isLocalTerragruntSource(source) {
+				continue
+			}
+			// With root//subdir, terragrunt copies the whole root and runs terraform in subdir
+			root, subdir, _ := strings.Cut(strings.TrimLeft(source, "/"), "//")
+			if strings.HasPrefix(source, "/") {
+				root = "/" + root
+			}
+			root = resolve(path.Dir(file), root)
+			r.paths[root] = true
+			if err := r.walkModule(clean(path.Join(root, subdir))); err != nil {
+				return err
+			}
+		case "include":
+			attributes, _, _ := block.Body.PartialContent(includeBlockSchema)
+			include, ok := evaluateString(attributes.Attributes["path"], ctx)
+			if !ok {
+				continue
+			}
+			if err := r.walkTerragrunt(dir, resolve(path.Dir(file), include)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Parses a file of the repository, returns nil if it can not be parsed
+func (r *resolver) parseFile(name string, parse func([]byte, string) (*hcl.File, hcl.Diagnostics)) (*hcl.File, error) {
+	src, err := fs.ReadFile(r.files, clean(name))
+	if err != nil {
+		return nil, err
+	}
+	file, diags := parse(src, name)
+	if diags.HasErrors() {
+		log.Warnf("could not parse %s, its dependencies are ignored: %s", name, diags.Error())
+		return nil, nil
+	}
+	return file, nil
+}
+
+// Evaluation context of terragrunt configurations, with the functions used to locate files.
+// Paths are absolute, the root of the repository being /.
+func (r *resolver) terragruntContext(dir, configDir string) *hcl.EvalContext {
+	stringFunc := func(value string) function.Function {
+		return function.New(&function.Spec{
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.StringVal(value), nil
+			},
+		})
+	}
+	findInParentFolders := function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := terragruntFile
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			for current := path.Dir(dir); ; current = path.Dir(current) {
+				candidate := path.Join(current, name)
+				if _, err := fs.Stat(r.files, clean(candidate)); err == nil {
+					return cty.StringVal("/" + candidate), nil
+				}
+				if current == "." || current == "/" {
+					break
+				}
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, fs.ErrNotExist
+		},
+	})
+	return &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			"find_in_parent_folders":      findInParentFolders,
+			"get_terragrunt_dir":          stringFunc("/" + dir),
+			"get_original_terragrunt_dir": stringFunc("/" + dir),
+			"get_parent_terragrunt_dir":   stringFunc("/" + configDir),
+			"get_repo_root":               stringFunc("/"),
+			"get_path_to_repo_root":       stringFunc(relativeToRoot(dir)),
+		},
+	}
+}
+
+func evaluateString(attribute *hcl.Attribute, ctx *hcl.EvalContext) (string, bool) {
+	if attribute == nil {
+		return "", false
+	}
+	value, diags := attribute.Expr.Value(ctx)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+func isLocalTerragruntSource(source string) bool {
+	return isLocalModuleSource(source) || strings.HasPrefix(source, "/")
+}
+
+// Resolves a path relative to dir, or absolute from the root of the repository
+func resolve(dir, p string) string {
+	if path.IsAbs(p) {
+		return clean(p)
+	}
+	return clean(path.Join(dir, p))
+}
+
+// Returns the path relative to the root of the repository, without leading or trailing slash
+func clean(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+func relativeToRoot(dir string) string {
+	if dir == "." {
+		return "."
+	}
+	return strings.TrimSuffix(strings.Repeat("../", strings.Count(dir, "/")+1), "/")
+}
@@ -0,0 +1,135 @@
+package triggerpaths_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/padok-team/burrito/internal/utils/triggerpaths"
+)
+
+func TestTriggerPaths(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TriggerPaths Suite")
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+var _ = Describe("TriggerPaths", func() {
+	Describe("Terraform layers", func() {
+		files := fstest.MapFS{
+			"layers/app/main.tf": file(`
+module "network" {
+  source = "../../modules/network"
+}
+module "local" {
+  source = "./local"
+}
+module "registry" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+module "git" {
+  source = "git::https://example.com/modules.git//vpc"
+}
+`),
+			"layers/app/local/main.tf": file(`
+module "shared" {
+  source = "../../../modules/shared"
+}
+`),
+			"modules/network/main.tf.json": file(`{"module": {"subnets": {"source": "../subnets"}}}`),
+			"modules/subnets/main.tf":      file(`resource "null_resource" "this" {}`),
+			"modules/shared/main.tf": file(`
+module "network" {
+  source = "../network"
+}
+`),
+			"modules/unused/main.tf": file(`resource "null_resource" "this" {}`),
+		}
+
+		It("should resolve local module sources recursively", func() {
+			paths, err := triggerpaths.Compute(files, "layers/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"modules/network", "modules/shared", "modules/subnets"}))
+		})
+
+		It("should accept layer paths with leading and trailing slashes", func() {
+			paths, err := triggerpaths.Compute(files, "/layers/app/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"modules/network", "modules/shared", "modules/subnets"}))
+		})
+
+		It("should ignore files which can not be parsed", func() {
+			paths, err := triggerpaths.Compute(fstest.MapFS{
+				"layers/app/main.tf":  file(`module "broken" {`),
+				"layers/app/other.tf": file(`module "ok" { source = "../../modules/ok" }`),
+			}, "layers/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"modules/ok"}))
+		})
+
+		It("should return no path for a missing layer", func() {
+			paths, err := triggerpaths.Compute(files, "layers/missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(BeEmpty())
+		})
+	})
+
+	Describe("Terragrunt layers", func() {
+		files := fstest.MapFS{
+			"live/root.hcl": file(`
+remote_state {
+  backend = "s3"
+}
+`),
+			"live/common.hcl": file(`
+terraform {
+  source = "${get_repo_root()}/modules//app"
+}
+`),
+			"live/dev/app/terragrunt.hcl": file(`
+include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+include "common" {
+  path   = "${get_terragrunt_dir()}/../../common.hcl"
+  expose = true
+}
+inputs = {
+  name = dependency.other.outputs.name
+}
+`),
+			"live/dev/other/terragrunt.hcl": file(`
+include {
+  path = find_in_parent_folders("root.hcl")
+}
+terraform {
+  source = "../../../modules/other"
+}
+`),
+			"modules/app/main.tf": file(`
+module "shared" {
+  source = "../shared"
+}
+`),
+			"modules/shared/main.tf": file(`resource "null_resource" "this" {}`),
+			"modules/other/main.tf":  file(`resource "null_resource" "this" {}`),
+		}
+
+		It("should resolve includes and terraform sources", func() {
+			paths, err := triggerpaths.Compute(files, "live/dev/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"live/common.hcl", "live/root.hcl", "modules", "modules/shared"}))
+		})
+
+		It("should resolve legacy includes and relative sources", func() {
+			paths, err := triggerpaths.Compute(files, "live/dev/other")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"live/root.hcl", "modules/other"}))
+		})
+	})
+})
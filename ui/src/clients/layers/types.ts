@@ -18,6 +18,7 @@ export type Layer = {
   manualSyncStatus: ManualSyncStatus;
   isPR: boolean;
   hasValidPlan: boolean;
+  partialPlan: boolean;
   autoApply: boolean;
 };
 
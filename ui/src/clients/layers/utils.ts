@@ -10,6 +10,9 @@ export const getApplyTooltip = (layer: Layer) => {
   if (!layer.hasValidPlan) {
     return 'No valid plan available. Run a plan first before applying.';
   }
+  if (layer.partialPlan) {
+    return 'Apply the partial plan, restricted by targets or replaces';
+  }
   return 'Apply';
 };
 
@@ -13,3 +13,26 @@ export const fetchLogs = async (
   );
   return response.data;
 };
+
+// Follow the logs of a running attempt, returns a function to stop following
+export const streamLogs = (
+  namespace: string,
+  layer: string,
+  runId: string,
+  attemptId: number,
+  onLine: (line: string) => void,
+  onEnd: () => void
+) => {
+  const source = new EventSource(
+    `${import.meta.env.VITE_API_BASE_URL}/logs/${namespace}/${layer}/${runId}/${attemptId}/stream`,
+    { withCredentials: true }
+  );
+  source.onmessage = (event) => onLine(event.data);
+  const close = () => {
+    source.close();
+    onEnd();
+  };
+  source.addEventListener('end', close);
+  source.addEventListener('error', close);
+  return () => source.close();
+};
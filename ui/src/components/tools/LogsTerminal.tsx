@@ -4,7 +4,7 @@ import { useQuery } from '@tanstack/react-query';
 import { Tooltip } from 'react-tooltip';
 
 import { fetchAttempts } from '@/clients/runs/client';
-import { fetchLogs } from '@/clients/logs/client';
+import { fetchLogs, streamLogs } from '@/clients/logs/client';
 import { reactQueryKeys } from '@/clients/reactQueryConfig';
 
 import AttemptsDropdown from '@/components/dropdowns/AttemptsDropdown';
@@ -27,7 +27,7 @@ export interface LogsTerminalProps {
 const LogsTerminal: React.FC<LogsTerminalProps> = ({
   className,
   variant = 'light',
-  layer: { namespace, name },
+  layer: { namespace, name, isRunning, lastRun },
   run
 }) => {
   const styles = {
@@ -48,6 +48,7 @@ const LogsTerminal: React.FC<LogsTerminalProps> = ({
   triggers the useEffect and resets the selectedAttempts and activeAttempt states. */
   const [displayLogsCopiedTooltip, setDisplayLogsCopiedTooltip] =
     useState<boolean>(false);
+  const [streamedLogs, setStreamedLogs] = useState<string[] | null>(null);
 
   const attemptsQuery = useQuery({
     queryKey: reactQueryKeys.attempts(namespace, name, run),
@@ -70,9 +71,36 @@ const LogsTerminal: React.FC<LogsTerminalProps> = ({
     }
   }, [attemptsQuery.data]);
 
+  // Follow the logs of the latest attempt while the run is in progress
+  useEffect(() => {
+    setStreamedLogs(null);
+    if (
+      !isRunning ||
+      lastRun?.id !== run ||
+      activeAttempt === null ||
+      !attemptsQuery.data ||
+      activeAttempt !== attemptsQuery.data.count - 1
+    ) {
+      return;
+    }
+    setStreamedLogs([]);
+    return streamLogs(
+      namespace,
+      name,
+      run,
+      activeAttempt,
+      (line) => setStreamedLogs((logs) => [...(logs ?? []), line]),
+      () => logsQuery.refetch()
+    );
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [namespace, name, run, activeAttempt, isRunning, attemptsQuery.data]);
+
+  const logs =
+    streamedLogs ?? (logsQuery.isSuccess ? logsQuery.data.results : []);
+
   const handleCopy = () => {
-    if (logsQuery.isSuccess) {
-      navigator.clipboard.writeText(logsQuery.data.results.join('\n'));
+    if (logs.length > 0) {
+      navigator.clipboard.writeText(logs.join('\n'));
       setDisplayLogsCopiedTooltip(true);
     }
   };
@@ -165,8 +193,7 @@ const LogsTerminal: React.FC<LogsTerminalProps> = ({
         <table>
           <tbody>
             {activeAttempt !== null &&
-              logsQuery.isSuccess &&
-              logsQuery.data.results.map((log, i) => (
+              logs.map((log, i) => (
                 <tr key={i}>
                   <td
                     className={`